@@ -19,3 +19,9 @@ type Action interface {
 	Resume() (interface{}, error)
 	Cancel() error
 }
+
+// CorrelatedAction is implemented by actions that start child processes;
+// returned copy passes correlation ID to them
+type CorrelatedAction interface {
+	WithCorrelationID(correlationID string) Action
+}
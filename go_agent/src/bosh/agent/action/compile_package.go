@@ -5,11 +5,13 @@ import (
 
 	boshmodels "bosh/agent/applier/models"
 	boshcomp "bosh/agent/compiler"
+	boshcrypto "bosh/crypto"
 	bosherr "bosh/errors"
 )
 
 type CompilePackageAction struct {
-	compiler boshcomp.Compiler
+	compiler      boshcomp.Compiler
+	correlationID string
 }
 
 func NewCompilePackage(compiler boshcomp.Compiler) (compilePackage CompilePackageAction) {
@@ -17,6 +19,11 @@ func NewCompilePackage(compiler boshcomp.Compiler) (compilePackage CompilePackag
 	return
 }
 
+func (a CompilePackageAction) WithCorrelationID(correlationID string) Action {
+	a.correlationID = correlationID
+	return a
+}
+
 func (a CompilePackageAction) IsAsynchronous() bool {
 	return true
 }
@@ -25,12 +32,19 @@ func (a CompilePackageAction) IsPersistent() bool {
 	return false
 }
 
-func (a CompilePackageAction) Run(blobID, sha1, name, version string, deps boshcomp.Dependencies) (val map[string]interface{}, err error) {
+// Run accepts optional detached signature of package blob after dependencies
+func (a CompilePackageAction) Run(blobID, sha1, name, version string, deps boshcomp.Dependencies, signature ...string) (val map[string]interface{}, err error) {
 	pkg := boshcomp.Package{
 		BlobstoreID: blobID,
 		Name:        name,
 		Sha1:        sha1,
 		Version:     version,
+
+		CorrelationID: a.correlationID,
+	}
+
+	if len(signature) > 0 {
+		pkg.Signature = signature[0]
 	}
 
 	modelsDeps := []boshmodels.Package{}
@@ -42,11 +56,12 @@ func (a CompilePackageAction) Run(blobID, sha1, name, version string, deps boshc
 			Source: boshmodels.Source{
 				Sha1:        dep.Sha1,
 				BlobstoreID: dep.BlobstoreID,
+				Signature:   dep.Signature,
 			},
 		})
 	}
 
-	uploadedBlobID, uploadedSha1, err := a.compiler.Compile(pkg, modelsDeps)
+	uploadedBlobID, uploadedDigest, err := a.compiler.Compile(pkg, modelsDeps)
 	if err != nil {
 		err = bosherr.WrapError(err, "Compiling package %s", pkg.Name)
 		return
@@ -54,7 +69,8 @@ func (a CompilePackageAction) Run(blobID, sha1, name, version string, deps boshc
 
 	result := map[string]string{
 		"blobstore_id": uploadedBlobID,
-		"sha1":         uploadedSha1,
+		"sha1":         plainSHA1(uploadedDigest),
+		"multi_digest": uploadedDigest,
 	}
 
 	val = map[string]interface{}{
@@ -63,6 +79,22 @@ func (a CompilePackageAction) Run(blobID, sha1, name, version string, deps boshc
 	return
 }
 
+// plainSHA1 keeps sha1 result readable by directors
+// that do not understand multiple digests
+func plainSHA1(fingerprint string) string {
+	digests, err := boshcrypto.ParseMultipleDigest(fingerprint)
+	if err != nil {
+		return fingerprint
+	}
+
+	digest, found := digests.Digest(boshcrypto.DigestAlgorithmSHA1)
+	if !found {
+		return fingerprint
+	}
+
+	return digest.Value
+}
+
 func (a CompilePackageAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
@@ -69,6 +69,7 @@ var _ = Describe("CompilePackageAction", func() {
 				"result": map[string]string{
 					"blobstore_id": "my-blob-id",
 					"sha1":         "some sha1",
+					"multi_digest": "some sha1",
 				},
 			}
 
@@ -99,6 +100,50 @@ var _ = Describe("CompilePackageAction", func() {
 			Expect(expectedDeps).To(Equal(compiler.CompileDeps))
 		})
 
+		It("returns plain sha1 next to multiple digest of compiled package", func() {
+			compiler.CompileBlobID = "my-blob-id"
+			compiler.CompileSha1 = "sha1:da39a3ee5e6b4b0d3255bfef95601890afd80709;" +
+				"sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+			value, err := action.Run(getCompileActionArguments())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(map[string]interface{}{
+				"result": map[string]string{
+					"blobstore_id": "my-blob-id",
+					"sha1":         "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+					"multi_digest": compiler.CompileSha1,
+				},
+			}))
+		})
+
+		It("passes signatures of package and its dependencies to compiler", func() {
+			blobID, sha1, name, version, _ := getCompileActionArguments()
+
+			deps := boshcomp.Dependencies{
+				"first_dep": boshcomp.Package{
+					BlobstoreID: "first_dep_blobstore_id",
+					Name:        "first_dep",
+					Sha1:        "first_dep_sha1",
+					Version:     "first_dep_version",
+					Signature:   "fake-key-id:fake-dep-signature",
+				},
+			}
+
+			_, err := action.Run(blobID, sha1, name, version, deps, "fake-key-id:fake-signature")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(compiler.CompilePkg.Signature).To(Equal("fake-key-id:fake-signature"))
+			Expect(compiler.CompileDeps).To(HaveLen(1))
+			Expect(compiler.CompileDeps[0].Source.Signature).To(Equal("fake-key-id:fake-dep-signature"))
+		})
+
+		It("compiles unsigned package when signature is not given", func() {
+			_, err := action.Run(getCompileActionArguments())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(compiler.CompilePkg.Signature).To(BeEmpty())
+		})
+
 		It("returns error when compile fails", func() {
 			compiler.CompileErr = errors.New("fake-compile-error")
 
@@ -10,9 +10,11 @@ import (
 	bosherr "bosh/errors"
 	boshjobsuper "bosh/jobsupervisor"
 	boshlog "bosh/logger"
+	boshmbus "bosh/mbus"
 	boshnotif "bosh/notification"
 	boshplatform "bosh/platform"
 	boshntp "bosh/platform/ntp"
+	boshvitals "bosh/platform/vitals"
 	boshsettings "bosh/settings"
 )
 
@@ -31,19 +33,23 @@ func NewFactory(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	specService boshas.V1Service,
 	drainScriptProvider boshdrain.DrainScriptProvider,
+	vitalsHistory boshvitals.History,
+	ntpService boshntp.Service,
+	mbusStatusProvider boshmbus.ConnectionStatusProvider,
+	blobCacheStatsProvider boshblob.CacheStatsProvider,
 	logger boshlog.Logger,
 ) (factory Factory) {
 	compressor := platform.GetCompressor()
 	copier := platform.GetCopier()
 	dirProvider := platform.GetDirProvider()
 	vitalsService := platform.GetVitalsService()
-	ntpService := boshntp.NewConcreteService(platform.GetFs(), dirProvider)
 
 	factory = concreteFactory{
 		availableActions: map[string]Action{
 			// Task management
 			"ping":        NewPing(),
 			"get_task":    NewGetTask(taskService),
+			"list_tasks":  NewListTasks(taskService),
 			"cancel_task": NewCancelTask(taskService),
 
 			// VM admin
@@ -56,9 +62,12 @@ func NewFactory(
 			"start":      NewStart(jobSupervisor),
 			"stop":       NewStop(jobSupervisor),
 			"drain":      NewDrain(notifier, specService, drainScriptProvider, jobSupervisor),
-			"get_state":  NewGetState(settingsService, specService, jobSupervisor, vitalsService, ntpService),
+			"get_state":  NewGetState(settingsService, specService, jobSupervisor, vitalsService, ntpService, mbusStatusProvider, blobCacheStatsProvider),
 			"run_errand": NewRunErrand(specService, dirProvider.JobsDir(), platform.GetRunner(), logger),
 
+			// Monitoring
+			"get_vitals_history": NewGetVitalsHistory(vitalsHistory),
+
 			// Compilation
 			"compile_package":    NewCompilePackage(compiler),
 			"release_apply_spec": NewReleaseApplySpec(platform),
@@ -13,9 +13,11 @@ import (
 	fakeblobstore "bosh/blobstore/fakes"
 	fakejobsuper "bosh/jobsupervisor/fakes"
 	boshlog "bosh/logger"
+	fakembus "bosh/mbus/fakes"
 	fakenotif "bosh/notification/fakes"
 	fakeplatform "bosh/platform/fakes"
-	boshntp "bosh/platform/ntp"
+	fakentp "bosh/platform/ntp/fakes"
+	fakevitals "bosh/platform/vitals/fakes"
 	fakesettings "bosh/settings/fakes"
 )
 
@@ -31,6 +33,10 @@ var _ = Describe("concreteFactory", func() {
 		jobSupervisor       *fakejobsuper.FakeJobSupervisor
 		specService         *fakeas.FakeV1Service
 		drainScriptProvider boshdrain.DrainScriptProvider
+		vitalsHistory       *fakevitals.FakeHistory
+		ntpService          *fakentp.FakeService
+		mbusStatusProvider  *fakembus.FakeConnectionStatusProvider
+		blobCacheStats      *fakeblobstore.FakeCacheStatsProvider
 		factory             Factory
 		logger              boshlog.Logger
 	)
@@ -46,6 +52,10 @@ var _ = Describe("concreteFactory", func() {
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 		specService = fakeas.NewFakeV1Service()
 		drainScriptProvider = boshdrain.NewConcreteDrainScriptProvider(nil, nil, platform.GetDirProvider())
+		vitalsHistory = &fakevitals.FakeHistory{}
+		ntpService = &fakentp.FakeService{}
+		mbusStatusProvider = &fakembus.FakeConnectionStatusProvider{}
+		blobCacheStats = &fakeblobstore.FakeCacheStatsProvider{}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 
 		factory = NewFactory(
@@ -59,6 +69,10 @@ var _ = Describe("concreteFactory", func() {
 			jobSupervisor,
 			specService,
 			drainScriptProvider,
+			vitalsHistory,
+			ntpService,
+			mbusStatusProvider,
+			blobCacheStats,
 			logger,
 		)
 	})
@@ -93,17 +107,28 @@ var _ = Describe("concreteFactory", func() {
 		Expect(action).To(Equal(NewGetTask(taskService)))
 	})
 
+	It("list_tasks", func() {
+		action, err := factory.Create("list_tasks")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewListTasks(taskService)))
+	})
+
 	It("cancel_task", func() {
 		action, err := factory.Create("cancel_task")
 		Expect(err).ToNot(HaveOccurred())
 		Expect(action).To(Equal(NewCancelTask(taskService)))
 	})
 
+	It("get_vitals_history", func() {
+		action, err := factory.Create("get_vitals_history")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewGetVitalsHistory(vitalsHistory)))
+	})
+
 	It("get_state", func() {
-		ntpService := boshntp.NewConcreteService(platform.GetFs(), platform.GetDirProvider())
 		action, err := factory.Create("get_state")
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService(), ntpService)))
+		Expect(action).To(Equal(NewGetState(settingsService, specService, jobSupervisor, platform.GetVitalsService(), ntpService, mbusStatusProvider, blobCacheStats)))
 	})
 
 	It("list_disk", func() {
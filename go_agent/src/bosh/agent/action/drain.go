@@ -15,6 +15,7 @@ type DrainAction struct {
 	notifier            boshnotif.Notifier
 	specService         boshas.V1Service
 	jobSupervisor       boshjobsuper.JobSupervisor
+	correlationID       string
 }
 
 func NewDrain(
@@ -30,6 +31,11 @@ func NewDrain(
 	return
 }
 
+func (a DrainAction) WithCorrelationID(correlationID string) Action {
+	a.correlationID = correlationID
+	return a
+}
+
 func (a DrainAction) IsAsynchronous() bool {
 	return true
 }
@@ -100,6 +106,10 @@ func (a DrainAction) Run(drainType DrainType, newSpecs ...boshas.V1ApplySpec) (i
 		return 0, nil
 	}
 
+	if a.correlationID != "" {
+		params = boshdrain.NewCorrelatedDrainParams(params, a.correlationID)
+	}
+
 	value, err := drainScript.Run(params)
 	if err != nil {
 		return 0, bosherr.WrapError(err, "Running Drain Script")
@@ -4,8 +4,10 @@ import (
 	"errors"
 
 	boshas "bosh/agent/applier/applyspec"
+	boshblob "bosh/blobstore"
 	bosherr "bosh/errors"
 	boshjobsuper "bosh/jobsupervisor"
+	boshmbus "bosh/mbus"
 	boshntp "bosh/platform/ntp"
 	boshvitals "bosh/platform/vitals"
 	boshsettings "bosh/settings"
@@ -17,6 +19,12 @@ type GetStateAction struct {
 	jobSupervisor   boshjobsuper.JobSupervisor
 	vitalsService   boshvitals.Service
 	ntpService      boshntp.Service
+
+	// Optional since not all message bus handlers maintain connection
+	mbusStatusProvider boshmbus.ConnectionStatusProvider
+
+	// Optional since blob cache can be disabled
+	blobCacheStatsProvider boshblob.CacheStatsProvider
 }
 
 func NewGetState(
@@ -25,12 +33,16 @@ func NewGetState(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	vitalsService boshvitals.Service,
 	ntpService boshntp.Service,
+	mbusStatusProvider boshmbus.ConnectionStatusProvider,
+	blobCacheStatsProvider boshblob.CacheStatsProvider,
 ) (action GetStateAction) {
 	action.settingsService = settingsService
 	action.specService = specService
 	action.jobSupervisor = jobSupervisor
 	action.vitalsService = vitalsService
 	action.ntpService = ntpService
+	action.mbusStatusProvider = mbusStatusProvider
+	action.blobCacheStatsProvider = blobCacheStatsProvider
 	return
 }
 
@@ -51,6 +63,9 @@ type GetStateV1ApplySpec struct {
 	Vitals       *boshvitals.Vitals `json:"vitals,omitempty"`
 	VM           boshsettings.VM    `json:"vm"`
 	Ntp          boshntp.NTPInfo    `json:"ntp"`
+
+	MbusConnection *boshmbus.ConnectionStatus `json:"mbus_connection,omitempty"`
+	BlobCache      *boshblob.CacheStats       `json:"blob_cache,omitempty"`
 }
 
 func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
@@ -63,13 +78,27 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 	var vitalsReference *boshvitals.Vitals
 
 	if len(filters) > 0 && filters[0] == "full" {
-		vitals, err = a.vitalsService.Get()
+		vitals, err = a.vitalsService.GetExtended("get_state")
 		if err != nil {
 			return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Building full vitals")
 		}
 		vitalsReference = &vitals
 	}
 
+	var mbusConnection *boshmbus.ConnectionStatus
+
+	if a.mbusStatusProvider != nil {
+		status := a.mbusStatusProvider.GetConnectionStatus()
+		mbusConnection = &status
+	}
+
+	var blobCache *boshblob.CacheStats
+
+	if a.blobCacheStatsProvider != nil {
+		stats := a.blobCacheStatsProvider.GetCacheStats()
+		blobCache = &stats
+	}
+
 	settings := a.settingsService.GetSettings()
 
 	value := GetStateV1ApplySpec{
@@ -80,6 +109,8 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 		vitalsReference,
 		settings.VM,
 		a.ntpService.GetInfo(),
+		mbusConnection,
+		blobCache,
 	}
 
 	if value.NetworkSpecs == nil {
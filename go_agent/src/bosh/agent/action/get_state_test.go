@@ -10,7 +10,11 @@ import (
 	boshas "bosh/agent/applier/applyspec"
 	fakeas "bosh/agent/applier/applyspec/fakes"
 	boshassert "bosh/assert"
+	boshblob "bosh/blobstore"
+	fakeblob "bosh/blobstore/fakes"
 	fakejobsuper "bosh/jobsupervisor/fakes"
+	boshmbus "bosh/mbus"
+	fakembus "bosh/mbus/fakes"
 	boshntp "bosh/platform/ntp"
 	fakentp "bosh/platform/ntp/fakes"
 	boshvitals "bosh/platform/vitals"
@@ -25,6 +29,7 @@ var _ = Describe("GetState", func() {
 		specService     *fakeas.FakeV1Service
 		jobSupervisor   *fakejobsuper.FakeJobSupervisor
 		vitalsService   *fakevitals.FakeService
+		ntpService      *fakentp.FakeService
 		action          GetStateAction
 	)
 
@@ -33,13 +38,13 @@ var _ = Describe("GetState", func() {
 		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 		specService = fakeas.NewFakeV1Service()
 		vitalsService = fakevitals.NewFakeService()
-		ntpService := &fakentp.FakeService{
+		ntpService = &fakentp.FakeService{
 			GetOffsetNTPOffset: boshntp.NTPInfo{
 				Offset:    "0.34958",
 				Timestamp: "12 Oct 17:37:58",
 			},
 		}
-		action = NewGetState(settingsService, specService, jobSupervisor, vitalsService, ntpService)
+		action = NewGetState(settingsService, specService, jobSupervisor, vitalsService, ntpService, nil, nil)
 	})
 
 	It("get state should be synchronous", func() {
@@ -103,8 +108,11 @@ var _ = Describe("GetState", func() {
 
 					expectedVitals := boshvitals.Vitals{
 						Load: []string{"foo", "bar", "baz"},
+						Uptime: &boshvitals.UptimeVitals{
+							Secs: "fake-uptime-secs",
+						},
 					}
-					vitalsService.GetVitals = expectedVitals
+					vitalsService.GetExtendedVitals = expectedVitals
 					expectedVM := map[string]interface{}{"name": "vm-abc-def"}
 
 					state, err := action.Run("full")
@@ -114,6 +122,7 @@ var _ = Describe("GetState", func() {
 					boshassert.MatchesJSONString(GinkgoT(), state.JobState, `"running"`)
 					boshassert.MatchesJSONString(GinkgoT(), state.Deployment, `"fake-deployment"`)
 					Expect(*state.Vitals).To(Equal(expectedVitals))
+					Expect(vitalsService.GetExtendedConsumer).To(Equal("get_state"))
 					boshassert.MatchesJSONMap(GinkgoT(), state.VM, expectedVM)
 				})
 
@@ -167,7 +176,7 @@ var _ = Describe("GetState", func() {
 
 			Context("when vitals cannot be retrieved", func() {
 				It("returns error", func() {
-					vitalsService.GetErr = errors.New("fake-vitals-get-error")
+					vitalsService.GetExtendedErr = errors.New("fake-vitals-get-error")
 
 					_, err := action.Run("full")
 					Expect(err).To(HaveOccurred())
@@ -176,6 +185,63 @@ var _ = Describe("GetState", func() {
 			})
 		})
 
+		Context("when message bus connection status is available", func() {
+			BeforeEach(func() {
+				mbusStatusProvider := &fakembus.FakeConnectionStatusProvider{
+					Status: boshmbus.ConnectionStatus{
+						State:      boshmbus.ConnectionStateReconnecting,
+						Reconnects: 3,
+					},
+				}
+				action = NewGetState(settingsService, specService, jobSupervisor, vitalsService, ntpService, mbusStatusProvider, nil)
+			})
+
+			It("returns connection state and reconnect count", func() {
+				state, err := action.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(*state.MbusConnection).To(Equal(boshmbus.ConnectionStatus{
+					State:      boshmbus.ConnectionStateReconnecting,
+					Reconnects: 3,
+				}))
+				boshassert.MatchesJSONString(GinkgoT(), state.MbusConnection, `{"state":"reconnecting","reconnects":3}`)
+			})
+		})
+
+		Context("when message bus connection status is not available", func() {
+			It("does not include connection status", func() {
+				state, err := action.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				boshassert.LacksJSONKey(GinkgoT(), state, "mbus_connection")
+			})
+		})
+
+		Context("when blob cache stats are available", func() {
+			BeforeEach(func() {
+				blobCacheStatsProvider := &fakeblob.FakeCacheStatsProvider{
+					Stats: boshblob.CacheStats{Hits: 5, Misses: 2, Entries: 1, SizeBytes: 1024},
+				}
+				action = NewGetState(settingsService, specService, jobSupervisor, vitalsService, ntpService, nil, blobCacheStatsProvider)
+			})
+
+			It("returns cache hits, misses and size", func() {
+				state, err := action.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				boshassert.MatchesJSONString(GinkgoT(), state.BlobCache, `{"hits":5,"misses":2,"entries":1,"size_bytes":1024}`)
+			})
+		})
+
+		Context("when blob cache stats are not available", func() {
+			It("does not include blob cache stats", func() {
+				state, err := action.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				boshassert.LacksJSONKey(GinkgoT(), state, "blob_cache")
+			})
+		})
+
 		Context("when current spec cannot be retrieved", func() {
 			It("without current spec", func() {
 				specService.GetErr = errors.New("fake-spec-get-error")
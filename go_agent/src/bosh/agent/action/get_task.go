@@ -38,6 +38,11 @@ func (a GetTaskAction) Run(taskID string) (interface{}, error) {
 	}
 
 	if task.Error != nil {
+		// Helps finding log lines of the failed task
+		if task.CorrelationID != "" {
+			return task.Value, bosherr.WrapError(task.Error, "Task %s (correlation ID %s) result", taskID, task.CorrelationID)
+		}
+
 		return task.Value, bosherr.WrapError(task.Error, "Task %s result", taskID)
 	}
 
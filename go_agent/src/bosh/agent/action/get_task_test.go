@@ -58,6 +58,19 @@ var _ = Describe("GetTask", func() {
 		Expect(taskValue).To(BeNil())
 	})
 
+	It("returns a failed task with its correlation ID", func() {
+		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+			ID:            "fake-task-id",
+			State:         boshtask.TaskStateFailed,
+			Error:         errors.New("fake-task-error"),
+			CorrelationID: "fake-correlation-id",
+		}
+
+		_, err := action.Run("fake-task-id")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Task fake-task-id (correlation ID fake-correlation-id) result: fake-task-error"))
+	})
+
 	It("returns a successful task", func() {
 		taskService.StartedTasks["fake-task-id"] = boshtask.Task{
 			ID:    "fake-task-id",
@@ -0,0 +1,54 @@
+package action
+
+import (
+	"errors"
+	"time"
+
+	bosherr "bosh/errors"
+	boshvitals "bosh/platform/vitals"
+)
+
+type GetVitalsHistoryAction struct {
+	vitalsHistory boshvitals.History
+}
+
+func NewGetVitalsHistory(vitalsHistory boshvitals.History) (action GetVitalsHistoryAction) {
+	action.vitalsHistory = vitalsHistory
+	return
+}
+
+func (a GetVitalsHistoryAction) IsAsynchronous() bool {
+	return false
+}
+
+func (a GetVitalsHistoryAction) IsPersistent() bool {
+	return false
+}
+
+// Run returns vitals samples taken between from and to (unix seconds, inclusive),
+// keeping at most one sample per resolutionSecs; zero resolution keeps all samples.
+func (a GetVitalsHistoryAction) Run(from, to, resolutionSecs int64) ([]boshvitals.Sample, error) {
+	if to < from {
+		return nil, bosherr.New("Invalid time range: %d is before %d", to, from)
+	}
+
+	if resolutionSecs < 0 {
+		return nil, bosherr.New("Invalid resolution: %d", resolutionSecs)
+	}
+
+	samples := a.vitalsHistory.Samples(
+		time.Unix(from, 0),
+		time.Unix(to, 0),
+		time.Duration(resolutionSecs)*time.Second,
+	)
+
+	return samples, nil
+}
+
+func (a GetVitalsHistoryAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a GetVitalsHistoryAction) Cancel() error {
+	return errors.New("not supported")
+}
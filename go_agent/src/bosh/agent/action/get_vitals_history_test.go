@@ -0,0 +1,64 @@
+package action_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/action"
+	boshvitals "bosh/platform/vitals"
+	fakevitals "bosh/platform/vitals/fakes"
+)
+
+var _ = Describe("GetVitalsHistory", func() {
+	var (
+		vitalsHistory *fakevitals.FakeHistory
+		action        GetVitalsHistoryAction
+	)
+
+	BeforeEach(func() {
+		vitalsHistory = &fakevitals.FakeHistory{}
+		action = NewGetVitalsHistory(vitalsHistory)
+	})
+
+	It("is synchronous", func() {
+		Expect(action.IsAsynchronous()).To(BeFalse())
+	})
+
+	It("is not persistent", func() {
+		Expect(action.IsPersistent()).To(BeFalse())
+	})
+
+	Describe("Run", func() {
+		It("returns samples for requested time range and resolution", func() {
+			samples := []boshvitals.Sample{
+				boshvitals.Sample{
+					Time:   time.Unix(150, 0),
+					Vitals: boshvitals.Vitals{Load: []string{"1", "2", "3"}},
+				},
+			}
+			vitalsHistory.SamplesSamples = samples
+
+			result, err := action.Run(100, 200, 60)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(samples))
+
+			Expect(vitalsHistory.SamplesFrom).To(Equal(time.Unix(100, 0)))
+			Expect(vitalsHistory.SamplesTo).To(Equal(time.Unix(200, 0)))
+			Expect(vitalsHistory.SamplesResolution).To(Equal(60 * time.Second))
+		})
+
+		It("returns error when time range ends before it starts", func() {
+			_, err := action.Run(200, 100, 0)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid time range"))
+		})
+
+		It("returns error when resolution is negative", func() {
+			_, err := action.Run(100, 200, -1)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid resolution"))
+		})
+	})
+})
@@ -0,0 +1,45 @@
+package action
+
+import (
+	"errors"
+
+	boshtask "bosh/agent/task"
+)
+
+type ListTasksAction struct {
+	taskService boshtask.Service
+}
+
+func NewListTasks(taskService boshtask.Service) (action ListTasksAction) {
+	action.taskService = taskService
+	return
+}
+
+func (a ListTasksAction) IsAsynchronous() bool {
+	return false
+}
+
+func (a ListTasksAction) IsPersistent() bool {
+	return false
+}
+
+func (a ListTasksAction) Run() ([]boshtask.TaskStateValue, error) {
+	values := []boshtask.TaskStateValue{}
+
+	for _, task := range a.taskService.GetTasks() {
+		values = append(values, boshtask.TaskStateValue{
+			AgentTaskID: task.ID,
+			State:       task.State,
+		})
+	}
+
+	return values, nil
+}
+
+func (a ListTasksAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a ListTasksAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,48 @@
+package action_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/action"
+	boshtask "bosh/agent/task"
+	faketask "bosh/agent/task/fakes"
+	boshassert "bosh/assert"
+)
+
+var _ = Describe("ListTasks", func() {
+	var (
+		taskService *faketask.FakeService
+		action      ListTasksAction
+	)
+
+	BeforeEach(func() {
+		taskService = faketask.NewFakeService()
+		action = NewListTasks(taskService)
+	})
+
+	It("is synchronous", func() {
+		Expect(action.IsAsynchronous()).To(BeFalse())
+	})
+
+	It("is not persistent", func() {
+		Expect(action.IsPersistent()).To(BeFalse())
+	})
+
+	It("returns ids and states of all tasks", func() {
+		taskService.StartedTasks["fake-task-2"] = boshtask.Task{ID: "fake-task-2", State: boshtask.TaskStateDone}
+		taskService.StartedTasks["fake-task-1"] = boshtask.Task{ID: "fake-task-1", State: boshtask.TaskStateRunning}
+
+		tasks, err := action.Run()
+		Expect(err).ToNot(HaveOccurred())
+
+		boshassert.MatchesJSONString(GinkgoT(), tasks,
+			`[{"agent_task_id":"fake-task-1","state":"running"},{"agent_task_id":"fake-task-2","state":"done"}]`)
+	})
+
+	It("returns empty list when there are no tasks", func() {
+		tasks, err := action.Run()
+		Expect(err).ToNot(HaveOccurred())
+		boshassert.MatchesJSONString(GinkgoT(), tasks, `[]`)
+	})
+})
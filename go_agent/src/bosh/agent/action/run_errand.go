@@ -19,6 +19,8 @@ type RunErrandAction struct {
 	cmdRunner   boshsys.CmdRunner
 	logger      boshlog.Logger
 
+	correlationID string
+
 	cancelCh chan struct{}
 }
 
@@ -40,6 +42,12 @@ func NewRunErrand(
 	}
 }
 
+func (a RunErrandAction) WithCorrelationID(correlationID string) Action {
+	a.correlationID = correlationID
+	a.logger = a.logger.WithCorrelationID(correlationID)
+	return a
+}
+
 func (a RunErrandAction) IsAsynchronous() bool {
 	return true
 }
@@ -71,6 +79,10 @@ func (a RunErrandAction) Run() (ErrandResult, error) {
 		},
 	}
 
+	if a.correlationID != "" {
+		command.Env[boshsys.CorrelationIDEnvVar] = a.correlationID
+	}
+
 	process, err := a.cmdRunner.RunComplexCommandAsync(command)
 	if err != nil {
 		return ErrandResult{}, bosherr.WrapError(err, "Running errand script")
@@ -1,6 +1,9 @@
 package agent
 
 import (
+	"sync"
+	"time"
+
 	boshaction "bosh/agent/action"
 	boshtask "bosh/agent/task"
 	bosherr "bosh/errors"
@@ -10,9 +13,21 @@ import (
 
 const actionDispatcherLogTag = "Action Dispatcher"
 
+// Actions still dispatched during shutdown so that
+// API consumers can follow tasks that are being drained
+var actionsAllowedDuringShutdown = map[string]bool{
+	"ping":     true,
+	"get_task": true,
+}
+
 type ActionDispatcher interface {
 	ResumePreviouslyDispatchedTasks()
 	Dispatch(req boshhandler.Request) (resp boshhandler.Response)
+
+	// Shutdown rejects new requests and waits for running tasks up to timeout.
+	// Afterwards non-persistent tasks are cancelled; persistent tasks
+	// stay recorded so that they are resumed after restart.
+	Shutdown(timeout time.Duration)
 }
 
 type concreteActionDispatcher struct {
@@ -21,6 +36,12 @@ type concreteActionDispatcher struct {
 	taskManager   boshtask.Manager
 	actionFactory boshaction.Factory
 	actionRunner  boshaction.Runner
+
+	shuttingDown     bool
+	shuttingDownLock sync.Mutex
+
+	// Requests that are being dispatched
+	inFlight pendingCounter
 }
 
 func NewActionDispatcher(
@@ -30,7 +51,7 @@ func NewActionDispatcher(
 	actionFactory boshaction.Factory,
 	actionRunner boshaction.Runner,
 ) (dispatcher ActionDispatcher) {
-	return concreteActionDispatcher{
+	return &concreteActionDispatcher{
 		logger:        logger,
 		taskService:   taskService,
 		taskManager:   taskManager,
@@ -39,7 +60,7 @@ func NewActionDispatcher(
 	}
 }
 
-func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
+func (dispatcher *concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 	taskInfos, err := dispatcher.taskManager.GetTaskInfos()
 	if err != nil {
 		// Ignore failure of resuming tasks because there is nothing we can do.
@@ -58,6 +79,8 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 			continue
 		}
 
+		action = correlateAction(action, taskInfo.CorrelationID)
+
 		taskID := taskInfo.TaskID
 		payload := taskInfo.Payload
 
@@ -68,17 +91,34 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 			dispatcher.removeTaskInfo,
 		)
 
+		task.CorrelationID = taskInfo.CorrelationID
+
 		dispatcher.taskService.StartTask(task)
 	}
 }
 
-func (dispatcher concreteActionDispatcher) Dispatch(req boshhandler.Request) boshhandler.Response {
+func (dispatcher *concreteActionDispatcher) Dispatch(req boshhandler.Request) boshhandler.Response {
+	dispatcher.shuttingDownLock.Lock()
+
+	if dispatcher.shuttingDown && !actionsAllowedDuringShutdown[req.Method] {
+		dispatcher.shuttingDownLock.Unlock()
+		dispatcher.logger.Info(actionDispatcherLogTag, "Rejecting action %s during shutdown", req.Method)
+		return boshhandler.NewExceptionResponse(bosherr.New("Agent is shutting down"))
+	}
+
+	dispatcher.inFlight.Add(1)
+	dispatcher.shuttingDownLock.Unlock()
+
+	defer dispatcher.inFlight.Add(-1)
+
 	action, err := dispatcher.actionFactory.Create(req.Method)
 	if err != nil {
-		dispatcher.logger.Error(actionDispatcherLogTag, "Unknown action %s", req.Method)
+		dispatcher.requestLogger(req).Error(actionDispatcherLogTag, "Unknown action %s", req.Method)
 		return boshhandler.NewExceptionResponse(bosherr.New("unknown message %s", req.Method))
 	}
 
+	action = correlateAction(action, req.CorrelationID)
+
 	if action.IsAsynchronous() {
 		return dispatcher.dispatchAsynchronousAction(action, req)
 	}
@@ -86,11 +126,13 @@ func (dispatcher concreteActionDispatcher) Dispatch(req boshhandler.Request) bos
 	return dispatcher.dispatchSynchronousAction(action, req)
 }
 
-func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
+func (dispatcher *concreteActionDispatcher) dispatchAsynchronousAction(
 	action boshaction.Action,
 	req boshhandler.Request,
 ) boshhandler.Response {
-	dispatcher.logger.Info(actionDispatcherLogTag, "Running async action %s", req.Method)
+	logger := dispatcher.requestLogger(req)
+
+	logger.Info(actionDispatcherLogTag, "Running async action %s", req.Method)
 
 	var task boshtask.Task
 	var err error
@@ -105,11 +147,11 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 	// after agent restart so that API consumers do not need to know
 	// if agent is restarted midway through the task.
 	if action.IsPersistent() {
-		dispatcher.logger.Info(actionDispatcherLogTag, "Running persistent action %s", req.Method)
+		logger.Info(actionDispatcherLogTag, "Running persistent action %s", req.Method)
 		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, dispatcher.removeTaskInfo)
 		if err != nil {
 			err = bosherr.WrapError(err, "Create Task Failed %s", req.Method)
-			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+			logger.Error(actionDispatcherLogTag, err.Error())
 			return boshhandler.NewExceptionResponse(err)
 		}
 
@@ -117,23 +159,27 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 			TaskID:  task.ID,
 			Method:  req.Method,
 			Payload: req.GetPayload(),
+
+			CorrelationID: req.CorrelationID,
 		}
 
 		err = dispatcher.taskManager.AddTaskInfo(taskInfo)
 		if err != nil {
 			err = bosherr.WrapError(err, "Action Failed %s", req.Method)
-			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+			logger.Error(actionDispatcherLogTag, err.Error())
 			return boshhandler.NewExceptionResponse(err)
 		}
 	} else {
 		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, nil)
 		if err != nil {
 			err = bosherr.WrapError(err, "Create Task Failed %s", req.Method)
-			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+			logger.Error(actionDispatcherLogTag, err.Error())
 			return boshhandler.NewExceptionResponse(err)
 		}
 	}
 
+	task.CorrelationID = req.CorrelationID
+
 	dispatcher.taskService.StartTask(task)
 
 	return boshhandler.NewValueResponse(boshtask.TaskStateValue{
@@ -142,23 +188,37 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 	})
 }
 
-func (dispatcher concreteActionDispatcher) dispatchSynchronousAction(
+func (dispatcher *concreteActionDispatcher) dispatchSynchronousAction(
 	action boshaction.Action,
 	req boshhandler.Request,
 ) boshhandler.Response {
-	dispatcher.logger.Info(actionDispatcherLogTag, "Running sync action %s", req.Method)
+	logger := dispatcher.requestLogger(req)
+
+	logger.Info(actionDispatcherLogTag, "Running sync action %s", req.Method)
 
 	value, err := dispatcher.actionRunner.Run(action, req.GetPayload())
 	if err != nil {
 		err = bosherr.WrapError(err, "Action Failed %s", req.Method)
-		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+		logger.Error(actionDispatcherLogTag, err.Error())
 		return boshhandler.NewExceptionResponse(err)
 	}
 
 	return boshhandler.NewValueResponse(value)
 }
 
-func (dispatcher concreteActionDispatcher) removeTaskInfo(task boshtask.Task) {
+func (dispatcher *concreteActionDispatcher) requestLogger(req boshhandler.Request) boshlog.Logger {
+	return dispatcher.logger.WithCorrelationID(req.CorrelationID)
+}
+
+func correlateAction(action boshaction.Action, correlationID string) boshaction.Action {
+	if correlatedAction, ok := action.(boshaction.CorrelatedAction); ok && correlationID != "" {
+		return correlatedAction.WithCorrelationID(correlationID)
+	}
+
+	return action
+}
+
+func (dispatcher *concreteActionDispatcher) removeTaskInfo(task boshtask.Task) {
 	err := dispatcher.taskManager.RemoveTaskInfo(task.ID)
 	if err != nil {
 		// There is not much we can do about failing to write state of a finished task.
@@ -166,3 +226,52 @@ func (dispatcher concreteActionDispatcher) removeTaskInfo(task boshtask.Task) {
 		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
 	}
 }
+
+func (dispatcher *concreteActionDispatcher) Shutdown(timeout time.Duration) {
+	dispatcher.shuttingDownLock.Lock()
+	dispatcher.shuttingDown = true
+	dispatcher.shuttingDownLock.Unlock()
+
+	dispatcher.logger.Info(actionDispatcherLogTag, "Waiting up to %s for running tasks", timeout)
+
+	drained := waitUntil(func() bool {
+		counts := dispatcher.taskService.GetCounts()
+		return dispatcher.inFlight.Count() == 0 && counts.Queued == 0 && counts.Running == 0
+	}, timeout)
+
+	if drained {
+		dispatcher.logger.Info(actionDispatcherLogTag, "All tasks finished")
+		return
+	}
+
+	taskInfos, err := dispatcher.taskManager.GetTaskInfos()
+	if err != nil {
+		// Cancelling a persistent task would prevent it from being resumed
+		dispatcher.logger.Error(actionDispatcherLogTag, "Not cancelling tasks: %s", err.Error())
+		return
+	}
+
+	persistentTaskIDs := map[string]bool{}
+
+	for _, taskInfo := range taskInfos {
+		persistentTaskIDs[taskInfo.TaskID] = true
+	}
+
+	for _, task := range dispatcher.taskService.GetTasks() {
+		if task.State != boshtask.TaskStateRunning {
+			continue
+		}
+
+		if persistentTaskIDs[task.ID] {
+			dispatcher.logger.Info(actionDispatcherLogTag, "Task #%s will be resumed after restart", task.ID)
+			continue
+		}
+
+		dispatcher.logger.Info(actionDispatcherLogTag, "Cancelling task #%s", task.ID)
+
+		err = task.Cancel()
+		if err != nil {
+			dispatcher.logger.Error(actionDispatcherLogTag, "Cancelling task #%s: %s", task.ID, err.Error())
+		}
+	}
+}
@@ -121,6 +121,12 @@ func init() {
 					Expect(taskService.StartedTasks["fake-generated-task-id"]).ToNot(BeNil())
 				})
 
+				It("records request correlation id on the task", func() {
+					req.CorrelationID = "fake-correlation-id"
+					dispatcher.Dispatch(req)
+					Expect(taskService.StartedTasks["fake-generated-task-id"].CorrelationID).To(Equal("fake-correlation-id"))
+				})
+
 				It("returns create task error", func() {
 					taskService.CreateTaskErr = errors.New("fake-create-task-error")
 					resp := dispatcher.Dispatch(req)
@@ -252,6 +258,76 @@ func init() {
 			})
 		})
 
+		Describe("Shutdown", func() {
+			var (
+				persistentTaskCanceled    bool
+				nonPersistentTaskCanceled bool
+			)
+
+			BeforeEach(func() {
+				persistentTaskCanceled = false
+				nonPersistentTaskCanceled = false
+
+				taskService.StartedTasks["fake-persistent-task-id"] = boshtask.Task{
+					ID:         "fake-persistent-task-id",
+					State:      boshtask.TaskStateRunning,
+					CancelFunc: func(_ boshtask.Task) error { persistentTaskCanceled = true; return nil },
+				}
+
+				taskService.StartedTasks["fake-task-id"] = boshtask.Task{
+					ID:         "fake-task-id",
+					State:      boshtask.TaskStateRunning,
+					CancelFunc: func(_ boshtask.Task) error { nonPersistentTaskCanceled = true; return nil },
+				}
+
+				err := taskManager.AddTaskInfo(boshtask.TaskInfo{TaskID: "fake-persistent-task-id"})
+				Expect(err).ToNot(HaveOccurred())
+
+				actionFactory.RegisterAction("fake-action", &fakeaction.TestAction{})
+				actionFactory.RegisterAction("get_task", &fakeaction.TestAction{})
+			})
+
+			It("rejects new requests except the ones used to follow tasks", func() {
+				dispatcher.Shutdown(0)
+
+				resp := dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "fake-action", []byte{}))
+				boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"Agent is shutting down"}}`)
+
+				actionRunner.RunValue = "fake-value"
+				resp = dispatcher.Dispatch(boshhandler.NewRequest("fake-reply", "get_task", []byte{}))
+				Expect(resp).To(Equal(boshhandler.NewValueResponse("fake-value")))
+			})
+
+			It("does not cancel tasks when they finish before timeout", func() {
+				taskService.Counts = boshtask.Counts{Done: 2}
+
+				dispatcher.Shutdown(0)
+
+				Expect(persistentTaskCanceled).To(BeFalse())
+				Expect(nonPersistentTaskCanceled).To(BeFalse())
+			})
+
+			It("cancels non-persistent tasks that are still running after timeout", func() {
+				taskService.Counts = boshtask.Counts{Running: 1, Queued: 1}
+
+				dispatcher.Shutdown(0)
+
+				Expect(nonPersistentTaskCanceled).To(BeTrue())
+			})
+
+			It("keeps persistent tasks so that they are resumed after restart", func() {
+				taskService.Counts = boshtask.Counts{Running: 1, Queued: 1}
+
+				dispatcher.Shutdown(0)
+
+				Expect(persistentTaskCanceled).To(BeFalse())
+
+				taskInfos, err := taskManager.GetTaskInfos()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(len(taskInfos)).To(Equal(1))
+			})
+		})
+
 		Describe("ResumePreviouslyDispatchedTasks", func() {
 			var firstAction, secondAction *fakeaction.TestAction
 
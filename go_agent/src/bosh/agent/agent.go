@@ -1,19 +1,42 @@
 package agent
 
 import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	boshalert "bosh/agent/alert"
 	boshas "bosh/agent/applier/applyspec"
 	bosherr "bosh/errors"
 	boshhandler "bosh/handler"
+	boshjobmetrics "bosh/jobmetrics"
 	boshjobsuper "bosh/jobsupervisor"
 	boshlog "bosh/logger"
 	boshmbus "bosh/mbus"
 	boshplatform "bosh/platform"
+	boshvitals "bosh/platform/vitals"
 	boshsyslog "bosh/syslog"
 )
 
+const (
+	agentLogTag = "Agent"
+
+	defaultShutdownTimeout = 30 * time.Second
+	alertsFlushTimeout     = 5 * time.Second
+)
+
+type Options struct {
+	// When set to true heartbeats will include extended vitals
+	// (network, disk I/O, processes, file descriptors and uptime)
+	HeartbeatExtendedVitals bool
+
+	// How long shutdown waits for running tasks
+	// before cancelling them; defaults to 30 seconds
+	ShutdownTimeoutSeconds int
+}
+
 type Agent struct {
 	logger            boshlog.Logger
 	mbusHandler       boshhandler.Handler
@@ -24,6 +47,12 @@ type Agent struct {
 	jobSupervisor     boshjobsuper.JobSupervisor
 	specService       boshas.V1Service
 	syslogServer      boshsyslog.Server
+	jobMetrics        boshjobmetrics.Collector
+	options           Options
+
+	shutdownCh    chan struct{}
+	shutdownOnce  *sync.Once
+	pendingAlerts *pendingCounter
 }
 
 func New(
@@ -35,7 +64,9 @@ func New(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	specService boshas.V1Service,
 	syslogServer boshsyslog.Server,
+	jobMetrics boshjobmetrics.Collector,
 	heartbeatInterval time.Duration,
+	options Options,
 ) (a Agent) {
 	a.logger = logger
 	a.mbusHandler = mbusHandler
@@ -46,6 +77,11 @@ func New(
 	a.jobSupervisor = jobSupervisor
 	a.specService = specService
 	a.syslogServer = syslogServer
+	a.jobMetrics = jobMetrics
+	a.options = options
+	a.shutdownCh = make(chan struct{})
+	a.shutdownOnce = &sync.Once{}
+	a.pendingAlerts = &pendingCounter{}
 	return
 }
 
@@ -67,10 +103,56 @@ func (a Agent) Run() error {
 
 	go a.syslogServer.Start(a.handleSyslogMsg(errCh))
 
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
 	select {
 	case err = <-errCh:
 		return err
+	case <-signalCh:
+	case <-a.shutdownCh:
+	}
+
+	a.shutdown()
+
+	return nil
+}
+
+// Shutdown makes Run return after in-flight work is drained
+func (a Agent) Shutdown() {
+	a.shutdownOnce.Do(func() { close(a.shutdownCh) })
+}
+
+func (a Agent) shutdown() {
+	timeout := time.Duration(a.options.ShutdownTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	a.logger.Info(agentLogTag, "Shutting down")
+
+	// Message bus keeps running so that tasks can be followed while draining
+	a.actionDispatcher.Shutdown(timeout)
+
+	err := a.syslogServer.Stop()
+	if err != nil {
+		a.logger.Error(agentLogTag, "Stopping syslog server: %s", err.Error())
+	}
+
+	err = a.jobSupervisor.StopMonitoringJobFailures()
+	if err != nil {
+		a.logger.Error(agentLogTag, "Stopping job failures monitoring: %s", err.Error())
+	}
+
+	flushed := waitUntil(func() bool { return a.pendingAlerts.Count() == 0 }, alertsFlushTimeout)
+	if !flushed {
+		a.logger.Error(agentLogTag, "Gave up waiting for %d alert(s) to be sent", a.pendingAlerts.Count())
 	}
+
+	a.mbusHandler.Stop()
+
+	a.logger.Info(agentLogTag, "Shut down")
 }
 
 func (a Agent) subscribeActionDispatcher(errCh chan error) {
@@ -110,15 +192,32 @@ func (a Agent) sendHeartbeat(errCh chan error) {
 
 	err = a.mbusHandler.SendToHealthManager("heartbeat", heartbeat)
 	if err != nil {
-		err = bosherr.WrapError(err, "Sending heartbeat")
-		errCh <- err
+		a.reportSendErr(errCh, bosherr.WrapError(err, "Sending heartbeat"))
 	}
 }
 
+// reportSendErr only logs messages dropped while message bus is
+// reconnecting so that temporary NATS outage does not stop the agent
+func (a Agent) reportSendErr(errCh chan error, err error) {
+	if bosherr.Cause(err) == boshmbus.ErrNotConnected {
+		a.logger.Error(agentLogTag, "Dropping message: %s", err.Error())
+		return
+	}
+
+	errCh <- err
+}
+
 func (a Agent) getHeartbeat() (boshmbus.Heartbeat, error) {
 	vitalsService := a.platform.GetVitalsService()
 
-	vitals, err := vitalsService.Get()
+	var vitals boshvitals.Vitals
+	var err error
+
+	if a.options.HeartbeatExtendedVitals {
+		vitals, err = vitalsService.GetExtended("heartbeat")
+	} else {
+		vitals, err = vitalsService.Get()
+	}
 	if err != nil {
 		return boshmbus.Heartbeat{}, bosherr.WrapError(err, "Getting job vitals")
 	}
@@ -133,15 +232,19 @@ func (a Agent) getHeartbeat() (boshmbus.Heartbeat, error) {
 		Index:    spec.Index,
 		JobState: a.jobSupervisor.Status(),
 		Vitals:   vitals,
+		Metrics:  a.jobMetrics.Collect(),
 	}
 	return hb, nil
 }
 
 func (a Agent) handleJobFailure(errCh chan error) boshjobsuper.JobFailureHandler {
 	return func(monitAlert boshalert.MonitAlert) error {
+		a.pendingAlerts.Add(1)
+		defer a.pendingAlerts.Add(-1)
+
 		err := a.alertSender.SendAlert(monitAlert)
 		if err != nil {
-			errCh <- bosherr.WrapError(err, "Sending alert")
+			a.reportSendErr(errCh, bosherr.WrapError(err, "Sending alert"))
 		}
 
 		return nil
@@ -150,9 +253,12 @@ func (a Agent) handleJobFailure(errCh chan error) boshjobsuper.JobFailureHandler
 
 func (a Agent) handleSyslogMsg(errCh chan error) boshsyslog.CallbackFunc {
 	return func(msg boshsyslog.Msg) {
+		a.pendingAlerts.Add(1)
+		defer a.pendingAlerts.Add(-1)
+
 		err := a.alertSender.SendSSHAlert(msg)
 		if err != nil {
-			errCh <- bosherr.WrapError(err, "Sending SSH alert")
+			a.reportSendErr(errCh, bosherr.WrapError(err, "Sending SSH alert"))
 		}
 	}
 }
@@ -13,7 +13,10 @@ import (
 	boshas "bosh/agent/applier/applyspec"
 	fakeas "bosh/agent/applier/applyspec/fakes"
 	fakeagent "bosh/agent/fakes"
+	bosherr "bosh/errors"
 	boshhandler "bosh/handler"
+	boshjobmetrics "bosh/jobmetrics"
+	fakejobmetrics "bosh/jobmetrics/fakes"
 	fakejobsuper "bosh/jobsupervisor/fakes"
 	boshlog "bosh/logger"
 	boshmbus "bosh/mbus"
@@ -36,6 +39,7 @@ func init() {
 			jobSupervisor    *fakejobsuper.FakeJobSupervisor
 			specService      *fakeas.FakeV1Service
 			syslogServer     *fakesyslog.FakeServer
+			jobMetrics       *fakejobmetrics.FakeCollector
 			agent            Agent
 		)
 
@@ -49,6 +53,7 @@ func init() {
 			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 			specService = fakeas.NewFakeV1Service()
 			syslogServer = &fakesyslog.FakeServer{}
+			jobMetrics = &fakejobmetrics.FakeCollector{}
 			agent = New(
 				logger,
 				handler,
@@ -58,7 +63,9 @@ func init() {
 				jobSupervisor,
 				specService,
 				syslogServer,
+				jobMetrics,
 				5*time.Millisecond,
+				Options{},
 			)
 		})
 
@@ -129,7 +136,9 @@ func init() {
 						jobSupervisor,
 						specService,
 						syslogServer,
+						jobMetrics,
 						5*time.Hour,
+						Options{},
 					)
 
 					// Immediately exit after sending initial heartbeat
@@ -165,6 +174,112 @@ func init() {
 				})
 			})
 
+			Context("when message bus is not connected", func() {
+				BeforeEach(func() {
+					handler.KeepOnRunning()
+				})
+
+				It("keeps sending heartbeats", func() {
+					sentRequests := 0
+					handler.SendToHealthManagerCallBack = func(_ fakembus.HMRequest) {
+						sentRequests++
+						if sentRequests == 1 {
+							handler.SendToHealthManagerErr = bosherr.WrapError(boshmbus.ErrNotConnected, "fake-publish-err")
+						} else {
+							handler.SendToHealthManagerErr = errors.New("stop")
+						}
+					}
+
+					err := agent.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("stop"))
+
+					Expect(handler.HMRequests()).To(HaveLen(2))
+				})
+
+				It("keeps running when job monitoring alerts cannot be sent", func() {
+					monitAlert := boshalert.MonitAlert{ID: "fake-monit-alert"}
+					jobSupervisor.JobFailureAlert = &monitAlert
+
+					alertSender.SendAlertErr = bosherr.WrapError(boshmbus.ErrNotConnected, "fake-publish-err")
+
+					sentRequests := 0
+					handler.SendToHealthManagerCallBack = func(_ fakembus.HMRequest) {
+						sentRequests++
+						if sentRequests == 3 {
+							handler.SendToHealthManagerErr = errors.New("stop")
+						}
+					}
+
+					err := agent.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("stop"))
+
+					Expect(alertSender.SendAlertMonitAlert).To(Equal(monitAlert))
+				})
+			})
+
+			Context("when heartbeats are configured to include extended vitals", func() {
+				BeforeEach(func() {
+					handler.KeepOnRunning()
+
+					platform.FakeVitalsService.GetVitals = boshvitals.Vitals{
+						Load: []string{"a", "b", "c"},
+					}
+					platform.FakeVitalsService.GetExtendedVitals = boshvitals.Vitals{
+						Load:   []string{"a", "b", "c"},
+						Uptime: &boshvitals.UptimeVitals{Secs: "fake-uptime-secs"},
+					}
+
+					agent = New(
+						logger,
+						handler,
+						platform,
+						actionDispatcher,
+						alertSender,
+						jobSupervisor,
+						specService,
+						syslogServer,
+						jobMetrics,
+						5*time.Hour,
+						Options{HeartbeatExtendedVitals: true},
+					)
+				})
+
+				It("sends heartbeat with extended vitals", func() {
+					// Immediately exit after sending initial heartbeat
+					handler.SendToHealthManagerErr = errors.New("stop")
+
+					err := agent.Run()
+					Expect(err).To(HaveOccurred())
+
+					hb := handler.HMRequests()[0].Payload.(boshmbus.Heartbeat)
+					Expect(hb.Vitals).To(Equal(platform.FakeVitalsService.GetExtendedVitals))
+					Expect(platform.FakeVitalsService.GetExtendedConsumer).To(Equal("heartbeat"))
+				})
+			})
+
+			Context("when jobs publish metrics", func() {
+				BeforeEach(func() {
+					handler.KeepOnRunning()
+
+					jobMetrics.CollectMetrics = []boshjobmetrics.Metric{
+						boshjobmetrics.Metric{Name: "fake-job.requests", Type: "counter", Value: 10, Timestamp: 1000},
+					}
+				})
+
+				It("sends heartbeat with job metrics", func() {
+					// Immediately exit after sending initial heartbeat
+					handler.SendToHealthManagerErr = errors.New("stop")
+
+					err := agent.Run()
+					Expect(err).To(HaveOccurred())
+
+					hb := handler.HMRequests()[0].Payload.(boshmbus.Heartbeat)
+					Expect(hb.Metrics).To(Equal(jobMetrics.CollectMetrics))
+				})
+			})
+
 			Context("when the agent fails to get job spec for a heartbeat", func() {
 				BeforeEach(func() {
 					specService.GetErr = errors.New("fake-spec-service-error")
@@ -223,5 +338,57 @@ func init() {
 				Expect(alertSender.SendSSHAlertMsg).To(Equal(syslogMsg))
 			})
 		})
+
+		Describe("Shutdown", func() {
+			BeforeEach(func() {
+				handler.KeepOnRunning()
+			})
+
+			It("drains dispatcher, stops listening for alerts and then stops message bus handler", func() {
+				agent.Shutdown()
+
+				err := agent.Run()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(actionDispatcher.ShutdownCalled).To(BeTrue())
+				Expect(actionDispatcher.ShutdownTimeout).To(Equal(30 * time.Second))
+				Expect(syslogServer.Stopped).To(BeTrue())
+				Expect(jobSupervisor.StoppedMonitoringJobFailures).To(BeTrue())
+				Expect(handler.ReceivedStop).To(BeTrue())
+			})
+
+			It("uses configured shutdown timeout", func() {
+				agent = New(
+					logger,
+					handler,
+					platform,
+					actionDispatcher,
+					alertSender,
+					jobSupervisor,
+					specService,
+					syslogServer,
+					jobMetrics,
+					5*time.Hour,
+					Options{ShutdownTimeoutSeconds: 5},
+				)
+
+				agent.Shutdown()
+
+				err := agent.Run()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actionDispatcher.ShutdownTimeout).To(Equal(5 * time.Second))
+			})
+
+			It("stops message bus handler even if listeners fail to stop", func() {
+				syslogServer.StopErr = errors.New("fake-stop-err")
+				jobSupervisor.StopMonitoringJobFailuresErr = errors.New("fake-stop-err")
+
+				agent.Shutdown()
+
+				err := agent.Run()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handler.ReceivedStop).To(BeTrue())
+			})
+		})
 	})
 }
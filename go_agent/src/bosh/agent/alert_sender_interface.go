@@ -2,10 +2,24 @@ package agent
 
 import (
 	boshalert "bosh/agent/alert"
+	boshntp "bosh/platform/ntp"
 	boshsyslog "bosh/syslog"
 )
 
 type AlertSender interface {
 	SendAlert(boshalert.MonitAlert) error
 	SendSSHAlert(boshsyslog.Msg) error
+	SendClockDriftAlert(boshntp.NTPInfo) error
+
+	// Number of alerts sent to health manager since start
+	GetCounts() AlertCounts
+}
+
+type AlertCounts struct {
+	MonitSent   uint64
+	MonitFailed uint64
+	SSHSent     uint64
+	SSHFailed   uint64
+	NTPSent     uint64
+	NTPFailed   uint64
 }
@@ -5,8 +5,9 @@ import models "bosh/agent/applier/models"
 type PackageSpec struct {
 	Name        string `json:"name"`
 	Version     string `json:"version"`
-	Sha1        string `json:"sha1"`
+	Sha1        string `json:"sha1"` // Plain SHA1 or multiple digest e.g. "sha1:<hex>;sha256:<hex>"
 	BlobstoreID string `json:"blobstore_id"`
+	Signature   string `json:"signature,omitempty"`
 }
 
 func (s *PackageSpec) AsPackage() models.Package {
@@ -16,6 +17,7 @@ func (s *PackageSpec) AsPackage() models.Package {
 		Source: models.Source{
 			Sha1:        s.Sha1,
 			BlobstoreID: s.BlobstoreID,
+			Signature:   s.Signature,
 		},
 	}
 }
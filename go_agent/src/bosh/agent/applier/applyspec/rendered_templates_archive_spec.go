@@ -7,6 +7,7 @@ import (
 type RenderedTemplatesArchiveSpec struct {
 	Sha1        string `json:"sha1"`
 	BlobstoreID string `json:"blobstore_id"`
+	Signature   string `json:"signature,omitempty"`
 }
 
 func (s RenderedTemplatesArchiveSpec) AsSource(job models.Job) models.Source {
@@ -14,5 +15,6 @@ func (s RenderedTemplatesArchiveSpec) AsSource(job models.Job) models.Source {
 		Sha1:          s.Sha1,
 		BlobstoreID:   s.BlobstoreID,
 		PathInArchive: job.Name,
+		Signature:     s.Signature,
 	}
 }
@@ -30,7 +30,7 @@ var _ = Describe("V1ApplySpec", func() {
 				},
 				"packages": {
 					"package 1": {"name": "package 1", "version": "0.1", "sha1": "package 1 sha1", "blobstore_id": "package-blob-id-1"},
-					"package 2": {"name": "package 2", "version": "0.2", "sha1": "package 2 sha1", "blobstore_id": "package-blob-id-2"}
+					"package 2": {"name": "package 2", "version": "0.2", "sha1": "package 2 sha1", "blobstore_id": "package-blob-id-2", "signature": "package 2 signature"}
 				},
 				"networks": {
 					"manual-net": {
@@ -62,7 +62,8 @@ var _ = Describe("V1ApplySpec", func() {
 				},
 				"rendered_templates_archive": {
 					"sha1": "archive sha 1",
-					"blobstore_id": "archive-blob-id-1"
+					"blobstore_id": "archive-blob-id-1",
+					"signature": "archive signature"
 				}
 			}`
 
@@ -111,11 +112,12 @@ var _ = Describe("V1ApplySpec", func() {
 				},
 				PackageSpecs: map[string]PackageSpec{
 					"package 1": PackageSpec{Name: "package 1", Version: "0.1", Sha1: "package 1 sha1", BlobstoreID: "package-blob-id-1"},
-					"package 2": PackageSpec{Name: "package 2", Version: "0.2", Sha1: "package 2 sha1", BlobstoreID: "package-blob-id-2"},
+					"package 2": PackageSpec{Name: "package 2", Version: "0.2", Sha1: "package 2 sha1", BlobstoreID: "package-blob-id-2", Signature: "package 2 signature"},
 				},
 				RenderedTemplatesArchiveSpec: RenderedTemplatesArchiveSpec{
 					Sha1:        "archive sha 1",
 					BlobstoreID: "archive-blob-id-1",
+					Signature:   "archive signature",
 				},
 				NetworkSpecs: expectedNetworks,
 			}
@@ -155,6 +157,7 @@ var _ = Describe("V1ApplySpec", func() {
 						Version:     "fake-package1-version",
 						Sha1:        "fake-package1-sha1",
 						BlobstoreID: "fake-package1-blob-id",
+						Signature:   "fake-package1-signature",
 					},
 					"fake-package2": PackageSpec{
 						Name:        "fake-package2-name",
@@ -166,6 +169,7 @@ var _ = Describe("V1ApplySpec", func() {
 				RenderedTemplatesArchiveSpec: RenderedTemplatesArchiveSpec{
 					Sha1:        "fake-rendered-templates-archive-sha1",
 					BlobstoreID: "fake-rendered-templates-archive-blobstore-id",
+					Signature:   "fake-rendered-templates-archive-signature",
 				},
 			}
 
@@ -177,6 +181,7 @@ var _ = Describe("V1ApplySpec", func() {
 						Sha1:          "fake-package1-sha1",
 						BlobstoreID:   "fake-package1-blob-id",
 						PathInArchive: "",
+						Signature:     "fake-package1-signature",
 					},
 				},
 				models.Package{
@@ -198,6 +203,7 @@ var _ = Describe("V1ApplySpec", func() {
 						Sha1:          "fake-rendered-templates-archive-sha1",
 						BlobstoreID:   "fake-rendered-templates-archive-blobstore-id",
 						PathInArchive: "fake-job1-name",
+						Signature:     "fake-rendered-templates-archive-signature",
 					},
 					Packages: expectedPackagesOnEachJob,
 				},
@@ -208,6 +214,7 @@ var _ = Describe("V1ApplySpec", func() {
 						Sha1:          "fake-rendered-templates-archive-sha1",
 						BlobstoreID:   "fake-rendered-templates-archive-blobstore-id",
 						PathInArchive: "fake-job2-name",
+						Signature:     "fake-rendered-templates-archive-signature",
 					},
 					Packages: expectedPackagesOnEachJob,
 				},
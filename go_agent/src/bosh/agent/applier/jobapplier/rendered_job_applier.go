@@ -10,6 +10,7 @@ import (
 	models "bosh/agent/applier/models"
 	boshpa "bosh/agent/applier/packageapplier"
 	boshblob "bosh/blobstore"
+	boshcrypto "bosh/crypto"
 	bosherr "bosh/errors"
 	boshjobsuper "bosh/jobsupervisor"
 	boshlog "bosh/logger"
@@ -24,6 +25,7 @@ type renderedJobApplier struct {
 	jobSupervisor          boshjobsuper.JobSupervisor
 	packageApplierProvider boshpa.PackageApplierProvider
 	blobstore              boshblob.Blobstore
+	signatureVerifier      boshcrypto.SignatureVerifier
 	compressor             boshcmd.Compressor
 	fs                     boshsys.FileSystem
 	logger                 boshlog.Logger
@@ -34,6 +36,7 @@ func NewRenderedJobApplier(
 	jobSupervisor boshjobsuper.JobSupervisor,
 	packageApplierProvider boshpa.PackageApplierProvider,
 	blobstore boshblob.Blobstore,
+	signatureVerifier boshcrypto.SignatureVerifier,
 	compressor boshcmd.Compressor,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
@@ -43,6 +46,7 @@ func NewRenderedJobApplier(
 		jobSupervisor:          jobSupervisor,
 		packageApplierProvider: packageApplierProvider,
 		blobstore:              blobstore,
+		signatureVerifier:      signatureVerifier,
 		compressor:             compressor,
 		fs:                     fs,
 		logger:                 logger,
@@ -108,6 +112,11 @@ func (s *renderedJobApplier) downloadAndInstall(job models.Job, jobBundle boshbc
 
 	defer s.blobstore.CleanUp(file)
 
+	err = s.signatureVerifier.VerifyFile(file, job.Source.Signature)
+	if err != nil {
+		return bosherr.WrapError(err, "Verifying signature of job %s/%s", job.Name, job.Version)
+	}
+
 	err = s.compressor.DecompressFileToDir(file, tmpDir)
 	if err != nil {
 		return bosherr.WrapError(err, "Decompressing files to temp dir")
@@ -12,6 +12,7 @@ import (
 	models "bosh/agent/applier/models"
 	fakepa "bosh/agent/applier/packageapplier/fakes"
 	fakeblob "bosh/blobstore/fakes"
+	fakecrypto "bosh/crypto/fakes"
 	fakejobsuper "bosh/jobsupervisor/fakes"
 	boshlog "bosh/logger"
 	fakecmd "bosh/platform/commands/fakes"
@@ -31,6 +32,7 @@ func buildJob(bc *fakebc.FakeBundleCollection) (models.Job, *fakebc.FakeBundle)
 			Sha1:          "fake-blob-sha1",
 			BlobstoreID:   "fake-blobstore-id",
 			PathInArchive: "fake-path-in-archive",
+			Signature:     "fake-key-id:fake-signature",
 		},
 		Packages: []models.Package{
 			models.Package{
@@ -66,6 +68,7 @@ func init() {
 			jobSupervisor          *fakejobsuper.FakeJobSupervisor
 			packageApplierProvider *fakepa.FakePackageApplierProvider
 			blobstore              *fakeblob.FakeBlobstore
+			signatureVerifier      *fakecrypto.FakeSignatureVerifier
 			compressor             *fakecmd.FakeCompressor
 			fs                     *fakesys.FakeFileSystem
 			applier                JobApplier
@@ -76,6 +79,7 @@ func init() {
 			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
 			packageApplierProvider = fakepa.NewFakePackageApplierProvider()
 			blobstore = fakeblob.NewFakeBlobstore()
+			signatureVerifier = &fakecrypto.FakeSignatureVerifier{}
 			fs = fakesys.NewFakeFileSystem()
 			compressor = fakecmd.NewFakeCompressor()
 			logger := boshlog.NewLogger(boshlog.LevelNone)
@@ -84,6 +88,7 @@ func init() {
 				jobSupervisor,
 				packageApplierProvider,
 				blobstore,
+				signatureVerifier,
 				compressor,
 				fs,
 				logger,
@@ -129,6 +134,29 @@ func init() {
 					Expect(err.Error()).To(ContainSubstring("fake-get-error"))
 				})
 
+				It("verifies signature of downloaded job template blob", func() {
+					blobstore.GetFileName = "/fake-blobstore-file-name"
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(signatureVerifier.VerifyFileFileNames).To(Equal([]string{"/fake-blobstore-file-name"}))
+					Expect(signatureVerifier.VerifyFileSignatures).To(Equal([]string{"fake-key-id:fake-signature"}))
+				})
+
+				It("does not install job and cleans up blob when signature verification fails", func() {
+					blobstore.GetFileName = "/fake-blobstore-file-name"
+					signatureVerifier.VerifyFileErr = errors.New("fake-verify-error")
+
+					err := act()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Verifying signature of job " + job.Name))
+					Expect(err.Error()).To(ContainSubstring("fake-verify-error"))
+
+					Expect(compressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+					Expect(bundle.Installed).To(BeFalse())
+					Expect(blobstore.CleanUpFileName).To(Equal("/fake-blobstore-file-name"))
+				})
+
 				It("decompresses job template blob to tmp path and later cleans it up", func() {
 					fs.TempDirDir = "/fake-tmp-dir"
 					blobstore.GetFileName = "/fake-blobstore-file-name"
@@ -11,5 +11,5 @@ func (s Package) BundleName() string {
 }
 
 func (s Package) BundleVersion() string {
-	return s.Version + "-" + s.Source.Sha1
+	return s.Version + "-" + s.Source.BundleDigest()
 }
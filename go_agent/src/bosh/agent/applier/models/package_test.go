@@ -23,5 +23,35 @@ var _ = Describe("Package", func() {
 			}
 			Expect(pkg.BundleVersion()).To(Equal("fake-version-fake-sha1"))
 		})
+
+		It("uses sha1 digest when source has multiple digests so that version matches plain sha1", func() {
+			pkg := Package{
+				Version: "fake-version",
+				Source: Source{
+					Sha1: "sha1:da39a3ee5e6b4b0d3255bfef95601890afd80709;" +
+						"sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+				},
+			}
+			Expect(pkg.BundleVersion()).To(Equal("fake-version-da39a3ee5e6b4b0d3255bfef95601890afd80709"))
+		})
+
+		It("uses the strongest digest when source has no sha1 digest", func() {
+			pkg := Package{
+				Version: "fake-version",
+				Source: Source{
+					Sha1: "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855;" +
+						"sha512:cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e",
+				},
+			}
+			Expect(pkg.BundleVersion()).To(Equal("fake-version-cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e"))
+		})
+
+		It("uses plain sha1 as before", func() {
+			pkg := Package{
+				Version: "fake-version",
+				Source:  Source{Sha1: "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+			}
+			Expect(pkg.BundleVersion()).To(Equal("fake-version-da39a3ee5e6b4b0d3255bfef95601890afd80709"))
+		})
 	})
 })
@@ -1,7 +1,33 @@
 package models
 
+import (
+	boshcrypto "bosh/crypto"
+)
+
 type Source struct {
-	Sha1          string
+	// Plain SHA1 or multiple digest e.g. "sha1:<hex>;sha256:<hex>"
+	Sha1 string
+
 	BlobstoreID   string
 	PathInArchive string
+
+	// Detached signature "<key id>:<base64 signature>"; empty when not signed
+	Signature string
+}
+
+// BundleDigest returns value of SHA1 digest so that plain SHA1 and
+// multiple digest of the same bundle result in the same bundle version;
+// the strongest digest is used only when there is no SHA1
+func (s Source) BundleDigest() string {
+	digests, err := boshcrypto.ParseMultipleDigest(s.Sha1)
+	if err != nil {
+		return s.Sha1
+	}
+
+	digest, found := digests.Digest(boshcrypto.DigestAlgorithmSHA1)
+	if found {
+		return digest.Value
+	}
+
+	return digests.Strongest().Value
 }
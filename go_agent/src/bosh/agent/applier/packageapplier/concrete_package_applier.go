@@ -4,6 +4,7 @@ import (
 	bc "bosh/agent/applier/bundlecollection"
 	models "bosh/agent/applier/models"
 	boshblob "bosh/blobstore"
+	boshcrypto "bosh/crypto"
 	bosherr "bosh/errors"
 	boshlog "bosh/logger"
 	boshcmd "bosh/platform/commands"
@@ -18,27 +19,30 @@ type concretePackageApplier struct {
 	// KeepOnly will permanently uninstall packages when operating as owner
 	packagesBcOwner bool
 
-	blobstore  boshblob.Blobstore
-	compressor boshcmd.Compressor
-	fs         boshsys.FileSystem
-	logger     boshlog.Logger
+	blobstore         boshblob.Blobstore
+	signatureVerifier boshcrypto.SignatureVerifier
+	compressor        boshcmd.Compressor
+	fs                boshsys.FileSystem
+	logger            boshlog.Logger
 }
 
 func NewConcretePackageApplier(
 	packagesBc bc.BundleCollection,
 	packagesBcOwner bool,
 	blobstore boshblob.Blobstore,
+	signatureVerifier boshcrypto.SignatureVerifier,
 	compressor boshcmd.Compressor,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 ) *concretePackageApplier {
 	return &concretePackageApplier{
-		packagesBc:      packagesBc,
-		packagesBcOwner: packagesBcOwner,
-		blobstore:       blobstore,
-		compressor:      compressor,
-		fs:              fs,
-		logger:          logger,
+		packagesBc:        packagesBc,
+		packagesBcOwner:   packagesBcOwner,
+		blobstore:         blobstore,
+		signatureVerifier: signatureVerifier,
+		compressor:        compressor,
+		fs:                fs,
+		logger:            logger,
 	}
 }
 
@@ -101,6 +105,11 @@ func (s *concretePackageApplier) downloadAndInstall(pkg models.Package, pkgBundl
 
 	defer s.blobstore.CleanUp(file)
 
+	err = s.signatureVerifier.VerifyFile(file, pkg.Source.Signature)
+	if err != nil {
+		return bosherr.WrapError(err, "Verifying signature of package %s/%s", pkg.Name, pkg.Version)
+	}
+
 	err = s.compressor.DecompressFileToDir(file, tmpDir)
 	if err != nil {
 		return bosherr.WrapError(err, "Decompressing package files")
@@ -5,6 +5,7 @@ import (
 
 	boshbc "bosh/agent/applier/bundlecollection"
 	boshblob "bosh/blobstore"
+	boshcrypto "bosh/crypto"
 	boshlog "bosh/logger"
 	boshcmd "bosh/platform/commands"
 	boshsys "bosh/system"
@@ -16,15 +17,17 @@ type concretePackageApplierProvider struct {
 	jobSpecificEnablePath string
 	name                  string
 
-	blobstore  boshblob.Blobstore
-	compressor boshcmd.Compressor
-	fs         boshsys.FileSystem
-	logger     boshlog.Logger
+	blobstore         boshblob.Blobstore
+	signatureVerifier boshcrypto.SignatureVerifier
+	compressor        boshcmd.Compressor
+	fs                boshsys.FileSystem
+	logger            boshlog.Logger
 }
 
 func NewConcretePackageApplierProvider(
 	installPath, rootEnablePath, jobSpecificEnablePath, name string,
 	blobstore boshblob.Blobstore,
+	signatureVerifier boshcrypto.SignatureVerifier,
 	compressor boshcmd.Compressor,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
@@ -33,18 +36,19 @@ func NewConcretePackageApplierProvider(
 		installPath:           installPath,
 		rootEnablePath:        rootEnablePath,
 		jobSpecificEnablePath: jobSpecificEnablePath,
-		name:       name,
-		blobstore:  blobstore,
-		compressor: compressor,
-		fs:         fs,
-		logger:     logger,
+		name:                  name,
+		blobstore:             blobstore,
+		signatureVerifier:     signatureVerifier,
+		compressor:            compressor,
+		fs:                    fs,
+		logger:                logger,
 	}
 }
 
 // Root provides package applier that operates on system-wide packages.
 // (e.g manages /var/vcap/packages/pkg-a -> /var/vcap/data/packages/pkg-a)
 func (p concretePackageApplierProvider) Root() PackageApplier {
-	return NewConcretePackageApplier(p.RootBundleCollection(), true, p.blobstore, p.compressor, p.fs, p.logger)
+	return NewConcretePackageApplier(p.RootBundleCollection(), true, p.blobstore, p.signatureVerifier, p.compressor, p.fs, p.logger)
 }
 
 // JobSpecific provides package applier that operates on job-specific packages.
@@ -52,7 +56,7 @@ func (p concretePackageApplierProvider) Root() PackageApplier {
 func (p concretePackageApplierProvider) JobSpecific(jobName string) PackageApplier {
 	enablePath := filepath.Join(p.jobSpecificEnablePath, jobName)
 	packagesBc := boshbc.NewFileBundleCollection(p.installPath, enablePath, p.name, p.fs, p.logger)
-	return NewConcretePackageApplier(packagesBc, false, p.blobstore, p.compressor, p.fs, p.logger)
+	return NewConcretePackageApplier(packagesBc, false, p.blobstore, p.signatureVerifier, p.compressor, p.fs, p.logger)
 }
 
 func (p concretePackageApplierProvider) RootBundleCollection() boshbc.BundleCollection {
@@ -7,6 +7,7 @@ import (
 	boshbc "bosh/agent/applier/bundlecollection"
 	. "bosh/agent/applier/packageapplier"
 	fakeblob "bosh/blobstore/fakes"
+	fakecrypto "bosh/crypto/fakes"
 	boshlog "bosh/logger"
 	fakecmd "bosh/platform/commands/fakes"
 	fakesys "bosh/system/fakes"
@@ -14,15 +15,17 @@ import (
 
 var _ = Describe("concretePackageApplierProvider", func() {
 	var (
-		blobstore  *fakeblob.FakeBlobstore
-		compressor *fakecmd.FakeCompressor
-		fs         *fakesys.FakeFileSystem
-		logger     boshlog.Logger
-		provider   PackageApplierProvider
+		blobstore         *fakeblob.FakeBlobstore
+		signatureVerifier *fakecrypto.FakeSignatureVerifier
+		compressor        *fakecmd.FakeCompressor
+		fs                *fakesys.FakeFileSystem
+		logger            boshlog.Logger
+		provider          PackageApplierProvider
 	)
 
 	BeforeEach(func() {
 		blobstore = fakeblob.NewFakeBlobstore()
+		signatureVerifier = &fakecrypto.FakeSignatureVerifier{}
 		compressor = fakecmd.NewFakeCompressor()
 		fs = fakesys.NewFakeFileSystem()
 		logger = boshlog.NewLogger(boshlog.LevelNone)
@@ -32,6 +35,7 @@ var _ = Describe("concretePackageApplierProvider", func() {
 			"fake-job-specific-enable-path",
 			"fake-name",
 			blobstore,
+			signatureVerifier,
 			compressor,
 			fs,
 			logger,
@@ -50,6 +54,7 @@ var _ = Describe("concretePackageApplierProvider", func() {
 				),
 				true,
 				blobstore,
+				signatureVerifier,
 				compressor,
 				fs,
 				logger,
@@ -74,6 +79,7 @@ var _ = Describe("concretePackageApplierProvider", func() {
 				false,
 
 				blobstore,
+				signatureVerifier,
 				compressor,
 				fs,
 				logger,
@@ -11,6 +11,7 @@ import (
 	models "bosh/agent/applier/models"
 	. "bosh/agent/applier/packageapplier"
 	fakeblob "bosh/blobstore/fakes"
+	fakecrypto "bosh/crypto/fakes"
 	boshlog "bosh/logger"
 	fakecmd "bosh/platform/commands/fakes"
 	fakesys "bosh/system/fakes"
@@ -28,6 +29,7 @@ func buildPkg(bc *fakebc.FakeBundleCollection) (models.Package, *fakebc.FakeBund
 		Source: models.Source{
 			Sha1:        "fake-blob-sha1",
 			BlobstoreID: "fake-blobstore-id",
+			Signature:   "fake-key-id:fake-signature",
 		},
 	}
 
@@ -39,21 +41,23 @@ func buildPkg(bc *fakebc.FakeBundleCollection) (models.Package, *fakebc.FakeBund
 func init() {
 	Describe("concretePackageApplier", func() {
 		var (
-			packagesBc *fakebc.FakeBundleCollection
-			blobstore  *fakeblob.FakeBlobstore
-			compressor *fakecmd.FakeCompressor
-			fs         *fakesys.FakeFileSystem
-			logger     boshlog.Logger
-			applier    PackageApplier
+			packagesBc        *fakebc.FakeBundleCollection
+			blobstore         *fakeblob.FakeBlobstore
+			signatureVerifier *fakecrypto.FakeSignatureVerifier
+			compressor        *fakecmd.FakeCompressor
+			fs                *fakesys.FakeFileSystem
+			logger            boshlog.Logger
+			applier           PackageApplier
 		)
 
 		BeforeEach(func() {
 			packagesBc = fakebc.NewFakeBundleCollection()
 			blobstore = fakeblob.NewFakeBlobstore()
+			signatureVerifier = &fakecrypto.FakeSignatureVerifier{}
 			compressor = fakecmd.NewFakeCompressor()
 			fs = fakesys.NewFakeFileSystem()
 			logger = boshlog.NewLogger(boshlog.LevelNone)
-			applier = NewConcretePackageApplier(packagesBc, true, blobstore, compressor, fs, logger)
+			applier = NewConcretePackageApplier(packagesBc, true, blobstore, signatureVerifier, compressor, fs, logger)
 		})
 
 		Describe("Prepare & Apply", func() {
@@ -95,6 +99,29 @@ func init() {
 					Expect(err.Error()).To(ContainSubstring("fake-get-error"))
 				})
 
+				It("verifies signature of downloaded package blob", func() {
+					blobstore.GetFileName = "/fake-blobstore-file-name"
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(signatureVerifier.VerifyFileFileNames).To(Equal([]string{"/fake-blobstore-file-name"}))
+					Expect(signatureVerifier.VerifyFileSignatures).To(Equal([]string{"fake-key-id:fake-signature"}))
+				})
+
+				It("does not install package and cleans up blob when signature verification fails", func() {
+					blobstore.GetFileName = "/fake-blobstore-file-name"
+					signatureVerifier.VerifyFileErr = errors.New("fake-verify-error")
+
+					err := act()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Verifying signature of package " + pkg.Name))
+					Expect(err.Error()).To(ContainSubstring("fake-verify-error"))
+
+					Expect(compressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+					Expect(bundle.Installed).To(BeFalse())
+					Expect(blobstore.CleanUpFileName).To(Equal("/fake-blobstore-file-name"))
+				})
+
 				It("decompresses package blob to tmp path and later cleans it up", func() {
 					fs.TempDirDir = "/fake-tmp-dir"
 					blobstore.GetFileName = "/fake-blobstore-file-name"
@@ -310,7 +337,7 @@ func init() {
 
 			Context("when operating on packages as a package owner", func() {
 				BeforeEach(func() {
-					applier = NewConcretePackageApplier(packagesBc, true, blobstore, compressor, fs, logger)
+					applier = NewConcretePackageApplier(packagesBc, true, blobstore, signatureVerifier, compressor, fs, logger)
 				})
 
 				It("first disables and then uninstalls packages that are not in keeponly list", func() {
@@ -346,7 +373,7 @@ func init() {
 
 			Context("when operating on packages not as a package owner", func() {
 				BeforeEach(func() {
-					applier = NewConcretePackageApplier(packagesBc, false, blobstore, compressor, fs, logger)
+					applier = NewConcretePackageApplier(packagesBc, false, blobstore, signatureVerifier, compressor, fs, logger)
 				})
 
 				It("disables and but does not uninstall packages that are not in keeponly list", func() {
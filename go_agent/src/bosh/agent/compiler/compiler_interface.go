@@ -5,7 +5,8 @@ import (
 )
 
 type Compiler interface {
-	Compile(pkg Package, deps []boshmodels.Package) (blobID, sha1 string, err error)
+	// Returned digest is a multiple digest of the uploaded compiled package
+	Compile(pkg Package, deps []boshmodels.Package) (blobID, digest string, err error)
 }
 
 type Package struct {
@@ -13,6 +14,12 @@ type Package struct {
 	Name        string
 	Sha1        string
 	Version     string
+
+	// Detached signature "<key id>:<base64 signature>"; empty when not signed
+	Signature string `json:"signature,omitempty"`
+
+	// Passed to packaging script; not part of package description
+	CorrelationID string `json:"-"`
 }
 
 type Dependencies map[string]Package
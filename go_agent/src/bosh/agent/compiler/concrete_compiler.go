@@ -8,6 +8,7 @@ import (
 	boshmodels "bosh/agent/applier/models"
 	boshpa "bosh/agent/applier/packageapplier"
 	boshblob "bosh/blobstore"
+	boshcrypto "bosh/crypto"
 	bosherr "bosh/errors"
 	boshcmd "bosh/platform/commands"
 	boshsys "bosh/system"
@@ -25,6 +26,7 @@ type concreteCompiler struct {
 	compileDirProvider CompileDirProvider
 	packageApplier     boshpa.PackageApplier
 	packagesBc         boshbc.BundleCollection
+	signatureVerifier  boshcrypto.SignatureVerifier
 }
 
 func NewConcreteCompiler(
@@ -35,6 +37,7 @@ func NewConcreteCompiler(
 	compileDirProvider CompileDirProvider,
 	packageApplier boshpa.PackageApplier,
 	packagesBc boshbc.BundleCollection,
+	signatureVerifier boshcrypto.SignatureVerifier,
 ) (c concreteCompiler) {
 	c.compressor = compressor
 	c.blobstore = blobstore
@@ -43,6 +46,7 @@ func NewConcreteCompiler(
 	c.compileDirProvider = compileDirProvider
 	c.packageApplier = packageApplier
 	c.packagesBc = packagesBc
+	c.signatureVerifier = signatureVerifier
 	return
 }
 
@@ -100,6 +104,10 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (strin
 			WorkingDir: compilePath,
 		}
 
+		if pkg.CorrelationID != "" {
+			command.Env[boshsys.CorrelationIDEnvVar] = pkg.CorrelationID
+		}
+
 		_, _, _, err = c.runner.RunComplexCommand(command)
 		if err != nil {
 			return "", "", bosherr.WrapError(err, "Running packaging script")
@@ -113,22 +121,35 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (strin
 
 	defer c.compressor.CleanUp(tmpPackageTar)
 
-	uploadedBlobID, sha1, err := c.blobstore.Create(tmpPackageTar)
+	uploadedBlobID, digest, err := c.blobstore.Create(tmpPackageTar)
 	if err != nil {
 		return "", "", bosherr.WrapError(err, "Uploading compiled package")
 	}
 
 	err = compiledPkgBundle.Disable()
 	if err != nil {
-		return "", "", bosherr.WrapError(err, "Disabling compiled package")
+		err = bosherr.WrapError(err, "Disabling compiled package")
+		return "", "", c.deleteUploadedBlob(uploadedBlobID, err)
 	}
 
 	err = compiledPkgBundle.Uninstall()
 	if err != nil {
-		return "", "", bosherr.WrapError(err, "Uninstalling compiled package")
+		err = bosherr.WrapError(err, "Uninstalling compiled package")
+		return "", "", c.deleteUploadedBlob(uploadedBlobID, err)
+	}
+
+	return uploadedBlobID, digest, nil
+}
+
+// deleteUploadedBlob removes blob of failed compilation
+// since director never learns about it
+func (c concreteCompiler) deleteUploadedBlob(blobID string, compileErr error) error {
+	err := c.blobstore.Delete(blobID)
+	if err != nil {
+		return bosherr.New("%s (deleting uploaded blob %s: %s)", compileErr.Error(), blobID, err.Error())
 	}
 
-	return uploadedBlobID, sha1, nil
+	return compileErr
 }
 
 func (c concreteCompiler) fetchAndUncompress(pkg Package, targetDir string) error {
@@ -142,6 +163,11 @@ func (c concreteCompiler) fetchAndUncompress(pkg Package, targetDir string) erro
 		return bosherr.WrapError(err, "Fetching package blob %s", pkg.BlobstoreID)
 	}
 
+	err = c.signatureVerifier.VerifyFile(depFilePath, pkg.Signature)
+	if err != nil {
+		return bosherr.WrapError(err, "Verifying signature of package %s/%s", pkg.Name, pkg.Version)
+	}
+
 	err = c.atomicDecompress(depFilePath, targetDir)
 	if err != nil {
 		return bosherr.WrapError(err, "Uncompressing package %s", pkg.Name)
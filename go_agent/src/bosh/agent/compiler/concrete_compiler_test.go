@@ -1,7 +1,17 @@
 package compiler_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
 	"errors"
+	"io/ioutil"
+	"math/big"
 	"os"
 	"time"
 
@@ -13,7 +23,11 @@ import (
 	fakepa "bosh/agent/applier/packageapplier/fakes"
 	. "bosh/agent/compiler"
 	fakeblobstore "bosh/blobstore/fakes"
+	boshcrypto "bosh/crypto"
+	fakecrypto "bosh/crypto/fakes"
+	boshlog "bosh/logger"
 	fakecmd "bosh/platform/commands/fakes"
+	boshsettings "bosh/settings"
 	boshsys "bosh/system"
 	fakesys "bosh/system/fakes"
 )
@@ -64,17 +78,11 @@ func init() {
 			runner         *fakesys.FakeCmdRunner
 			packageApplier *fakepa.FakePackageApplier
 			packagesBc     *fakebc.FakeBundleCollection
+			verifier       *fakecrypto.FakeSignatureVerifier
 		)
 
-		BeforeEach(func() {
-			compressor = fakecmd.NewFakeCompressor()
-			blobstore = &fakeblobstore.FakeBlobstore{}
-			fs = fakesys.NewFakeFileSystem()
-			runner = fakesys.NewFakeCmdRunner()
-			packageApplier = fakepa.NewFakePackageApplier()
-			packagesBc = fakebc.NewFakeBundleCollection()
-
-			compiler = NewConcreteCompiler(
+		buildCompiler := func(signatureVerifier boshcrypto.SignatureVerifier) Compiler {
+			return NewConcreteCompiler(
 				compressor,
 				blobstore,
 				fs,
@@ -82,7 +90,20 @@ func init() {
 				FakeCompileDirProvider{Dir: "/fake-compile-dir"},
 				packageApplier,
 				packagesBc,
+				signatureVerifier,
 			)
+		}
+
+		BeforeEach(func() {
+			compressor = fakecmd.NewFakeCompressor()
+			blobstore = &fakeblobstore.FakeBlobstore{}
+			fs = fakesys.NewFakeFileSystem()
+			runner = fakesys.NewFakeCmdRunner()
+			packageApplier = fakepa.NewFakePackageApplier()
+			packagesBc = fakebc.NewFakeBundleCollection()
+			verifier = &fakecrypto.FakeSignatureVerifier{}
+
+			compiler = buildCompiler(verifier)
 		})
 
 		BeforeEach(func() {
@@ -158,6 +179,96 @@ func init() {
 				}
 			})
 
+			It("verifies signature of source package", func() {
+				blobstore.GetFileName = "/fake-source-package"
+				pkg.Signature = "fake-key-id:fake-signature"
+
+				_, _, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(verifier.VerifyFileFileNames).To(Equal([]string{"/fake-source-package"}))
+				Expect(verifier.VerifyFileSignatures).To(Equal([]string{"fake-key-id:fake-signature"}))
+			})
+
+			It("returns an error and does not uncompress source package if signature verification fails", func() {
+				verifier.VerifyFileErr = errors.New("fake-verify-err")
+
+				_, _, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Verifying signature of package pkg_name/pkg_version"))
+				Expect(err.Error()).To(ContainSubstring("fake-verify-err"))
+
+				Expect(compressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+			})
+
+			Context("when strict blob signing is enabled", func() {
+				var (
+					key            *ecdsa.PrivateKey
+					sourcePackage  string
+					sourceContents = []byte("fake-source-package-contents")
+				)
+
+				BeforeEach(func() {
+					var err error
+
+					key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+					Expect(err).ToNot(HaveOccurred())
+
+					publicKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+					Expect(err).ToNot(HaveOccurred())
+
+					signatureVerifier, err := boshcrypto.NewSignatureVerifier(boshsettings.BlobSigning{
+						TrustedKeys: map[string]string{
+							"fake-key-id": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})),
+						},
+						Strict: true,
+					}, boshlog.NewLogger(boshlog.LevelNone))
+					Expect(err).ToNot(HaveOccurred())
+
+					compiler = buildCompiler(signatureVerifier)
+
+					file, err := ioutil.TempFile("", "compiler-source-package")
+					Expect(err).ToNot(HaveOccurred())
+
+					_, err = file.Write(sourceContents)
+					Expect(err).ToNot(HaveOccurred())
+
+					file.Close()
+
+					sourcePackage = file.Name()
+					blobstore.GetFileName = sourcePackage
+				})
+
+				AfterEach(func() {
+					os.Remove(sourcePackage)
+				})
+
+				sign := func() string {
+					digest := sha256.Sum256(sourceContents)
+
+					r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+					Expect(err).ToNot(HaveOccurred())
+
+					signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+					Expect(err).ToNot(HaveOccurred())
+
+					return "fake-key-id:" + base64.StdEncoding.EncodeToString(signature)
+				}
+
+				It("compiles signed source package", func() {
+					pkg.Signature = sign()
+
+					_, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("rejects unsigned source package", func() {
+					_, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Blob is not signed and strict signature verification is enabled"))
+				})
+			})
+
 			It("returns an error if removing compile target directory during uncompression fails", func() {
 				fs.RegisterRemoveAllError("/fake-compile-dir/pkg_name", errors.New("fake-remove-error"))
 
@@ -271,6 +382,28 @@ func init() {
 				Expect(err.Error()).To(ContainSubstring("fake-create-err"))
 			})
 
+			It("deletes uploaded package if uninstalling compiled package fails", func() {
+				blobstore.CreateBlobID = "fake-blob-id"
+				bundle.UninstallErr = errors.New("fake-uninstall-err")
+
+				_, _, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-uninstall-err"))
+
+				Expect(blobstore.DeleteBlobIDs).To(Equal([]string{"fake-blob-id"}))
+			})
+
+			It("returns both errors if deleting uploaded package fails", func() {
+				blobstore.CreateBlobID = "fake-blob-id"
+				blobstore.DeleteErr = errors.New("fake-delete-err")
+				bundle.DisableErr = errors.New("fake-disable-err")
+
+				_, _, err := compiler.Compile(pkg, pkgDeps)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-disable-err"))
+				Expect(err.Error()).To(ContainSubstring("deleting uploaded blob fake-blob-id: fake-delete-err"))
+			})
+
 			It("cleans up compressed package after uploading it to blobstore", func() {
 				var beforeCleanUpTarballPath, afterCleanUpTarballPath string
 
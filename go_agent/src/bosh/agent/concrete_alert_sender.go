@@ -1,11 +1,14 @@
 package agent
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 
 	boshalert "bosh/agent/alert"
 	bosherr "bosh/errors"
 	boshhandler "bosh/handler"
+	boshntp "bosh/platform/ntp"
 	boshsyslog "bosh/syslog"
 	boshtime "bosh/time"
 	boshuuid "bosh/uuid"
@@ -16,6 +19,9 @@ type concreteAlertSender struct {
 	alertBuilder  boshalert.Builder
 	uuidGenerator boshuuid.Generator
 	timeService   boshtime.Service
+
+	counts     AlertCounts
+	countsLock sync.Mutex
 }
 
 func NewConcreteAlertSender(
@@ -23,8 +29,8 @@ func NewConcreteAlertSender(
 	alertBuilder boshalert.Builder,
 	uuidGenerator boshuuid.Generator,
 	timeService boshtime.Service,
-) concreteAlertSender {
-	return concreteAlertSender{
+) *concreteAlertSender {
+	return &concreteAlertSender{
 		mbusHandler:   mbusHandler,
 		alertBuilder:  alertBuilder,
 		uuidGenerator: uuidGenerator,
@@ -32,7 +38,7 @@ func NewConcreteAlertSender(
 	}
 }
 
-func (as concreteAlertSender) SendAlert(monitAlert boshalert.MonitAlert) error {
+func (as *concreteAlertSender) SendAlert(monitAlert boshalert.MonitAlert) error {
 	alert, err := as.alertBuilder.Build(monitAlert)
 	if err != nil {
 		return bosherr.WrapError(err, "Building alert")
@@ -43,6 +49,15 @@ func (as concreteAlertSender) SendAlert(monitAlert boshalert.MonitAlert) error {
 	}
 
 	err = as.mbusHandler.SendToHealthManager("alert", alert)
+
+	as.countsLock.Lock()
+	if err != nil {
+		as.counts.MonitFailed++
+	} else {
+		as.counts.MonitSent++
+	}
+	as.countsLock.Unlock()
+
 	if err != nil {
 		return bosherr.WrapError(err, "Sending alert")
 	}
@@ -50,7 +65,7 @@ func (as concreteAlertSender) SendAlert(monitAlert boshalert.MonitAlert) error {
 	return nil
 }
 
-func (as concreteAlertSender) SendSSHAlert(message boshsyslog.Msg) error {
+func (as *concreteAlertSender) SendSSHAlert(message boshsyslog.Msg) error {
 	var title string
 
 	if strings.Contains(message.Content, "disconnected by user") {
@@ -75,9 +90,56 @@ func (as concreteAlertSender) SendSSHAlert(message boshsyslog.Msg) error {
 	}
 
 	err = as.mbusHandler.SendToHealthManager("alert", alert)
+
+	as.countsLock.Lock()
+	if err != nil {
+		as.counts.SSHFailed++
+	} else {
+		as.counts.SSHSent++
+	}
+	as.countsLock.Unlock()
+
+	if err != nil {
+		return bosherr.WrapError(err, "Sending alert")
+	}
+
+	return nil
+}
+
+func (as *concreteAlertSender) SendClockDriftAlert(ntpInfo boshntp.NTPInfo) error {
+	uuid, err := as.uuidGenerator.Generate()
+	if err != nil {
+		return bosherr.WrapError(err, "Generating uuid")
+	}
+
+	alert := boshalert.Alert{
+		ID:        uuid,
+		Severity:  boshalert.SeverityWarning,
+		Title:     "Clock Drift",
+		Summary:   fmt.Sprintf("Clock offset from NTP server %s is %s seconds", ntpInfo.Server, ntpInfo.Offset),
+		CreatedAt: as.timeService.Now().Unix(),
+	}
+
+	err = as.mbusHandler.SendToHealthManager("alert", alert)
+
+	as.countsLock.Lock()
+	if err != nil {
+		as.counts.NTPFailed++
+	} else {
+		as.counts.NTPSent++
+	}
+	as.countsLock.Unlock()
+
 	if err != nil {
 		return bosherr.WrapError(err, "Sending alert")
 	}
 
 	return nil
 }
+
+func (as *concreteAlertSender) GetCounts() AlertCounts {
+	as.countsLock.Lock()
+	defer as.countsLock.Unlock()
+
+	return as.counts
+}
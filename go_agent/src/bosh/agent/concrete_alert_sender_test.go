@@ -11,6 +11,7 @@ import (
 	boshalert "bosh/agent/alert"
 	fakealert "bosh/agent/alert/fakes"
 	fakembus "bosh/mbus/fakes"
+	boshntp "bosh/platform/ntp"
 	boshsyslog "bosh/syslog"
 	faketime "bosh/time/fakes"
 	fakeuuid "bosh/uuid/fakes"
@@ -69,6 +70,18 @@ var _ = Describe("concreteAlertSender", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-send-to-hm-err"))
 		})
+
+		It("counts sent and failed monit alerts", func() {
+			err := alertSender.SendAlert(monitAlert)
+			Expect(err).ToNot(HaveOccurred())
+
+			handler.SendToHealthManagerErr = errors.New("fake-send-to-hm-err")
+
+			err = alertSender.SendAlert(monitAlert)
+			Expect(err).To(HaveOccurred())
+
+			Expect(alertSender.GetCounts()).To(Equal(AlertCounts{MonitSent: 1, MonitFailed: 1}))
+		})
 	})
 
 	Describe("SendSSHAlert", func() {
@@ -115,6 +128,18 @@ var _ = Describe("concreteAlertSender", func() {
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-send-to-hm-err"))
 			})
+
+			It("counts sent and failed ssh alerts", func() {
+				err := alertSender.SendSSHAlert(msg)
+				Expect(err).ToNot(HaveOccurred())
+
+				handler.SendToHealthManagerErr = errors.New("fake-send-to-hm-err")
+
+				err = alertSender.SendSSHAlert(msg)
+				Expect(err).To(HaveOccurred())
+
+				Expect(alertSender.GetCounts()).To(Equal(AlertCounts{SSHSent: 1, SSHFailed: 1}))
+			})
 		})
 
 		Context("when syslog message indicates ssh logout", func() {
@@ -166,4 +191,53 @@ var _ = Describe("concreteAlertSender", func() {
 			})
 		})
 	})
+
+	Describe("SendClockDriftAlert", func() {
+		presetNow := time.Now()
+		ntpInfo := boshntp.NTPInfo{Server: "fake-server", Offset: "-1.500000"}
+
+		BeforeEach(func() {
+			timeService.NowTime = presetNow
+			uuidGenerator.GeneratedUuid = "fake-uuid"
+		})
+
+		It("sends clock drift alert to health manager", func() {
+			err := alertSender.SendClockDriftAlert(ntpInfo)
+			Expect(err).ToNot(HaveOccurred())
+
+			expectedHMRequest := fakembus.HMRequest{
+				Topic: "alert",
+				Payload: boshalert.Alert{
+					ID:        "fake-uuid",
+					Severity:  boshalert.SeverityWarning,
+					Title:     "Clock Drift",
+					Summary:   "Clock offset from NTP server fake-server is -1.500000 seconds",
+					CreatedAt: presetNow.Unix(),
+				},
+			}
+
+			Expect(handler.HMRequests()).To(Equal([]fakembus.HMRequest{expectedHMRequest}))
+		})
+
+		It("returns error if generating uuid fails", func() {
+			uuidGenerator.GenerateError = errors.New("fake-generate-err")
+
+			err := alertSender.SendClockDriftAlert(ntpInfo)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-generate-err"))
+		})
+
+		It("counts sent and failed clock drift alerts", func() {
+			err := alertSender.SendClockDriftAlert(ntpInfo)
+			Expect(err).ToNot(HaveOccurred())
+
+			handler.SendToHealthManagerErr = errors.New("fake-send-to-hm-err")
+
+			err = alertSender.SendClockDriftAlert(ntpInfo)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-send-to-hm-err"))
+
+			Expect(alertSender.GetCounts()).To(Equal(AlertCounts{NTPSent: 1, NTPFailed: 1}))
+		})
+	})
 })
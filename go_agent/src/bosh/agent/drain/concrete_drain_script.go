@@ -65,6 +65,11 @@ func (script ConcreteDrainScript) Run(params DrainScriptParams) (int, error) {
 		command.Env["BOSH_JOB_NEXT_STATE"] = jobNextState
 	}
 
+	correlationID := params.CorrelationID()
+	if correlationID != "" {
+		command.Env[boshsys.CorrelationIDEnvVar] = correlationID
+	}
+
 	command.Args = append(command.Args, jobChange, hashChange)
 	command.Args = append(command.Args, updatedPkgs...)
 
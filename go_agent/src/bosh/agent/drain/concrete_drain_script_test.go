@@ -21,6 +21,8 @@ type fakeDrainParams struct {
 
 	jobNextState    string
 	jobNextStateErr error
+
+	correlationID string
 }
 
 func (p fakeDrainParams) JobChange() (change string)       { return p.jobChange }
@@ -29,6 +31,7 @@ func (p fakeDrainParams) UpdatedPackages() (pkgs []string) { return p.updatedPac
 
 func (p fakeDrainParams) JobState() (string, error)     { return p.jobState, p.jobStateErr }
 func (p fakeDrainParams) JobNextState() (string, error) { return p.jobNextState, p.jobNextStateErr }
+func (p fakeDrainParams) CorrelationID() string         { return p.correlationID }
 
 var _ = Describe("ConcreteDrainScript", func() {
 	var (
@@ -143,6 +146,30 @@ var _ = Describe("ConcreteDrainScript", func() {
 			})
 		})
 
+		Describe("correlation id", func() {
+			It("sets the BOSH_CORRELATION_ID env variable if correlation id is present", func() {
+				params.correlationID = "fake-correlation-id"
+
+				_, err := drainScript.Run(params)
+				Expect(err).To(HaveOccurred())
+
+				Expect(len(runner.RunComplexCommands)).To(Equal(1))
+
+				env := runner.RunComplexCommands[0].Env
+				Expect(env["BOSH_CORRELATION_ID"]).To(Equal("fake-correlation-id"))
+			})
+
+			It("does not set the BOSH_CORRELATION_ID env variable if correlation id is empty", func() {
+				params.correlationID = ""
+
+				_, err := drainScript.Run(params)
+				Expect(err).To(HaveOccurred())
+
+				Expect(len(runner.RunComplexCommands)).To(Equal(1))
+				Expect(runner.RunComplexCommands[0].Env).ToNot(HaveKey("BOSH_CORRELATION_ID"))
+			})
+		})
+
 		Describe("job next state", func() {
 			It("sets the BOSH_JOB_NEXT_STATE env variable if job next state is present", func() {
 				params.jobNextState = "fake-job-next-state"
@@ -7,4 +7,23 @@ type DrainScriptParams interface {
 
 	JobState() (string, error)
 	JobNextState() (string, error)
+
+	// Empty unless params are wrapped with NewCorrelatedDrainParams
+	CorrelationID() string
+}
+
+type correlatedDrainParams struct {
+	DrainScriptParams
+	correlationID string
+}
+
+func NewCorrelatedDrainParams(params DrainScriptParams, correlationID string) DrainScriptParams {
+	return correlatedDrainParams{
+		DrainScriptParams: params,
+		correlationID:     correlationID,
+	}
+}
+
+func (p correlatedDrainParams) CorrelationID() string {
+	return p.correlationID
 }
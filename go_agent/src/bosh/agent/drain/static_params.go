@@ -50,3 +50,7 @@ func (p staticDrainParams) JobState() (string, error) {
 func (p staticDrainParams) JobNextState() (string, error) {
 	return newPresentedJobState(p.newSpec).MarshalToJSONString()
 }
+
+func (p staticDrainParams) CorrelationID() string {
+	return ""
+}
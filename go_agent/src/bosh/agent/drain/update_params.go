@@ -58,3 +58,7 @@ func (p updateDrainParams) JobState() (string, error) {
 func (p updateDrainParams) JobNextState() (string, error) {
 	return newPresentedJobState(&p.newSpec).MarshalToJSONString()
 }
+
+func (p updateDrainParams) CorrelationID() string {
+	return ""
+}
@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"time"
+
 	boshhandler "bosh/handler"
 )
 
@@ -9,6 +11,9 @@ type FakeActionDispatcher struct {
 
 	DispatchReq  boshhandler.Request
 	DispatchResp boshhandler.Response
+
+	ShutdownCalled  bool
+	ShutdownTimeout time.Duration
 }
 
 func (dispatcher *FakeActionDispatcher) ResumePreviouslyDispatchedTasks() {
@@ -19,3 +24,8 @@ func (dispatcher *FakeActionDispatcher) Dispatch(req boshhandler.Request) boshha
 	dispatcher.DispatchReq = req
 	return dispatcher.DispatchResp
 }
+
+func (dispatcher *FakeActionDispatcher) Shutdown(timeout time.Duration) {
+	dispatcher.ShutdownCalled = true
+	dispatcher.ShutdownTimeout = timeout
+}
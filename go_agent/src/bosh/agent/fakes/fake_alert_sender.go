@@ -1,7 +1,9 @@
 package fakes
 
 import (
+	boshagent "bosh/agent"
 	boshalert "bosh/agent/alert"
+	boshntp "bosh/platform/ntp"
 	boshsyslog "bosh/syslog"
 )
 
@@ -11,6 +13,11 @@ type FakeAlertSender struct {
 
 	SendSSHAlertMsg boshsyslog.Msg
 	SendSSHAlertErr error
+
+	SendClockDriftAlertNTPInfo boshntp.NTPInfo
+	SendClockDriftAlertErr     error
+
+	Counts boshagent.AlertCounts
 }
 
 func (as *FakeAlertSender) SendAlert(monitAlert boshalert.MonitAlert) error {
@@ -22,3 +29,12 @@ func (as *FakeAlertSender) SendSSHAlert(msg boshsyslog.Msg) error {
 	as.SendSSHAlertMsg = msg
 	return as.SendSSHAlertErr
 }
+
+func (as *FakeAlertSender) SendClockDriftAlert(ntpInfo boshntp.NTPInfo) error {
+	as.SendClockDriftAlertNTPInfo = ntpInfo
+	return as.SendClockDriftAlertErr
+}
+
+func (as *FakeAlertSender) GetCounts() boshagent.AlertCounts {
+	return as.Counts
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"sync"
+	"time"
+)
+
+const pendingCounterPollInterval = 100 * time.Millisecond
+
+// pendingCounter tracks operations that should complete before shutdown;
+// unlike sync.WaitGroup it can be incremented while someone is waiting
+type pendingCounter struct {
+	count int
+	lock  sync.Mutex
+}
+
+func (c *pendingCounter) Add(delta int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.count += delta
+}
+
+func (c *pendingCounter) Count() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.count
+}
+
+// waitUntil returns false if condition is not met before timeout
+func waitUntil(condition func() bool, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		time.Sleep(pendingCounterPollInterval)
+	}
+
+	return true
+}
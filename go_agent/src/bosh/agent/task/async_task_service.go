@@ -1,6 +1,8 @@
 package task
 
 import (
+	"sort"
+
 	boshlog "bosh/logger"
 	boshuuid "bosh/uuid"
 )
@@ -13,6 +15,7 @@ type asyncTaskService struct {
 	logger  boshlog.Logger
 
 	currentTasks map[string]Task
+	counts       *Counts
 	taskChan     chan Task
 	taskSem      chan func()
 }
@@ -22,6 +25,7 @@ func NewAsyncTaskService(uuidGen boshuuid.Generator, logger boshlog.Logger) (ser
 		uuidGen:      uuidGen,
 		logger:       logger,
 		currentTasks: make(map[string]Task),
+		counts:       &Counts{},
 		taskChan:     make(chan Task),
 		taskSem:      make(chan func()),
 	}
@@ -65,6 +69,7 @@ func (service asyncTaskService) StartTask(task Task) {
 
 	service.taskSem <- func() {
 		service.currentTasks[task.ID] = task
+		service.counts.Queued++
 		taskChan <- task
 	}
 
@@ -85,6 +90,33 @@ func (service asyncTaskService) FindTaskWithID(id string) (Task, bool) {
 	return <-taskChan, <-foundChan
 }
 
+func (service asyncTaskService) GetTasks() []Task {
+	tasksChan := make(chan []Task)
+
+	service.taskSem <- func() {
+		tasks := make([]Task, 0, len(service.currentTasks))
+		for _, task := range service.currentTasks {
+			tasks = append(tasks, task)
+		}
+		tasksChan <- tasks
+	}
+
+	tasks := <-tasksChan
+	sort.Sort(tasksByID(tasks))
+
+	return tasks
+}
+
+func (service asyncTaskService) GetCounts() Counts {
+	countsChan := make(chan Counts)
+
+	service.taskSem <- func() {
+		countsChan <- *service.counts
+	}
+
+	return <-countsChan
+}
+
 func (service asyncTaskService) processSemFuncs() {
 	defer service.logger.HandlePanic("Task Service Process Sem Funcs")
 
@@ -100,11 +132,17 @@ func (service asyncTaskService) processTasks() {
 	for {
 		task := <-service.taskChan
 
+		service.taskSem <- func() {
+			service.counts.Queued--
+			service.counts.Running++
+		}
+
 		value, err := task.TaskFunc()
 		if err != nil {
 			task.Error = err
 			task.State = TaskStateFailed
-			service.logger.Error("Task Service", "Failed processing task #%s got: %s", task.ID, err.Error())
+			service.logger.WithCorrelationID(task.CorrelationID).Error(
+				"Task Service", "Failed processing task #%s got: %s", task.ID, err.Error())
 		} else {
 			task.Value = value
 			task.State = TaskStateDone
@@ -116,6 +154,13 @@ func (service asyncTaskService) processTasks() {
 
 		service.taskSem <- func() {
 			service.currentTasks[task.ID] = task
+			service.counts.Running--
+
+			if task.State == TaskStateFailed {
+				service.counts.Failed++
+			} else {
+				service.counts.Done++
+			}
 		}
 	}
 }
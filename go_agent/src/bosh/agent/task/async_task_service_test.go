@@ -156,6 +156,68 @@ func init() {
 			})
 		})
 
+		Describe("GetTasks", func() {
+			It("returns started tasks ordered by id", func() {
+				releaseChan := make(chan struct{})
+
+				blockingFunc := func() (interface{}, error) {
+					<-releaseChan
+					return nil, nil
+				}
+
+				task2 := service.CreateTaskWithID("fake-task-2", blockingFunc, nil, nil)
+				task1 := service.CreateTaskWithID("fake-task-1", blockingFunc, nil, nil)
+
+				service.StartTask(task2)
+				go service.StartTask(task1)
+
+				Eventually(func() []string {
+					ids := []string{}
+					for _, task := range service.GetTasks() {
+						ids = append(ids, task.ID)
+					}
+					return ids
+				}).Should(Equal([]string{"fake-task-1", "fake-task-2"}))
+
+				close(releaseChan)
+			})
+		})
+
+		Describe("GetCounts", func() {
+			It("returns zero counts when no tasks were started", func() {
+				Expect(service.GetCounts()).To(Equal(Counts{}))
+			})
+
+			It("counts running, queued, done and failed tasks", func() {
+				releaseChan := make(chan struct{})
+
+				blockingFunc := func() (interface{}, error) {
+					<-releaseChan
+					return nil, nil
+				}
+				failingFunc := func() (interface{}, error) {
+					return nil, errors.New("fake-error")
+				}
+
+				uuidGen.GeneratedUuid = "fake-task-1"
+				task1, err := service.CreateTask(blockingFunc, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				uuidGen.GeneratedUuid = "fake-task-2"
+				task2, err := service.CreateTask(failingFunc, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				service.StartTask(task1)
+				go service.StartTask(task2)
+
+				Eventually(service.GetCounts).Should(Equal(Counts{Queued: 1, Running: 1}))
+
+				close(releaseChan)
+
+				Eventually(service.GetCounts).Should(Equal(Counts{Done: 1, Failed: 1}))
+			})
+		})
+
 		Describe("CreateTask", func() {
 			It("creates a task with auto-assigned id", func() {
 				uuidGen.GeneratedUuid = "fake-uuid"
@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"sort"
+
 	boshtask "bosh/agent/task"
 )
 
@@ -8,6 +10,8 @@ type FakeService struct {
 	StartedTasks        map[string]boshtask.Task
 	CreateTaskErr       error
 	CreateTaskWithIDErr error
+
+	Counts boshtask.Counts
 }
 
 func NewFakeService() *FakeService {
@@ -50,3 +54,24 @@ func (s *FakeService) FindTaskWithID(id string) (boshtask.Task, bool) {
 	task, found := s.StartedTasks[id]
 	return task, found
 }
+
+func (s *FakeService) GetTasks() []boshtask.Task {
+	tasks := []boshtask.Task{}
+	for _, task := range s.StartedTasks {
+		tasks = append(tasks, task)
+	}
+
+	sort.Sort(tasksByID(tasks))
+
+	return tasks
+}
+
+type tasksByID []boshtask.Task
+
+func (s tasksByID) Len() int           { return len(s) }
+func (s tasksByID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+func (s tasksByID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func (s *FakeService) GetCounts() boshtask.Counts {
+	return s.Counts
+}
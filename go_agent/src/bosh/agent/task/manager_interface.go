@@ -8,6 +8,8 @@ type TaskInfo struct {
 	TaskID  string
 	Method  string
 	Payload []byte
+
+	CorrelationID string
 }
 
 type ManagerProvider interface {
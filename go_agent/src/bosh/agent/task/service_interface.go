@@ -8,4 +8,11 @@ type Service interface {
 	// Records that task to run later
 	StartTask(Task)
 	FindTaskWithID(string) (Task, bool)
+
+	// Returns all recorded tasks ordered by ID
+	GetTasks() []Task
+
+	// Number of queued and running tasks,
+	// and totals of finished tasks since start
+	GetCounts() Counts
 }
@@ -20,6 +20,9 @@ type Task struct {
 	Value interface{}
 	Error error
 
+	// Correlation ID of the request that created the task
+	CorrelationID string
+
 	TaskFunc    TaskFunc
 	CancelFunc  TaskCancelFunc
 	TaskEndFunc TaskEndFunc
@@ -32,6 +35,19 @@ func (t Task) Cancel() error {
 	return nil
 }
 
+type tasksByID []Task
+
+func (s tasksByID) Len() int           { return len(s) }
+func (s tasksByID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+func (s tasksByID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+type Counts struct {
+	Queued  int
+	Running int
+	Done    int
+	Failed  int
+}
+
 type TaskStateValue struct {
 	AgentTaskID string    `json:"agent_task_id"`
 	State       TaskState `json:"state"`
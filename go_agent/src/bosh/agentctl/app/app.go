@@ -0,0 +1,88 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	bosherr "bosh/errors"
+	boshlocalapi "bosh/localapi"
+)
+
+const usage = "Usage: bosh-agent-ctl [-s socket] <action> [arguments...]"
+
+type App struct {
+	client boshlocalapi.Client
+	stdout io.Writer
+}
+
+type response struct {
+	Value     *json.RawMessage `json:"value"`
+	Exception *struct {
+		Message string `json:"message"`
+	} `json:"exception"`
+}
+
+func New(client boshlocalapi.Client, stdout io.Writer) (app App) {
+	app.client = client
+	app.stdout = stdout
+	return
+}
+
+// Run calls action named by the first argument and prints returned value.
+// Arguments that are valid JSON (numbers, booleans, objects) are passed as such;
+// others are passed as strings.
+func (app App) Run(args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	arguments := []interface{}{}
+
+	for _, arg := range args[1:] {
+		arguments = append(arguments, parseArgument(arg))
+	}
+
+	respBytes, err := app.client.Call(args[0], arguments)
+	if err != nil {
+		return bosherr.WrapError(err, "Calling agent")
+	}
+
+	var resp response
+
+	err = json.Unmarshal(respBytes, &resp)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling response")
+	}
+
+	if resp.Exception != nil {
+		return errors.New(resp.Exception.Message)
+	}
+
+	if resp.Value == nil {
+		return nil
+	}
+
+	var out bytes.Buffer
+
+	err = json.Indent(&out, *resp.Value, "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Formatting value")
+	}
+
+	_, err = fmt.Fprintln(app.stdout, out.String())
+	return err
+}
+
+func parseArgument(arg string) interface{} {
+	var value interface{}
+
+	err := json.Unmarshal([]byte(arg), &value)
+	if err != nil {
+		return arg
+	}
+
+	return value
+}
@@ -0,0 +1,67 @@
+package app_test
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agentctl/app"
+	fakelocalapi "bosh/localapi/fakes"
+)
+
+var _ = Describe("App", func() {
+	var (
+		client *fakelocalapi.FakeClient
+		stdout *bytes.Buffer
+		app    App
+	)
+
+	BeforeEach(func() {
+		client = &fakelocalapi.FakeClient{}
+		stdout = &bytes.Buffer{}
+		app = New(client, stdout)
+	})
+
+	It("calls action with arguments and prints formatted value", func() {
+		client.CallResponse = []byte(`{"value":{"job_state":"running"}}`)
+
+		err := app.Run([]string{"get_state", "full"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(client.CallMethod).To(Equal("get_state"))
+		Expect(client.CallArguments).To(Equal([]interface{}{"full"}))
+		Expect(stdout.String()).To(Equal("{\n  \"job_state\": \"running\"\n}\n"))
+	})
+
+	It("passes arguments that are valid JSON as JSON values", func() {
+		client.CallResponse = []byte(`{"value":[]}`)
+
+		err := app.Run([]string{"get_vitals_history", "0", "100", "60"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client.CallArguments).To(Equal([]interface{}{0.0, 100.0, 60.0}))
+	})
+
+	It("returns exception message from agent as error", func() {
+		client.CallResponse = []byte(`{"exception":{"message":"fake-exception"}}`)
+
+		err := app.Run([]string{"get_task", "fake-task-id"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("fake-exception"))
+	})
+
+	It("returns error when agent cannot be called", func() {
+		client.CallErr = errors.New("fake-call-error")
+
+		err := app.Run([]string{"ping"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-call-error"))
+	})
+
+	It("returns usage when action is not given", func() {
+		err := app.Run([]string{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Usage"))
+	})
+})
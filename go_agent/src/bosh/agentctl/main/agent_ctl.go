@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"bosh/agentctl/app"
+	boshlocalapi "bosh/localapi"
+	boshdir "bosh/settings/directories"
+)
+
+const callTimeout = 1 * time.Minute
+
+func main() {
+	defaultSocketPath := boshlocalapi.Options{}.SocketPathOrDefault(boshdir.NewDirectoriesProvider("/var/vcap"))
+
+	flagSet := flag.NewFlagSet("bosh-agent-ctl", flag.ExitOnError)
+	socketPath := flagSet.String("s", defaultSocketPath, "Agent socket path")
+	flagSet.Parse(os.Args[1:])
+
+	client := boshlocalapi.NewUnixSocketClient(*socketPath, callTimeout)
+
+	cli := app.New(client, os.Stdout)
+
+	err := cli.Run(flagSet.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
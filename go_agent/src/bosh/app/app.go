@@ -17,14 +17,21 @@ import (
 	boshtask "bosh/agent/task"
 	boshblob "bosh/blobstore"
 	boshboot "bosh/bootstrap"
+	boshcrypto "bosh/crypto"
 	bosherr "bosh/errors"
+	boshhandler "bosh/handler"
 	boshinf "bosh/infrastructure"
+	boshjobmetrics "bosh/jobmetrics"
 	boshjobsuper "bosh/jobsupervisor"
 	boshmonit "bosh/jobsupervisor/monit"
+	boshlocalapi "bosh/localapi"
 	boshlog "bosh/logger"
 	boshmbus "bosh/mbus"
+	boshmetrics "bosh/metrics"
 	boshnotif "bosh/notification"
 	boshplatform "bosh/platform"
+	boshntp "bosh/platform/ntp"
+	boshvitals "bosh/platform/vitals"
 	boshsettings "bosh/settings"
 	boshdirs "bosh/settings/directories"
 	boshsyslog "bosh/syslog"
@@ -38,6 +45,13 @@ type app struct {
 	agent          boshagent.Agent
 	platform       boshplatform.Platform
 	infrastructure boshinf.Infrastructure
+	metricsServer  boshmetrics.Server
+	vitalsHistory  boshvitals.History
+	sntpService    boshntp.SNTPService
+	alertSender    boshagent.AlertSender
+
+	localAPIHandler  boshhandler.Handler
+	actionDispatcher boshagent.ActionDispatcher
 }
 
 func New(logger boshlog.Logger) app {
@@ -88,7 +102,7 @@ func (app *app) Setup(args []string) error {
 		return bosherr.WrapError(err, "Running bootstrap")
 	}
 
-	mbusHandlerProvider := boshmbus.NewHandlerProvider(settingsService, app.logger)
+	mbusHandlerProvider := boshmbus.NewHandlerProvider(settingsService, config.Micro, app.logger)
 
 	mbusHandler, err := mbusHandlerProvider.Get(app.platform, dirProvider)
 	if err != nil {
@@ -97,11 +111,32 @@ func (app *app) Setup(args []string) error {
 
 	blobstoreProvider := boshblob.NewProvider(app.platform, dirProvider, app.logger)
 
-	blobstore, err := blobstoreProvider.Get(settingsService.GetSettings().Blobstore)
+	providedBlobstore, err := blobstoreProvider.Get(settingsService.GetSettings().Blobstore)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting blobstore")
 	}
 
+	countingBlobstore := boshblob.NewCountingBlobstore(providedBlobstore, app.platform.GetFs())
+
+	// Cache is placed outside of counting blobstore so that only actual transfers are counted
+	blobstore := boshblob.NewCachingBlobstore(
+		countingBlobstore,
+		filepath.Join(dirProvider.DataDir(), "blob_cache"),
+		config.BlobCache,
+		app.platform.GetFs(),
+		app.logger,
+	)
+
+	err = blobstore.PrepareCacheDir()
+	if err != nil {
+		return bosherr.WrapError(err, "Preparing blob cache")
+	}
+
+	var blobCacheStatsProvider boshblob.CacheStatsProvider
+	if config.BlobCache.MaxSizeBytes >= 0 {
+		blobCacheStatsProvider = blobstore
+	}
+
 	monitClientProvider := boshmonit.NewProvider(app.platform, app.logger)
 
 	monitClient, err := monitClientProvider.Get()
@@ -124,7 +159,12 @@ func (app *app) Setup(args []string) error {
 
 	notifier := boshnotif.NewNotifier(mbusHandler)
 
-	applier, compiler := app.buildApplierAndCompiler(dirProvider, blobstore, jobSupervisor)
+	signatureVerifier, err := boshcrypto.NewSignatureVerifier(settingsService.GetSettings().BlobSigning, app.logger)
+	if err != nil {
+		return bosherr.WrapError(err, "Building blob signature verifier")
+	}
+
+	applier, compiler := app.buildApplierAndCompiler(dirProvider, blobstore, signatureVerifier, jobSupervisor)
 
 	uuidGen := boshuuid.NewGenerator()
 
@@ -150,6 +190,32 @@ func (app *app) Setup(args []string) error {
 		dirProvider,
 	)
 
+	app.vitalsHistory = boshvitals.NewHistory(
+		app.platform.GetVitalsService(),
+		app.platform.GetFs(),
+		timeService,
+		config.VitalsHistory,
+		app.logger,
+	)
+
+	// Only handlers which maintain a connection report its status
+	mbusStatusProvider, _ := mbusHandler.(boshmbus.ConnectionStatusProvider)
+
+	var ntpService boshntp.Service
+
+	if config.Ntp.IntervalSeconds > 0 {
+		app.sntpService = boshntp.NewSNTPService(
+			boshntp.NewUDPSNTPClient(time.Duration(config.Ntp.TimeoutSeconds)*time.Second),
+			settingsService,
+			timeService,
+			config.Ntp,
+			app.logger,
+		)
+		ntpService = app.sntpService
+	} else {
+		ntpService = boshntp.NewConcreteService(app.platform.GetFs(), dirProvider)
+	}
+
 	actionFactory := boshaction.NewFactory(
 		settingsService,
 		app.platform,
@@ -161,6 +227,10 @@ func (app *app) Setup(args []string) error {
 		jobSupervisor,
 		specService,
 		drainScriptProvider,
+		app.vitalsHistory,
+		ntpService,
+		mbusStatusProvider,
+		blobCacheStatsProvider,
 		app.logger,
 	)
 
@@ -176,7 +246,7 @@ func (app *app) Setup(args []string) error {
 
 	alertBuilder := boshalert.NewBuilder(settingsService, app.logger)
 
-	alertSender := boshagent.NewConcreteAlertSender(
+	app.alertSender = boshagent.NewConcreteAlertSender(
 		mbusHandler,
 		alertBuilder,
 		uuidGen,
@@ -185,29 +255,117 @@ func (app *app) Setup(args []string) error {
 
 	syslogServer := boshsyslog.NewServer(33331, app.logger)
 
+	jobMetricsCollector := boshjobmetrics.NewConcreteCollector(
+		app.platform.GetFs(),
+		dirProvider,
+		timeService,
+		config.JobMetrics,
+		app.logger,
+	)
+
 	app.agent = boshagent.New(
 		app.logger,
 		mbusHandler,
 		app.platform,
 		actionDispatcher,
-		alertSender,
+		app.alertSender,
 		jobSupervisor,
 		specService,
 		syslogServer,
+		jobMetricsCollector,
 		time.Minute,
+		config.Agent,
 	)
 
+	app.actionDispatcher = actionDispatcher
+
+	if !config.LocalAPI.Disabled {
+		app.localAPIHandler = boshlocalapi.NewUnixSocketHandler(
+			config.LocalAPI.SocketPathOrDefault(dirProvider),
+			config.LocalAPI.AllowedActionsOrDefault(),
+			config.LocalAPI.IOTimeoutOrDefault(),
+			app.platform.GetFs(),
+			app.logger,
+		)
+	}
+
+	if config.Metrics.ListenAddress != "" {
+		metricsCollector := boshmetrics.NewAgentCollector(
+			app.platform.GetVitalsService(),
+			jobSupervisor,
+			taskService,
+			countingBlobstore,
+			app.alertSender,
+			ntpService,
+			app.logger,
+		)
+
+		app.metricsServer = boshmetrics.NewServer(config.Metrics, metricsCollector, app.logger)
+	}
+
 	return nil
 }
 
 func (app *app) Run() error {
+	if app.metricsServer != nil {
+		go func() {
+			err := app.metricsServer.Start()
+			if err != nil {
+				app.logger.Error("App", "Metrics server failed: %s", err.Error())
+			}
+		}()
+	}
+
+	go app.vitalsHistory.Start()
+
+	if app.localAPIHandler != nil {
+		err := app.localAPIHandler.Start(app.actionDispatcher.Dispatch)
+		if err != nil {
+			app.logger.Error("App", "Local API failed: %s", err.Error())
+		}
+	}
+
+	if app.sntpService != nil {
+		go app.sntpService.Start(app.handleClockDrift)
+	}
+
 	err := app.agent.Run()
+
+	// Agent has drained in-flight tasks by the time it returns without error
+	app.stop()
+
 	if err != nil {
 		return bosherr.WrapError(err, "Running agent")
 	}
 	return nil
 }
 
+func (app *app) stop() {
+	if app.localAPIHandler != nil {
+		app.localAPIHandler.Stop()
+	}
+
+	if app.sntpService != nil {
+		app.sntpService.Stop()
+	}
+
+	app.vitalsHistory.Stop()
+
+	if app.metricsServer != nil {
+		err := app.metricsServer.Stop()
+		if err != nil {
+			app.logger.Error("App", "Stopping metrics server: %s", err.Error())
+		}
+	}
+}
+
+func (app *app) handleClockDrift(ntpInfo boshntp.NTPInfo) {
+	err := app.alertSender.SendClockDriftAlert(ntpInfo)
+	if err != nil {
+		app.logger.Error("App", "Sending clock drift alert: %s", err.Error())
+	}
+}
+
 func (app *app) GetPlatform() boshplatform.Platform {
 	return app.platform
 }
@@ -219,6 +377,7 @@ func (app *app) GetInfrastructure() boshinf.Infrastructure {
 func (app *app) buildApplierAndCompiler(
 	dirProvider boshdirs.DirectoriesProvider,
 	blobstore boshblob.Blobstore,
+	signatureVerifier boshcrypto.SignatureVerifier,
 	jobSupervisor boshjobsuper.JobSupervisor,
 ) (boshapplier.Applier, boshcomp.Compiler) {
 	jobsBc := boshbc.NewFileBundleCollection(
@@ -235,6 +394,7 @@ func (app *app) buildApplierAndCompiler(
 		dirProvider.JobsDir(),
 		"packages",
 		blobstore,
+		signatureVerifier,
 		app.platform.GetCompressor(),
 		app.platform.GetFs(),
 		app.logger,
@@ -245,6 +405,7 @@ func (app *app) buildApplierAndCompiler(
 		jobSupervisor,
 		packageApplierProvider,
 		blobstore,
+		signatureVerifier,
 		app.platform.GetCompressor(),
 		app.platform.GetFs(),
 		app.logger,
@@ -266,6 +427,7 @@ func (app *app) buildApplierAndCompiler(
 		dirProvider,
 		packageApplierProvider.Root(),
 		packageApplierProvider.RootBundleCollection(),
+		signatureVerifier,
 	)
 
 	return applier, compiler
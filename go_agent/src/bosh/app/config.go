@@ -3,13 +3,31 @@ package app
 import (
 	"encoding/json"
 
+	boshagent "bosh/agent"
+	boshblob "bosh/blobstore"
 	bosherr "bosh/errors"
+	boshjobmetrics "bosh/jobmetrics"
+	boshlocalapi "bosh/localapi"
+	boshmetrics "bosh/metrics"
+	boshmicro "bosh/micro"
 	boshplatform "bosh/platform"
+	boshntp "bosh/platform/ntp"
+	boshvitals "bosh/platform/vitals"
 	boshsys "bosh/system"
 )
 
 type Config struct {
 	Platform boshplatform.ProviderOptions
+	Agent    boshagent.Options
+	Metrics  boshmetrics.Options
+	Micro    boshmicro.Options
+	LocalAPI boshlocalapi.Options
+
+	BlobCache boshblob.CacheOptions
+
+	VitalsHistory boshvitals.HistoryOptions
+	JobMetrics    boshjobmetrics.Options
+	Ntp           boshntp.SNTPOptions
 }
 
 func LoadConfigFromPath(fs boshsys.FileSystem, path string) (Config, error) {
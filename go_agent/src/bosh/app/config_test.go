@@ -6,7 +6,14 @@ import (
 
 	. "bosh/app"
 
+	boshagent "bosh/agent"
+	boshjobmetrics "bosh/jobmetrics"
+	boshlocalapi "bosh/localapi"
+	boshmetrics "bosh/metrics"
+	boshmicro "bosh/micro"
 	boshplatform "bosh/platform"
+	boshntp "bosh/platform/ntp"
+	boshvitals "bosh/platform/vitals"
 	fakesys "bosh/system/fakes"
 )
 
@@ -27,6 +34,36 @@ var _ = Describe("LoadConfigFromPath", func() {
 					"UsePreformattedPersistentDisk": true,
 					"BindMountPersistentDisk": true
 				}
+			},
+			"Agent": {
+				"HeartbeatExtendedVitals": true
+			},
+			"Metrics": {
+				"ListenAddress": ":9100",
+				"Username": "fake-user",
+				"Password": "fake-pass"
+			},
+			"Micro": {
+				"HealthQueueSize": 50,
+				"HealthManagerURL": "https://fake-hm"
+			},
+			"LocalAPI": {
+				"SocketPath": "/fake-agent.sock",
+				"AllowedActions": ["ping"]
+			},
+			"VitalsHistory": {
+				"IntervalSeconds": 10,
+				"Capacity": 360,
+				"Path": "/fake-vitals-history.json"
+			},
+			"JobMetrics": {
+				"MaxMetricsPerJob": 50,
+				"MaxAgeSeconds": 300
+			},
+			"Ntp": {
+				"IntervalSeconds": 60,
+				"TimeoutSeconds": 2,
+				"DriftThresholdMilliseconds": 500
 			}
 		}`)
 
@@ -40,6 +77,36 @@ var _ = Describe("LoadConfigFromPath", func() {
 					BindMountPersistentDisk:       true,
 				},
 			},
+			Agent: boshagent.Options{
+				HeartbeatExtendedVitals: true,
+			},
+			Metrics: boshmetrics.Options{
+				ListenAddress: ":9100",
+				Username:      "fake-user",
+				Password:      "fake-pass",
+			},
+			Micro: boshmicro.Options{
+				HealthQueueSize:  50,
+				HealthManagerURL: "https://fake-hm",
+			},
+			LocalAPI: boshlocalapi.Options{
+				SocketPath:     "/fake-agent.sock",
+				AllowedActions: []string{"ping"},
+			},
+			VitalsHistory: boshvitals.HistoryOptions{
+				IntervalSeconds: 10,
+				Capacity:        360,
+				Path:            "/fake-vitals-history.json",
+			},
+			JobMetrics: boshjobmetrics.Options{
+				MaxMetricsPerJob: 50,
+				MaxAgeSeconds:    300,
+			},
+			Ntp: boshntp.SNTPOptions{
+				IntervalSeconds:            60,
+				TimeoutSeconds:             2,
+				DriftThresholdMilliseconds: 500,
+			},
 		}))
 	})
 
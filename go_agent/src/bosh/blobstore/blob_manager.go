@@ -1,12 +1,25 @@
 package blobstore
 
 import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
 	bosherr "bosh/errors"
 	boshdir "bosh/settings/directories"
 	boshsys "bosh/system"
-	"path/filepath"
 )
 
+// Blob IDs are used as file names so they cannot contain path separators
+// and cannot start with a dot
+var blobIDRegexp = regexp.MustCompile(`\A[A-Za-z0-9][A-Za-z0-9._-]*\z`)
+
+// Incomplete uploads are kept apart so that they are never served as blobs
+const partialBlobsDirName = ".partial"
+
 type BlobManager struct {
 	fs          boshsys.FileSystem
 	dirProvider boshdir.DirectoriesProvider
@@ -18,6 +31,10 @@ func NewBlobManager(fs boshsys.FileSystem, dirProvider boshdir.DirectoriesProvid
 	return
 }
 
+func IsValidBlobID(blobID string) bool {
+	return blobIDRegexp.MatchString(blobID)
+}
+
 func (manager BlobManager) Fetch(blobID string) (blobBytes []byte, err error) {
 	blobPath := filepath.Join(manager.dirProvider.MicroStore(), blobID)
 
@@ -37,3 +54,156 @@ func (manager BlobManager) Write(blobID string, blobBytes []byte) (err error) {
 	}
 	return
 }
+
+func (manager BlobManager) Exists(blobID string) bool {
+	if !IsValidBlobID(blobID) {
+		return false
+	}
+
+	return manager.fs.FileExists(manager.blobPath(blobID))
+}
+
+// Open returns blob for reading; caller must close it
+func (manager BlobManager) Open(blobID string) (boshsys.File, error) {
+	if !IsValidBlobID(blobID) {
+		return nil, bosherr.New("Invalid blob ID '%s'", blobID)
+	}
+
+	file, err := manager.fs.OpenFile(manager.blobPath(blobID), os.O_RDONLY, 0)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Opening blob")
+	}
+
+	return file, nil
+}
+
+// PartialSize returns number of bytes uploaded so far for an incomplete blob
+func (manager BlobManager) PartialSize(blobID string) (int64, error) {
+	if !IsValidBlobID(blobID) {
+		return 0, bosherr.New("Invalid blob ID '%s'", blobID)
+	}
+
+	if !manager.fs.FileExists(manager.partialPath(blobID)) {
+		return 0, nil
+	}
+
+	file, err := manager.fs.OpenFile(manager.partialPath(blobID), os.O_RDONLY, 0)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Opening partial blob")
+	}
+
+	defer file.Close()
+
+	size, err := file.Seek(0, 2)
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Seeking to end of partial blob")
+	}
+
+	return size, nil
+}
+
+// WritePartial streams reader into an incomplete blob. Writing at offset 0
+// starts over; any other offset must match current PartialSize.
+// Returns size of the incomplete blob after writing.
+func (manager BlobManager) WritePartial(blobID string, offset int64, reader io.Reader) (int64, error) {
+	if !IsValidBlobID(blobID) {
+		return 0, bosherr.New("Invalid blob ID '%s'", blobID)
+	}
+
+	err := manager.fs.MkdirAll(filepath.Dir(manager.partialPath(blobID)), os.FileMode(0700))
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Creating partial blobs directory")
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+
+	if offset > 0 {
+		size, err := manager.PartialSize(blobID)
+		if err != nil {
+			return 0, err
+		}
+
+		if size != offset {
+			return size, bosherr.New("Partial blob has %d bytes; cannot write at offset %d", size, offset)
+		}
+
+		flag = os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := manager.fs.OpenFile(manager.partialPath(blobID), flag, os.FileMode(0600))
+	if err != nil {
+		return 0, bosherr.WrapError(err, "Opening partial blob")
+	}
+
+	defer file.Close()
+
+	written, err := io.Copy(file, reader)
+	if err != nil {
+		return offset + written, bosherr.WrapError(err, "Updating blob")
+	}
+
+	return offset + written, nil
+}
+
+// PartialSHA1 returns hex encoded SHA1 of an incomplete blob
+func (manager BlobManager) PartialSHA1(blobID string) (string, error) {
+	if !IsValidBlobID(blobID) {
+		return "", bosherr.New("Invalid blob ID '%s'", blobID)
+	}
+
+	file, err := manager.fs.OpenFile(manager.partialPath(blobID), os.O_RDONLY, 0)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening partial blob")
+	}
+
+	defer file.Close()
+
+	hash := sha1.New()
+
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Calculating SHA1")
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// CompletePartial makes an incomplete blob available, replacing existing blob
+func (manager BlobManager) CompletePartial(blobID string) error {
+	if !IsValidBlobID(blobID) {
+		return bosherr.New("Invalid blob ID '%s'", blobID)
+	}
+
+	err := manager.fs.MkdirAll(manager.dirProvider.MicroStore(), os.FileMode(0700))
+	if err != nil {
+		return bosherr.WrapError(err, "Creating blobs directory")
+	}
+
+	err = manager.fs.Rename(manager.partialPath(blobID), manager.blobPath(blobID))
+	if err != nil {
+		return bosherr.WrapError(err, "Moving partial blob")
+	}
+
+	return nil
+}
+
+func (manager BlobManager) DiscardPartial(blobID string) error {
+	if !IsValidBlobID(blobID) {
+		return bosherr.New("Invalid blob ID '%s'", blobID)
+	}
+
+	err := manager.fs.RemoveAll(manager.partialPath(blobID))
+	if err != nil {
+		return bosherr.WrapError(err, "Removing partial blob")
+	}
+
+	return nil
+}
+
+func (manager BlobManager) blobPath(blobID string) string {
+	return filepath.Join(manager.dirProvider.MicroStore(), blobID)
+}
+
+func (manager BlobManager) partialPath(blobID string) string {
+	return filepath.Join(manager.dirProvider.MicroStore(), partialBlobsDirName, blobID)
+}
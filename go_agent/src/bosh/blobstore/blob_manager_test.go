@@ -1,6 +1,9 @@
 package blobstore_test
 
 import (
+	"io/ioutil"
+	"strings"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -38,5 +41,109 @@ func init() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(contents).To(Equal("new data"))
 		})
+
+		Describe("IsValidBlobID", func() {
+			It("accepts ids used as file names", func() {
+				Expect(IsValidBlobID("105d33ae-655c-493d-bf9f-1df5cf3ca847")).To(BeTrue())
+				Expect(IsValidBlobID("stemcell_1.0.tgz")).To(BeTrue())
+			})
+
+			It("rejects ids that could escape blobs directory", func() {
+				Expect(IsValidBlobID("")).To(BeFalse())
+				Expect(IsValidBlobID("..")).To(BeFalse())
+				Expect(IsValidBlobID(".partial")).To(BeFalse())
+				Expect(IsValidBlobID("../etc/passwd")).To(BeFalse())
+				Expect(IsValidBlobID("a/b")).To(BeFalse())
+			})
+		})
+
+		Describe("partial blobs", func() {
+			It("writes partial blob in pieces and completes it", func() {
+				blobManager, fs := createBlobManager()
+
+				size, err := blobManager.WritePartial("fake-blob-id", 0, strings.NewReader("some "))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(size).To(Equal(int64(5)))
+
+				Expect(blobManager.Exists("fake-blob-id")).To(BeFalse())
+
+				size, err = blobManager.WritePartial("fake-blob-id", 5, strings.NewReader("data"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(size).To(Equal(int64(9)))
+
+				partialSize, err := blobManager.PartialSize("fake-blob-id")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(partialSize).To(Equal(int64(9)))
+
+				sha1, err := blobManager.PartialSHA1("fake-blob-id")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sha1).To(Equal("baf34551fecb48acc3da868eb85e1b6dac9de356"))
+
+				err = blobManager.CompletePartial("fake-blob-id")
+				Expect(err).ToNot(HaveOccurred())
+
+				contents, err := fs.ReadFileString("/var/vcap/micro_bosh/data/cache/fake-blob-id")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(contents).To(Equal("some data"))
+				Expect(fs.FileExists("/var/vcap/micro_bosh/data/cache/.partial/fake-blob-id")).To(BeFalse())
+			})
+
+			It("starts over when writing at offset 0", func() {
+				blobManager, fs := createBlobManager()
+
+				_, err := blobManager.WritePartial("fake-blob-id", 0, strings.NewReader("old data"))
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = blobManager.WritePartial("fake-blob-id", 0, strings.NewReader("new"))
+				Expect(err).ToNot(HaveOccurred())
+
+				contents, err := fs.ReadFileString("/var/vcap/micro_bosh/data/cache/.partial/fake-blob-id")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(contents).To(Equal("new"))
+			})
+
+			It("returns error when offset does not match partial blob size", func() {
+				blobManager, _ := createBlobManager()
+
+				_, err := blobManager.WritePartial("fake-blob-id", 0, strings.NewReader("some "))
+				Expect(err).ToNot(HaveOccurred())
+
+				size, err := blobManager.WritePartial("fake-blob-id", 3, strings.NewReader("data"))
+				Expect(err).To(HaveOccurred())
+				Expect(size).To(Equal(int64(5)))
+			})
+
+			It("discards partial blob", func() {
+				blobManager, fs := createBlobManager()
+
+				_, err := blobManager.WritePartial("fake-blob-id", 0, strings.NewReader("some data"))
+				Expect(err).ToNot(HaveOccurred())
+
+				err = blobManager.DiscardPartial("fake-blob-id")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fs.FileExists("/var/vcap/micro_bosh/data/cache/.partial/fake-blob-id")).To(BeFalse())
+			})
+
+			It("returns error for invalid blob id", func() {
+				blobManager, _ := createBlobManager()
+
+				_, err := blobManager.WritePartial("../fake-blob-id", 0, strings.NewReader("some data"))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Invalid blob ID"))
+			})
+		})
+
+		It("opens blob for streaming", func() {
+			blobManager, fs := createBlobManager()
+			fs.WriteFileString("/var/vcap/micro_bosh/data/cache/fake-blob-id", "some data")
+
+			file, err := blobManager.Open("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer file.Close()
+
+			contents, err := ioutil.ReadAll(file)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("some data"))
+		})
 	})
 }
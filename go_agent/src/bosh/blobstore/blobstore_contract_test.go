@@ -0,0 +1,301 @@
+package blobstore_test
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/blobstore"
+	fakeblob "bosh/blobstore/fakes"
+	boshcrypto "bosh/crypto"
+	boshlog "bosh/logger"
+	boshsettings "bosh/settings"
+	boshsys "bosh/system"
+	faketime "bosh/time/fakes"
+	fakeuuid "bosh/uuid/fakes"
+)
+
+// describeBlobstoreContract runs behaviour expected from every blobstore
+// that actually stores blobs; buildBlobstore returns blobstore and a function
+// that releases its resources
+func describeBlobstoreContract(name string, buildBlobstore func() (Blobstore, func())) {
+	Describe(name, func() {
+		var (
+			blobstore Blobstore
+			tearDown  func()
+			fileName  string
+		)
+
+		BeforeEach(func() {
+			blobstore, tearDown = buildBlobstore()
+
+			Expect(blobstore.Validate()).ToNot(HaveOccurred())
+
+			file, err := ioutil.TempFile("", "blobstore-contract")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = file.WriteString("fake-contract-content")
+			Expect(err).ToNot(HaveOccurred())
+
+			file.Close()
+			fileName = file.Name()
+		})
+
+		AfterEach(func() {
+			os.Remove(fileName)
+			tearDown()
+		})
+
+		It("gets created blob", func() {
+			blobID, _, err := blobstore.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).ToNot(BeEmpty())
+
+			downloadedFileName, err := blobstore.Get(blobID, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			content, err := ioutil.ReadFile(downloadedFileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("fake-contract-content"))
+
+			err = blobstore.CleanUp(downloadedFileName)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = os.Stat(downloadedFileName)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("reports created blob as existing", func() {
+			blobID, _, err := blobstore.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+
+			exists, err := blobstore.Exists(blobID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		})
+
+		It("reports unknown blob as not existing", func() {
+			exists, err := blobstore.Exists("fake-unknown-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("deletes blob", func() {
+			blobID, _, err := blobstore.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = blobstore.Delete(blobID)
+			Expect(err).ToNot(HaveOccurred())
+
+			exists, err := blobstore.Exists(blobID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+
+			_, err = blobstore.Get(blobID, "")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("does not return error when deleting unknown blob", func() {
+			err := blobstore.Delete("fake-unknown-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+}
+
+var _ = Describe("Blobstore contract", func() {
+	var (
+		logger  boshlog.Logger
+		fs      boshsys.FileSystem
+		uuidGen *fakeuuid.FakeGenerator
+	)
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		uuidGen = &fakeuuid.FakeGenerator{GeneratedUuid: "fake-blob-id"}
+	})
+
+	buildLocalBlobstore := func() (Blobstore, func()) {
+		blobstorePath, err := ioutil.TempDir("", "local-blobstore-contract")
+		Expect(err).ToNot(HaveOccurred())
+
+		options := map[string]interface{}{"blobstore_path": blobstorePath}
+
+		return NewLocalBlobstore(fs, uuidGen, options), func() { os.RemoveAll(blobstorePath) }
+	}
+
+	describeBlobstoreContract("localBlobstore", buildLocalBlobstore)
+
+	describeBlobstoreContract("externalBlobstore", func() (Blobstore, func()) {
+		dir, err := ioutil.TempDir("", "external-blobstore-contract")
+		Expect(err).ToNot(HaveOccurred())
+
+		blobsDir := filepath.Join(dir, "blobs")
+		Expect(os.Mkdir(blobsDir, os.ModePerm)).ToNot(HaveOccurred())
+
+		// Implements external blobstore CLI on top of a directory
+		script := fmt.Sprintf(`#!/bin/sh
+set -e
+case "$3" in
+  put) cp "$4" "%[1]s/$5" ;;
+  get) cp "%[1]s/$4" "$5" ;;
+  delete) rm -f "%[1]s/$4" ;;
+  exists) if [ -f "%[1]s/$4" ]; then echo true; else echo false; fi ;;
+  *) exit 1 ;;
+esac
+`, blobsDir)
+
+		err = ioutil.WriteFile(filepath.Join(dir, "bosh-blobstore-contract"), []byte(script), 0755)
+		Expect(err).ToNot(HaveOccurred())
+
+		oldPath := os.Getenv("PATH")
+		os.Setenv("PATH", dir+":"+oldPath)
+
+		blobstore := NewExternalBlobstore(
+			"contract",
+			map[string]interface{}{},
+			fs,
+			boshsys.NewExecCmdRunner(logger),
+			uuidGen,
+			filepath.Join(dir, "blobstore-contract.json"),
+		)
+
+		return blobstore, func() {
+			os.Setenv("PATH", oldPath)
+			os.RemoveAll(dir)
+		}
+	})
+
+	describeBlobstoreContract("s3Blobstore", func() (Blobstore, func()) {
+		server := fakeblob.NewFakeS3Server()
+
+		hostAndPort := strings.Split(server.Host(), ":")
+		port, err := strconv.Atoi(hostAndPort[1])
+		Expect(err).ToNot(HaveOccurred())
+
+		options := map[string]interface{}{
+			"bucket_name":         "fake-bucket",
+			"access_key_id":       "fake-access-key-id",
+			"secret_access_key":   "fake-secret-access-key",
+			"host":                hostAndPort[0],
+			"port":                port,
+			"use_ssl":             false,
+			"s3_force_path_style": true,
+		}
+
+		timeService := &faketime.FakeService{NowTime: time.Now()}
+
+		return NewS3Blobstore(options, fs, uuidGen, timeService, logger), server.Close
+	})
+
+	describeBlobstoreContract("davBlobstore", func() (Blobstore, func()) {
+		server := httptest.NewServer(newContractDavHandler())
+
+		// Small parts so that larger contract blobs are downloaded in parallel
+		options := map[string]interface{}{"endpoint": server.URL, "download_part_size": 5}
+
+		timeService := &faketime.FakeService{NowTime: time.Now()}
+
+		return NewDavBlobstore(options, fs, uuidGen, timeService, logger), server.Close
+	})
+
+	describeBlobstoreContract("digestVerifiableBlobstore", func() (Blobstore, func()) {
+		blobstore, tearDown := buildLocalBlobstore()
+		return NewDigestVerifiableBlobstore(blobstore), tearDown
+	})
+
+	describeBlobstoreContract("encryptingBlobstore", func() (Blobstore, func()) {
+		blobstore, tearDown := buildLocalBlobstore()
+
+		blobCipher, err := boshcrypto.NewBlobCipher(boshsettings.BlobstoreEncryption{
+			Keys:  map[string]string{"fake-key-id": "MDEyMzQ1Njc4OWFiY2RlZg=="},
+			KeyID: "fake-key-id",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		return NewEncryptingBlobstore(blobstore, blobCipher, fs, logger), tearDown
+	})
+
+	describeBlobstoreContract("retryableBlobstore", func() (Blobstore, func()) {
+		blobstore, tearDown := buildLocalBlobstore()
+		return NewRetryableBlobstore(blobstore, boshsettings.BlobstoreRetry{}, &faketime.FakeService{}, logger), tearDown
+	})
+
+	describeBlobstoreContract("countingBlobstore", func() (Blobstore, func()) {
+		blobstore, tearDown := buildLocalBlobstore()
+		return NewCountingBlobstore(blobstore, fs), tearDown
+	})
+
+	describeBlobstoreContract("cachingBlobstore", func() (Blobstore, func()) {
+		blobstore, tearDown := buildLocalBlobstore()
+
+		cacheDir, err := ioutil.TempDir("", "caching-blobstore-contract")
+		Expect(err).ToNot(HaveOccurred())
+
+		return NewCachingBlobstore(blobstore, cacheDir, CacheOptions{}, fs, logger), func() {
+			os.RemoveAll(cacheDir)
+			tearDown()
+		}
+	})
+})
+
+// newContractDavHandler stores blobs in memory and checks
+// that they are placed under SHA1 prefix of their ID
+func newContractDavHandler() http.Handler {
+	blobs := map[string][]byte{}
+	lock := sync.Mutex{}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if len(parts) != 2 || parts[0] != fmt.Sprintf("%02x", sha1.Sum([]byte(parts[1]))[0]) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		blob, found := blobs[parts[1]]
+
+		switch r.Method {
+		case "PUT":
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			blobs[parts[1]] = body
+			w.WriteHeader(http.StatusCreated)
+
+		case "GET", "HEAD":
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(blob))
+
+		case "DELETE":
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(blobs, parts[1])
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
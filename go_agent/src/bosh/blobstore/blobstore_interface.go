@@ -11,5 +11,10 @@ type Blobstore interface {
 
 	Create(fileName string) (blobID string, fingerprint string, err error)
 
+	// Deleting blob that does not exist is not an error
+	Delete(blobID string) (err error)
+
+	Exists(blobID string) (exists bool, err error)
+
 	Validate() (err error)
 }
@@ -0,0 +1,395 @@
+package blobstore
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	boshcrypto "bosh/crypto"
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+const (
+	cachingBlobstoreLogTag = "cachingBlobstore"
+
+	defaultCacheMaxSizeBytes = 1024 * 1024 * 1024
+)
+
+// All supported algorithms are computed so that fingerprint
+// with any of them can be matched against cached blobs
+var cacheDigestAlgorithms = []string{
+	boshcrypto.DigestAlgorithmSHA1,
+	boshcrypto.DigestAlgorithmSHA256,
+	boshcrypto.DigestAlgorithmSHA512,
+}
+
+type CacheOptions struct {
+	// Defaults to 1 GiB; caching is disabled when negative
+	MaxSizeBytes int64
+}
+
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+
+	Entries   int   `json:"entries"`
+	SizeBytes int64 `json:"size_bytes"`
+}
+
+type CacheStatsProvider interface {
+	GetCacheStats() CacheStats
+}
+
+type cacheEntry struct {
+	blobID  string
+	digests boshcrypto.MultipleDigest
+	path    string
+	size    int64
+}
+
+// cachingBlobstore keeps copies of downloaded and uploaded blobs
+// so that packages used by several compilations are downloaded once.
+// Blobs are keyed by blob ID; digests of cached content are recorded
+// and verified on every hit. Cache directory has to be emptied
+// with PrepareCacheDir on start since the index is only kept in memory.
+type cachingBlobstore struct {
+	blobstore Blobstore
+	dir       string
+	maxSize   int64
+	fs        boshsys.FileSystem
+	logger    boshlog.Logger
+
+	// Most recently used entries are at the front
+	entries *list.List
+	index   map[string]*list.Element
+	size    int64
+	stats   CacheStats
+	lock    sync.Mutex
+
+	// Names files being added to cache directory
+	addCount uint64
+}
+
+func NewCachingBlobstore(
+	blobstore Blobstore,
+	dir string,
+	options CacheOptions,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) *cachingBlobstore {
+	maxSize := options.MaxSizeBytes
+	if maxSize == 0 {
+		maxSize = defaultCacheMaxSizeBytes
+	}
+
+	return &cachingBlobstore{
+		blobstore: blobstore,
+		dir:       dir,
+		maxSize:   maxSize,
+		fs:        fs,
+		logger:    logger,
+		entries:   list.New(),
+		index:     map[string]*list.Element{},
+	}
+}
+
+// Get matches the strongest digest of given fingerprint against cached blob;
+// empty fingerprint matches any cached blob with the same ID
+func (b *cachingBlobstore) Get(blobID, fingerprint string) (string, error) {
+	fileName, found := b.getCached(blobID, fingerprint)
+	if found {
+		return fileName, nil
+	}
+
+	fileName, err := b.blobstore.Get(blobID, fingerprint)
+	if err != nil {
+		return "", err
+	}
+
+	b.add(blobID, fingerprint, fileName)
+
+	return fileName, nil
+}
+
+func (b *cachingBlobstore) CleanUp(fileName string) error {
+	return b.blobstore.CleanUp(fileName)
+}
+
+// Create caches uploaded blob since compilations
+// often download packages compiled just before
+func (b *cachingBlobstore) Create(fileName string) (string, string, error) {
+	blobID, fingerprint, err := b.blobstore.Create(fileName)
+	if err != nil {
+		return blobID, fingerprint, err
+	}
+
+	b.add(blobID, fingerprint, fileName)
+
+	return blobID, fingerprint, nil
+}
+
+func (b *cachingBlobstore) Delete(blobID string) error {
+	b.lock.Lock()
+	b.evict(blobID)
+	b.lock.Unlock()
+
+	return b.blobstore.Delete(blobID)
+}
+
+func (b *cachingBlobstore) Exists(blobID string) (bool, error) {
+	return b.blobstore.Exists(blobID)
+}
+
+func (b *cachingBlobstore) Validate() error {
+	return b.blobstore.Validate()
+}
+
+// PrepareCacheDir removes blobs cached by previous run;
+// inner blobstore is not validated again
+func (b *cachingBlobstore) PrepareCacheDir() error {
+	if b.maxSize < 0 {
+		return nil
+	}
+
+	err := b.fs.RemoveAll(b.dir)
+	if err != nil {
+		return bosherr.WrapError(err, "Emptying blob cache directory")
+	}
+
+	err = b.fs.MkdirAll(b.dir, os.FileMode(0700))
+	if err != nil {
+		return bosherr.WrapError(err, "Creating blob cache directory")
+	}
+
+	return nil
+}
+
+func (b *cachingBlobstore) GetCacheStats() CacheStats {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	stats := b.stats
+	stats.Entries = b.entries.Len()
+	stats.SizeBytes = b.size
+
+	return stats
+}
+
+// getCached returns copy of cached blob so that caller can clean it up
+func (b *cachingBlobstore) getCached(blobID, fingerprint string) (string, bool) {
+	if b.maxSize < 0 {
+		return "", false
+	}
+
+	b.lock.Lock()
+
+	element, found := b.index[blobID]
+	if !found || !element.Value.(*cacheEntry).matches(fingerprint) {
+		b.stats.Misses++
+		b.lock.Unlock()
+		return "", false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	b.entries.MoveToFront(element)
+
+	// Opened while holding the lock so that concurrent eviction
+	// cannot remove the file before it is opened
+	cachedFile, err := b.fs.OpenFile(entry.path, os.O_RDONLY, 0)
+
+	b.lock.Unlock()
+
+	if err != nil {
+		b.logger.Error(cachingBlobstoreLogTag, "Failed to open cached blob %s: %s", blobID, err.Error())
+		b.invalidate(entry)
+		return "", false
+	}
+
+	defer cachedFile.Close()
+
+	fileName, digests, err := b.copyToTempFile(cachedFile)
+	if err != nil {
+		b.logger.Error(cachingBlobstoreLogTag, "Failed to copy cached blob %s: %s", blobID, err.Error())
+		b.invalidate(entry)
+		return "", false
+	}
+
+	if digests.String() != entry.digests.String() {
+		b.logger.Error(cachingBlobstoreLogTag, "Cached blob %s is corrupted: expected %s, got %s", blobID, entry.digests, digests)
+		b.fs.RemoveAll(fileName)
+		b.invalidate(entry)
+		return "", false
+	}
+
+	b.lock.Lock()
+	b.stats.Hits++
+	b.lock.Unlock()
+
+	return fileName, true
+}
+
+// invalidate evicts entry and counts the lookup as a miss
+func (b *cachingBlobstore) invalidate(entry *cacheEntry) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.stats.Misses++
+
+	if element, found := b.index[entry.blobID]; found && element.Value.(*cacheEntry) == entry {
+		b.remove(element)
+	}
+}
+
+// add copies blob into cache; failures are only logged
+// since the blob was already transferred successfully
+func (b *cachingBlobstore) add(blobID, fingerprint, fileName string) {
+	if b.maxSize < 0 {
+		return
+	}
+
+	file, err := b.fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		b.logger.Error(cachingBlobstoreLogTag, "Failed to open blob %s for caching: %s", blobID, err.Error())
+		return
+	}
+
+	defer file.Close()
+
+	size, err := file.Seek(0, os.SEEK_END)
+	if err != nil || size > b.maxSize {
+		return
+	}
+
+	_, err = file.Seek(0, os.SEEK_SET)
+	if err != nil {
+		return
+	}
+
+	b.lock.Lock()
+	b.addCount++
+	// Created in cache directory so that it can be renamed into place
+	addedFileName := filepath.Join(b.dir, fmt.Sprintf("add-%d", b.addCount))
+	b.lock.Unlock()
+
+	cachedFile, err := b.fs.OpenFile(addedFileName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, os.FileMode(0600))
+	if err != nil {
+		b.logger.Error(cachingBlobstoreLogTag, "Failed to create file for caching blob %s: %s", blobID, err.Error())
+		return
+	}
+
+	digests, err := copyWithDigests(cachedFile, file)
+	cachedFile.Close()
+
+	entry := &cacheEntry{
+		blobID:  blobID,
+		digests: digests,
+		path:    filepath.Join(b.dir, cacheFileName(blobID)),
+		size:    size,
+	}
+
+	if err == nil && !entry.matches(fingerprint) {
+		err = bosherr.New("Fingerprint %s does not match %s", fingerprint, digests)
+	}
+
+	if err != nil {
+		b.logger.Error(cachingBlobstoreLogTag, "Failed to cache blob %s: %s", blobID, err.Error())
+		b.fs.RemoveAll(addedFileName)
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.evict(blobID)
+
+	err = b.fs.Rename(addedFileName, entry.path)
+	if err != nil {
+		b.logger.Error(cachingBlobstoreLogTag, "Failed to move blob %s into cache: %s", blobID, err.Error())
+		b.fs.RemoveAll(addedFileName)
+		return
+	}
+
+	b.index[blobID] = b.entries.PushFront(entry)
+	b.size += entry.size
+
+	for b.size > b.maxSize {
+		b.remove(b.entries.Back())
+	}
+}
+
+// evict must be called with the lock held
+func (b *cachingBlobstore) evict(blobID string) {
+	if element, found := b.index[blobID]; found {
+		b.remove(element)
+	}
+}
+
+// remove must be called with the lock held
+func (b *cachingBlobstore) remove(element *list.Element) {
+	entry := element.Value.(*cacheEntry)
+
+	b.entries.Remove(element)
+	delete(b.index, entry.blobID)
+	b.size -= entry.size
+
+	err := b.fs.RemoveAll(entry.path)
+	if err != nil {
+		b.logger.Error(cachingBlobstoreLogTag, "Failed to remove cached blob %s: %s", entry.blobID, err.Error())
+	}
+}
+
+func (b *cachingBlobstore) copyToTempFile(src io.Reader) (string, boshcrypto.MultipleDigest, error) {
+	file, fileName, err := openTempFile(b.fs, "bosh-blobstore-cachingBlobstore-Get")
+	if err != nil {
+		return "", nil, err
+	}
+
+	defer file.Close()
+
+	digests, err := copyWithDigests(file, src)
+	if err != nil {
+		b.fs.RemoveAll(fileName)
+		return "", nil, err
+	}
+
+	return fileName, digests, nil
+}
+
+func copyWithDigests(dst io.Writer, src io.Reader) (boshcrypto.MultipleDigest, error) {
+	digests, err := boshcrypto.NewMultipleDigest(io.TeeReader(src, dst), cacheDigestAlgorithms...)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Copying blob")
+	}
+
+	return digests, nil
+}
+
+// matches compares the strongest digest of fingerprint
+// with the same algorithm digest of cached content
+func (e *cacheEntry) matches(fingerprint string) bool {
+	if fingerprint == "" {
+		return true
+	}
+
+	expectedDigests, err := boshcrypto.ParseMultipleDigest(fingerprint)
+	if err != nil {
+		return false
+	}
+
+	expected := expectedDigests.Strongest()
+
+	actual, found := e.digests.Digest(expected.Algorithm)
+
+	return found && actual == expected
+}
+
+// cacheFileName keeps arbitrary blob IDs out of file paths
+func cacheFileName(blobID string) string {
+	return fmt.Sprintf("%x", sha1.Sum([]byte(blobID)))
+}
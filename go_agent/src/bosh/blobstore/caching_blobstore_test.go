@@ -0,0 +1,370 @@
+package blobstore_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshblob "bosh/blobstore"
+	fakeblob "bosh/blobstore/fakes"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+	fakesys "bosh/system/fakes"
+)
+
+var _ = Describe("cachingBlobstore", func() {
+	const (
+		// SHA1 of "fake-content"
+		contentSHA1 = "50fe6e45709c690c0737343ecd613813d8dd2d53"
+	)
+
+	var (
+		innerBlobstore   *fakeblob.FakeBlobstore
+		cacheDir         string
+		contentFileName  string
+		cachingBlobstore interface {
+			boshblob.Blobstore
+			boshblob.CacheStatsProvider
+			PrepareCacheDir() error
+		}
+	)
+
+	writeTempFile := func(content string) string {
+		file, err := ioutil.TempFile("", "caching-blobstore-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = file.WriteString(content)
+		Expect(err).ToNot(HaveOccurred())
+
+		file.Close()
+
+		return file.Name()
+	}
+
+	readFile := func(fileName string) string {
+		content, err := ioutil.ReadFile(fileName)
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	cachedFileNames := func() []string {
+		fileNames, err := filepath.Glob(filepath.Join(cacheDir, "*"))
+		Expect(err).ToNot(HaveOccurred())
+		return fileNames
+	}
+
+	buildBlobstore := func(options boshblob.CacheOptions) {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := boshsys.NewOsFileSystem(logger)
+
+		cachingBlobstore = boshblob.NewCachingBlobstore(innerBlobstore, cacheDir, options, fs, logger)
+
+		err := cachingBlobstore.PrepareCacheDir()
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		innerBlobstore = fakeblob.NewFakeBlobstore()
+
+		tempDir, err := ioutil.TempDir("", "caching-blobstore-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		cacheDir = filepath.Join(tempDir, "blob_cache")
+
+		contentFileName = writeTempFile("fake-content")
+		innerBlobstore.GetFileName = contentFileName
+
+		buildBlobstore(boshblob.CacheOptions{})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(filepath.Dir(cacheDir))
+		os.Remove(contentFileName)
+	})
+
+	Describe("PrepareCacheDir", func() {
+		It("empties cache directory left from previous run", func() {
+			_, err := cachingBlobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cachedFileNames()).To(HaveLen(1))
+
+			buildBlobstore(boshblob.CacheOptions{})
+
+			Expect(cachedFileNames()).To(BeEmpty())
+		})
+
+		It("does not validate inner blobstore", func() {
+			innerBlobstore.ValidateError = errors.New("fake-validate-err")
+
+			err := cachingBlobstore.PrepareCacheDir()
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("Validate", func() {
+		It("returns error from inner blobstore", func() {
+			innerBlobstore.ValidateError = errors.New("fake-validate-err")
+
+			err := cachingBlobstore.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-validate-err"))
+		})
+	})
+
+	Describe("Get", func() {
+		It("downloads blob from inner blobstore on first request", func() {
+			fileName, err := cachingBlobstore.Get("fake-blob-id", contentSHA1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal(contentFileName))
+
+			Expect(innerBlobstore.GetBlobIDs).To(Equal([]string{"fake-blob-id"}))
+			Expect(cachingBlobstore.GetCacheStats()).To(Equal(boshblob.CacheStats{
+				Misses:    1,
+				Entries:   1,
+				SizeBytes: int64(len("fake-content")),
+			}))
+		})
+
+		It("returns copy of cached blob on following requests", func() {
+			_, err := cachingBlobstore.Get("fake-blob-id", contentSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			fileName, err := cachingBlobstore.Get("fake-blob-id", contentSHA1)
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(fileName)
+
+			Expect(fileName).ToNot(Equal(contentFileName))
+			Expect(readFile(fileName)).To(Equal("fake-content"))
+
+			Expect(innerBlobstore.GetBlobIDs).To(Equal([]string{"fake-blob-id"}))
+			Expect(cachingBlobstore.GetCacheStats().Hits).To(Equal(uint64(1)))
+		})
+
+		It("returns cached blob when fingerprint is not given", func() {
+			_, err := cachingBlobstore.Get("fake-blob-id", contentSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			fileName, err := cachingBlobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(fileName)
+
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(1))
+		})
+
+		It("downloads blob again when fingerprint does not match cached blob", func() {
+			_, err := cachingBlobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = cachingBlobstore.Get("fake-blob-id", "fake-other-fingerprint")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(innerBlobstore.GetFingerprints).To(Equal([]string{"", "fake-other-fingerprint"}))
+			Expect(cachingBlobstore.GetCacheStats().Misses).To(Equal(uint64(2)))
+		})
+
+		It("evicts corrupted blob and downloads it again", func() {
+			_, err := cachingBlobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = ioutil.WriteFile(cachedFileNames()[0], []byte("fake-corrupted"), os.ModePerm)
+			Expect(err).ToNot(HaveOccurred())
+
+			fileName, err := cachingBlobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal(contentFileName))
+
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(2))
+			Expect(cachingBlobstore.GetCacheStats()).To(Equal(boshblob.CacheStats{
+				Misses:    2,
+				Entries:   1,
+				SizeBytes: int64(len("fake-content")),
+			}))
+		})
+
+		It("does not cache blob that does not match fingerprint", func() {
+			_, err := cachingBlobstore.Get("fake-blob-id", "fake-other-fingerprint")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cachingBlobstore.GetCacheStats().Entries).To(Equal(0))
+			Expect(cachedFileNames()).To(BeEmpty())
+		})
+
+		It("returns error from inner blobstore", func() {
+			innerBlobstore.GetError = errors.New("fake-get-err")
+
+			_, err := cachingBlobstore.Get("fake-blob-id", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-err"))
+
+			Expect(cachingBlobstore.GetCacheStats().Entries).To(Equal(0))
+		})
+
+		It("evicts least recently used blobs when size limit is exceeded", func() {
+			// Fits two blobs of "fake-content"
+			buildBlobstore(boshblob.CacheOptions{MaxSizeBytes: 2 * int64(len("fake-content"))})
+
+			for _, blobID := range []string{"fake-blob-id-1", "fake-blob-id-2"} {
+				_, err := cachingBlobstore.Get(blobID, "")
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			// Makes blob 1 most recently used
+			fileName, err := cachingBlobstore.Get("fake-blob-id-1", "")
+			Expect(err).ToNot(HaveOccurred())
+			os.Remove(fileName)
+
+			_, err = cachingBlobstore.Get("fake-blob-id-3", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cachingBlobstore.GetCacheStats().Entries).To(Equal(2))
+			Expect(cachedFileNames()).To(HaveLen(2))
+
+			innerBlobstore.GetBlobIDs = nil
+
+			for _, blobID := range []string{"fake-blob-id-1", "fake-blob-id-3", "fake-blob-id-2"} {
+				fileName, err := cachingBlobstore.Get(blobID, "")
+				Expect(err).ToNot(HaveOccurred())
+				if fileName != contentFileName {
+					os.Remove(fileName)
+				}
+			}
+
+			Expect(innerBlobstore.GetBlobIDs).To(Equal([]string{"fake-blob-id-2"}))
+		})
+
+		It("does not cache blobs larger than size limit", func() {
+			buildBlobstore(boshblob.CacheOptions{MaxSizeBytes: 1})
+
+			_, err := cachingBlobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cachingBlobstore.GetCacheStats().Entries).To(Equal(0))
+			Expect(cachedFileNames()).To(BeEmpty())
+		})
+
+		It("does not cache blobs when cache is disabled", func() {
+			buildBlobstore(boshblob.CacheOptions{MaxSizeBytes: -1})
+
+			for i := 0; i < 2; i++ {
+				_, err := cachingBlobstore.Get("fake-blob-id", "")
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(2))
+			Expect(cachingBlobstore.GetCacheStats()).To(Equal(boshblob.CacheStats{}))
+		})
+
+		It("can be used concurrently", func() {
+			buildBlobstore(boshblob.CacheOptions{MaxSizeBytes: 2 * int64(len("fake-content"))})
+
+			wg := &sync.WaitGroup{}
+
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+
+				go func(blobID string) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					fileName, err := cachingBlobstore.Get(blobID, contentSHA1)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(readFile(fileName)).To(Equal("fake-content"))
+
+					if fileName != contentFileName {
+						os.Remove(fileName)
+					}
+				}([]string{"fake-blob-id-1", "fake-blob-id-2", "fake-blob-id-3"}[i%3])
+			}
+
+			wg.Wait()
+
+			stats := cachingBlobstore.GetCacheStats()
+			Expect(stats.Hits + stats.Misses).To(Equal(uint64(10)))
+			Expect(stats.SizeBytes).To(BeNumerically("<=", 2*len("fake-content")))
+		})
+	})
+
+	Describe("Create", func() {
+		It("caches uploaded blob", func() {
+			innerBlobstore.CreateBlobID = "fake-blob-id"
+			innerBlobstore.CreateFingerprint = contentSHA1
+
+			blobID, fingerprint, err := cachingBlobstore.Create(contentFileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+			Expect(fingerprint).To(Equal(contentSHA1))
+
+			fileName, err := cachingBlobstore.Get("fake-blob-id", contentSHA1)
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(fileName)
+
+			Expect(readFile(fileName)).To(Equal("fake-content"))
+			Expect(innerBlobstore.GetBlobIDs).To(BeEmpty())
+		})
+
+		It("returns error from inner blobstore", func() {
+			innerBlobstore.CreateErr = errors.New("fake-create-err")
+
+			_, _, err := cachingBlobstore.Create(contentFileName)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-create-err"))
+
+			Expect(cachingBlobstore.GetCacheStats().Entries).To(Equal(0))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("evicts cached blob and deletes it from inner blobstore", func() {
+			_, err := cachingBlobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = cachingBlobstore.Delete("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(innerBlobstore.DeleteBlobIDs).To(Equal([]string{"fake-blob-id"}))
+			Expect(cachingBlobstore.GetCacheStats().Entries).To(Equal(0))
+			Expect(cachedFileNames()).To(BeEmpty())
+		})
+	})
+
+	Describe("CleanUp", func() {
+		It("cleans up file with inner blobstore", func() {
+			err := cachingBlobstore.CleanUp("fake-file-name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(innerBlobstore.CleanUpFileName).To(Equal("fake-file-name"))
+		})
+	})
+
+	Context("with fake file system", func() {
+		It("reads and writes cached blobs through file system", func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			fs := fakesys.NewFakeFileSystem()
+
+			blobstore := boshblob.NewCachingBlobstore(innerBlobstore, "/fake-cache-dir", boshblob.CacheOptions{}, fs, logger)
+
+			err := blobstore.PrepareCacheDir()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.WriteFileString("/fake-upload", "fake-content")
+			Expect(err).ToNot(HaveOccurred())
+
+			innerBlobstore.CreateBlobID = "fake-blob-id"
+			innerBlobstore.CreateFingerprint = contentSHA1
+
+			_, _, err = blobstore.Create("/fake-upload")
+			Expect(err).ToNot(HaveOccurred())
+
+			fileName, err := blobstore.Get("fake-blob-id", contentSHA1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.ReadFileString(fileName)).To(Equal("fake-content"))
+
+			Expect(innerBlobstore.GetBlobIDs).To(BeEmpty())
+			Expect(blobstore.GetCacheStats().Hits).To(Equal(uint64(1)))
+		})
+	})
+})
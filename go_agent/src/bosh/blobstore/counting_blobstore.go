@@ -0,0 +1,106 @@
+package blobstore
+
+import (
+	"os"
+	"sync"
+
+	boshsys "bosh/system"
+)
+
+type TransferStats struct {
+	Downloads        uint64
+	DownloadFailures uint64
+	DownloadedBytes  uint64
+
+	Uploads        uint64
+	UploadFailures uint64
+	UploadedBytes  uint64
+}
+
+// countingBlobstore keeps track of number and size of transferred blobs
+type countingBlobstore struct {
+	blobstore Blobstore
+	fs        boshsys.FileSystem
+
+	stats     TransferStats
+	statsLock sync.Mutex
+}
+
+func NewCountingBlobstore(blobstore Blobstore, fs boshsys.FileSystem) *countingBlobstore {
+	return &countingBlobstore{blobstore: blobstore, fs: fs}
+}
+
+func (b *countingBlobstore) Get(blobID, fingerprint string) (string, error) {
+	fileName, err := b.blobstore.Get(blobID, fingerprint)
+
+	b.statsLock.Lock()
+	defer b.statsLock.Unlock()
+
+	if err != nil {
+		b.stats.DownloadFailures++
+		return fileName, err
+	}
+
+	b.stats.Downloads++
+	b.stats.DownloadedBytes += b.fileSize(fileName)
+
+	return fileName, nil
+}
+
+func (b *countingBlobstore) CleanUp(fileName string) error {
+	return b.blobstore.CleanUp(fileName)
+}
+
+func (b *countingBlobstore) Create(fileName string) (string, string, error) {
+	blobID, fingerprint, err := b.blobstore.Create(fileName)
+
+	b.statsLock.Lock()
+	defer b.statsLock.Unlock()
+
+	if err != nil {
+		b.stats.UploadFailures++
+		return blobID, fingerprint, err
+	}
+
+	b.stats.Uploads++
+	b.stats.UploadedBytes += b.fileSize(fileName)
+
+	return blobID, fingerprint, nil
+}
+
+func (b *countingBlobstore) Delete(blobID string) error {
+	return b.blobstore.Delete(blobID)
+}
+
+func (b *countingBlobstore) Exists(blobID string) (bool, error) {
+	return b.blobstore.Exists(blobID)
+}
+
+func (b *countingBlobstore) Validate() error {
+	return b.blobstore.Validate()
+}
+
+func (b *countingBlobstore) GetTransferStats() TransferStats {
+	b.statsLock.Lock()
+	defer b.statsLock.Unlock()
+
+	return b.stats
+}
+
+// fileSize returns 0 if the file cannot be opened;
+// transfer counts are informational and should not fail the operation
+func (b *countingBlobstore) fileSize(fileName string) uint64 {
+	file, err := b.fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return 0
+	}
+
+	defer file.Close()
+
+	size, err := file.Seek(0, os.SEEK_END)
+	if err != nil {
+		return 0
+	}
+
+	return uint64(size)
+}
@@ -0,0 +1,114 @@
+package blobstore_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshblob "bosh/blobstore"
+	fakeblob "bosh/blobstore/fakes"
+	fakesys "bosh/system/fakes"
+)
+
+var _ = Describe("countingBlobstore", func() {
+	const (
+		fixturePath = "/fake-blob-file"
+
+		// Length of "fake-content"
+		fixtureSize = 12
+	)
+
+	var (
+		innerBlobstore    *fakeblob.FakeBlobstore
+		countingBlobstore interface {
+			boshblob.Blobstore
+			GetTransferStats() boshblob.TransferStats
+		}
+	)
+
+	BeforeEach(func() {
+		fs := fakesys.NewFakeFileSystem()
+
+		err := fs.WriteFileString(fixturePath, "fake-content")
+		Expect(err).ToNot(HaveOccurred())
+
+		innerBlobstore = fakeblob.NewFakeBlobstore()
+		countingBlobstore = boshblob.NewCountingBlobstore(innerBlobstore, fs)
+	})
+
+	Describe("Get", func() {
+		It("returns file name from inner blobstore", func() {
+			innerBlobstore.GetFileName = fixturePath
+
+			fileName, err := countingBlobstore.Get("fake-blob-id", "fake-fingerprint")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal(fixturePath))
+
+			Expect(innerBlobstore.GetBlobIDs).To(Equal([]string{"fake-blob-id"}))
+			Expect(innerBlobstore.GetFingerprints).To(Equal([]string{"fake-fingerprint"}))
+		})
+
+		It("counts successful downloads and their size", func() {
+			innerBlobstore.GetFileName = fixturePath
+
+			_, err := countingBlobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = countingBlobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(countingBlobstore.GetTransferStats()).To(Equal(boshblob.TransferStats{
+				Downloads:       2,
+				DownloadedBytes: 2 * fixtureSize,
+			}))
+		})
+
+		It("counts failed downloads and returns error", func() {
+			innerBlobstore.GetError = errors.New("fake-get-err")
+
+			_, err := countingBlobstore.Get("fake-blob-id", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-err"))
+
+			Expect(countingBlobstore.GetTransferStats()).To(Equal(boshblob.TransferStats{
+				DownloadFailures: 1,
+			}))
+		})
+	})
+
+	Describe("Create", func() {
+		It("returns blob id and fingerprint from inner blobstore", func() {
+			innerBlobstore.CreateBlobID = "fake-blob-id"
+			innerBlobstore.CreateFingerprint = "fake-fingerprint"
+
+			blobID, fingerprint, err := countingBlobstore.Create(fixturePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+			Expect(fingerprint).To(Equal("fake-fingerprint"))
+			Expect(innerBlobstore.CreateFileName).To(Equal(fixturePath))
+		})
+
+		It("counts successful uploads and their size", func() {
+			_, _, err := countingBlobstore.Create(fixturePath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(countingBlobstore.GetTransferStats()).To(Equal(boshblob.TransferStats{
+				Uploads:       1,
+				UploadedBytes: fixtureSize,
+			}))
+		})
+
+		It("counts failed uploads and returns error", func() {
+			innerBlobstore.CreateErr = errors.New("fake-create-err")
+
+			_, _, err := countingBlobstore.Create(fixturePath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-create-err"))
+
+			Expect(countingBlobstore.GetTransferStats()).To(Equal(boshblob.TransferStats{
+				UploadFailures: 1,
+			}))
+		})
+	})
+})
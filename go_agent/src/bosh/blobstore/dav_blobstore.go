@@ -0,0 +1,161 @@
+package blobstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+
+	davclient "bosh/davcli/client"
+	davconf "bosh/davcli/config"
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+	boshtime "bosh/time"
+	boshuuid "bosh/uuid"
+)
+
+// davBlobstore uses davcli client in process instead of
+// running bosh-blobstore-dav for every blob
+type davBlobstore struct {
+	config     davconf.Config
+	configErr  error
+	client     davclient.Client
+	downloader parallelDownloader
+	fs         boshsys.FileSystem
+	uuidGen    boshuuid.Generator
+}
+
+func NewDavBlobstore(
+	options map[string]interface{},
+	fs boshsys.FileSystem,
+	uuidGen boshuuid.Generator,
+	timeService boshtime.Service,
+	logger boshlog.Logger,
+) Blobstore {
+	config, err := parseDavOptions(options)
+
+	// Parts of downloads and whole operations are already retried
+	// by the agent; retrying in client as well multiplies attempts
+	config.RetryAttempts = 1
+
+	downloadOptions, downloadErr := parseParallelDownloadOptions(options)
+	if err == nil {
+		err = downloadErr
+	}
+
+	return davBlobstore{
+		config:     config,
+		configErr:  err,
+		client:     davclient.NewClient(config),
+		downloader: newParallelDownloader(downloadOptions, timeService, logger),
+		fs:         fs,
+		uuidGen:    uuidGen,
+	}
+}
+
+func parseDavOptions(options map[string]interface{}) (davconf.Config, error) {
+	var config davconf.Config
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Marshalling dav options")
+	}
+
+	err = json.Unmarshal(optionsJSON, &config)
+	if err != nil {
+		return config, bosherr.WrapError(err, "Unmarshalling dav options")
+	}
+
+	return config, nil
+}
+
+func (b davBlobstore) Get(blobID, _ string) (string, error) {
+	file, err := b.fs.TempFile("bosh-blobstore-davBlobstore-Get")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file")
+	}
+
+	fileName := file.Name()
+
+	err = b.download(blobID, file)
+	file.Close()
+
+	if err != nil {
+		b.fs.RemoveAll(fileName)
+		return "", bosherr.WrapError(err, "Downloading blob %s", blobID)
+	}
+
+	return fileName, nil
+}
+
+func (b davBlobstore) download(blobID string, file *os.File) error {
+	return b.downloader.Download(blobID, file, func(start, end int64) (*http.Response, error) {
+		return b.client.GetRange(blobID, start, end)
+	})
+}
+
+func (b davBlobstore) CleanUp(fileName string) error {
+	return b.fs.RemoveAll(fileName)
+}
+
+func (b davBlobstore) Create(fileName string) (string, string, error) {
+	blobID, err := b.uuidGen.Generate()
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Generating blobID")
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Opening file for upload")
+	}
+
+	defer file.Close()
+
+	fingerprint, err := b.client.Put(blobID, file)
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Uploading blob %s", blobID)
+	}
+
+	return blobID, fingerprint, nil
+}
+
+func (b davBlobstore) Delete(blobID string) error {
+	err := b.client.Delete(blobID)
+	if err != nil {
+		return bosherr.WrapError(err, "Deleting blob %s", blobID)
+	}
+
+	return nil
+}
+
+func (b davBlobstore) Exists(blobID string) (bool, error) {
+	exists, err := b.client.Exists(blobID)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Checking existence of blob %s", blobID)
+	}
+
+	return exists, nil
+}
+
+func (b davBlobstore) Validate() error {
+	if b.configErr != nil {
+		return bosherr.WrapError(b.configErr, "Validating dav options")
+	}
+
+	if b.config.Endpoint == "" {
+		return bosherr.New("missing endpoint")
+	}
+
+	_, err := url.Parse(b.config.Endpoint)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing endpoint")
+	}
+
+	_, err = b.config.CertPool()
+	if err != nil {
+		return bosherr.WrapError(err, "Validating ca_cert")
+	}
+
+	return nil
+}
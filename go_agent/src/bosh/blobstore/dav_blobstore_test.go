@@ -0,0 +1,276 @@
+package blobstore_test
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/blobstore"
+	testcmd "bosh/davcli/cmd/testing"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+	faketime "bosh/time/fakes"
+	fakeuuid "bosh/uuid/fakes"
+)
+
+var _ = Describe("davBlobstore", func() {
+	var (
+		server      *httptest.Server
+		requests    []*http.Request
+		bodies      []string
+		status      int
+		fs          boshsys.FileSystem
+		logger      boshlog.Logger
+		timeService *faketime.FakeService
+		uuidGen     *fakeuuid.FakeGenerator
+		options     map[string]interface{}
+	)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+
+		w.WriteHeader(status)
+		w.Write([]byte("fake-content"))
+	})
+
+	BeforeEach(func() {
+		requests = []*http.Request{}
+		bodies = []string{}
+		status = http.StatusOK
+
+		server = httptest.NewServer(handler)
+
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		timeService = &faketime.FakeService{NowTime: time.Now()}
+		uuidGen = &fakeuuid.FakeGenerator{GeneratedUuid: "fake-blob-id"}
+
+		options = map[string]interface{}{
+			"endpoint": server.URL + "/fake-path",
+			"user":     "fake-user",
+			"password": "fake-password",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Validate", func() {
+		It("returns no error when endpoint is given", func() {
+			err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Validate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error when endpoint is missing", func() {
+			delete(options, "endpoint")
+
+			err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("missing endpoint"))
+		})
+
+		It("returns error when CA certificate is not PEM encoded", func() {
+			options["ca_cert"] = "fake-ca-cert"
+
+			err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Validating ca_cert"))
+		})
+
+		It("returns error when download part size is not positive", func() {
+			options["download_part_size"] = -1
+
+			err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("download_part_size must be positive"))
+		})
+	})
+
+	Describe("Get", func() {
+		It("downloads blob from SHA1 prefixed path into temporary file", func() {
+			fileName, err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer os.Remove(fileName)
+
+			content, err := ioutil.ReadFile(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("fake-content"))
+
+			Expect(requests[0].Method).To(Equal("GET"))
+			Expect(requests[0].URL.Path).To(Equal("/fake-path/80/fake-blob-id"))
+			Expect(requests[0].Header.Get("Range")).To(Equal("bytes=0-16777215"))
+
+			user, password, err := testcmd.NewHTTPRequest(requests[0]).ExtractBasicAuth()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(user).To(Equal("fake-user"))
+			Expect(password).To(Equal("fake-password"))
+		})
+
+		It("returns error and removes temporary file when response status is not successful", func() {
+			status = http.StatusNotFound
+
+			_, err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Get("fake-blob-id", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Wrong response code: 404"))
+		})
+
+		Context("when server supports ranges", func() {
+			var (
+				rangeServer *httptest.Server
+				rangeLock   sync.Mutex
+				ranges      []string
+				failRange   string
+			)
+
+			content := "0123456789abcdefghij-fake-large-content"
+
+			BeforeEach(func() {
+				ranges = []string{}
+				failRange = ""
+
+				rangeServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					rangeLock.Lock()
+					ranges = append(ranges, r.Header.Get("Range"))
+					fail := ""
+					if r.Header.Get("Range") == failRange {
+						fail, failRange = failRange, ""
+					}
+					rangeLock.Unlock()
+
+					if fail != "" {
+						// Body shorter than announced content length
+						w.Header().Set("Content-Length", "4")
+						w.Header().Set("Content-Range", fmt.Sprintf("bytes %s/%d", strings.TrimPrefix(fail, "bytes="), len(content)))
+						w.WriteHeader(http.StatusPartialContent)
+						w.Write([]byte("x"))
+						return
+					}
+
+					http.ServeContent(w, r, "", time.Time{}, bytes.NewReader([]byte(content)))
+				}))
+
+				options["endpoint"] = rangeServer.URL
+				options["download_part_size"] = 4
+				options["download_concurrency"] = 3
+			})
+
+			AfterEach(func() {
+				rangeServer.Close()
+			})
+
+			It("downloads large blob in parallel parts", func() {
+				fileName, err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Get("fake-blob-id", "")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer os.Remove(fileName)
+
+				downloaded, err := ioutil.ReadFile(fileName)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(downloaded)).To(Equal(content))
+
+				Expect(ranges).To(HaveLen(10))
+				Expect(ranges[0]).To(Equal("bytes=0-3"))
+				Expect(ranges).To(ContainElement("bytes=8-11"))
+				Expect(ranges).To(ContainElement("bytes=36-38"))
+			})
+
+			It("retries only the failed part", func() {
+				failRange = "bytes=8-11"
+
+				fileName, err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Get("fake-blob-id", "")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer os.Remove(fileName)
+
+				downloaded, err := ioutil.ReadFile(fileName)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(downloaded)).To(Equal(content))
+
+				Expect(ranges).To(HaveLen(11))
+				Expect(strings.Count(strings.Join(ranges, ","), "bytes=8-11")).To(Equal(2))
+				Expect(timeService.SleepDurations).To(Equal([]time.Duration{1 * time.Second}))
+			})
+		})
+
+		It("retries failed request only as part of download", func() {
+			status = http.StatusInternalServerError
+
+			_, err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Get("fake-blob-id", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Wrong response code: 500"))
+
+			Expect(requests).To(HaveLen(3))
+			Expect(timeService.SleepDurations).To(Equal([]time.Duration{1 * time.Second, 2 * time.Second}))
+		})
+
+		It("verifies HTTPS endpoint with configured CA certificate", func() {
+			tlsServer := httptest.NewTLSServer(handler)
+			defer tlsServer.Close()
+
+			options["endpoint"] = tlsServer.URL
+
+			_, err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Get("fake-blob-id", "")
+			Expect(err).To(HaveOccurred())
+
+			options["ca_cert"] = string(pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: tlsServer.TLS.Certificates[0].Certificate[0],
+			}))
+
+			fileName, err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+			os.Remove(fileName)
+		})
+	})
+
+	Describe("Create", func() {
+		It("uploads file to SHA1 prefixed path", func() {
+			file, err := ioutil.TempFile("", "dav-blobstore-test")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer os.Remove(file.Name())
+
+			file.WriteString("fake-upload-content")
+			file.Close()
+
+			status = http.StatusCreated
+
+			blobID, fingerprint, err := NewDavBlobstore(options, fs, uuidGen, timeService, logger).Create(file.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+			Expect(fingerprint).To(Equal("ae33b673c3c715c937f13247536dcdf439484ceb"))
+
+			Expect(requests[0].Method).To(Equal("PUT"))
+			Expect(requests[0].URL.Path).To(Equal("/fake-path/80/fake-blob-id"))
+			Expect(bodies[0]).To(Equal("fake-upload-content"))
+		})
+
+		It("returns error when response status is not successful", func() {
+			file, err := ioutil.TempFile("", "dav-blobstore-test")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer os.Remove(file.Name())
+			file.Close()
+
+			status = http.StatusInternalServerError
+
+			_, _, err = NewDavBlobstore(options, fs, uuidGen, timeService, logger).Create(file.Name())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Wrong response code: 500"))
+		})
+	})
+})
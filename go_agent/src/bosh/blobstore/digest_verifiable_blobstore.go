@@ -0,0 +1,87 @@
+package blobstore
+
+import (
+	boshcrypto "bosh/crypto"
+	bosherr "bosh/errors"
+)
+
+// digestVerifiableBlobstore verifies downloaded blobs against
+// the strongest digest of their fingerprint and fingerprints
+// uploaded blobs with default digest algorithms
+type digestVerifiableBlobstore struct {
+	blobstore Blobstore
+}
+
+func NewDigestVerifiableBlobstore(blobstore Blobstore) Blobstore {
+	return digestVerifiableBlobstore{blobstore: blobstore}
+}
+
+func (b digestVerifiableBlobstore) Get(blobID, fingerprint string) (string, error) {
+	var digests boshcrypto.MultipleDigest
+
+	if fingerprint != "" {
+		var err error
+
+		digests, err = boshcrypto.ParseMultipleDigest(fingerprint)
+		if err != nil {
+			return "", invalidFingerprintError{bosherr.WrapError(err, "Parsing fingerprint of blob %s", blobID)}
+		}
+	}
+
+	fileName, err := b.blobstore.Get(blobID, fingerprint)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting blob from inner blobstore")
+	}
+
+	if fingerprint == "" {
+		return fileName, nil
+	}
+
+	err = digests.VerifyFile(fileName)
+	if err != nil {
+		// Mismatch is not retried so downloaded file would be left behind
+		b.blobstore.CleanUp(fileName)
+		return "", err
+	}
+
+	return fileName, nil
+}
+
+// invalidFingerprintError is not retried since fingerprint would not change
+type invalidFingerprintError struct {
+	err error
+}
+
+func (e invalidFingerprintError) Error() string {
+	return e.err.Error()
+}
+
+func (b digestVerifiableBlobstore) CleanUp(fileName string) error {
+	return b.blobstore.CleanUp(fileName)
+}
+
+func (b digestVerifiableBlobstore) Create(fileName string) (string, string, error) {
+	digests, err := boshcrypto.NewMultipleDigestFromFile(fileName, boshcrypto.DefaultDigestAlgorithms...)
+	if err != nil {
+		return "", "", err
+	}
+
+	blobID, _, err := b.blobstore.Create(fileName)
+	if err != nil {
+		return "", "", err
+	}
+
+	return blobID, digests.String(), nil
+}
+
+func (b digestVerifiableBlobstore) Delete(blobID string) error {
+	return b.blobstore.Delete(blobID)
+}
+
+func (b digestVerifiableBlobstore) Exists(blobID string) (bool, error) {
+	return b.blobstore.Exists(blobID)
+}
+
+func (b digestVerifiableBlobstore) Validate() error {
+	return b.blobstore.Validate()
+}
@@ -0,0 +1,170 @@
+package blobstore_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshblob "bosh/blobstore"
+	fakeblob "bosh/blobstore/fakes"
+	boshcrypto "bosh/crypto"
+	bosherr "bosh/errors"
+)
+
+var _ = Describe("digestVerifiableBlobstore", func() {
+	const (
+		fixturePath   = "../../../fixtures/some.config"
+		fixtureSHA1   = "da39a3ee5e6b4b0d3255bfef95601890afd80709"
+		fixtureSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+		incorrectSHA1   = "0000000000000000000000000000000000000000"
+		incorrectSHA256 = "0000000000000000000000000000000000000000000000000000000000000000"
+	)
+
+	var (
+		innerBlobstore            *fakeblob.FakeBlobstore
+		digestVerifiableBlobstore boshblob.Blobstore
+	)
+
+	BeforeEach(func() {
+		innerBlobstore = &fakeblob.FakeBlobstore{}
+		digestVerifiableBlobstore = boshblob.NewDigestVerifiableBlobstore(innerBlobstore)
+	})
+
+	Describe("Get", func() {
+		It("returns without an error if plain sha1 matches", func() {
+			innerBlobstore.GetFileName = fixturePath
+
+			fileName, err := digestVerifiableBlobstore.Get("fake-blob-id", fixtureSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(innerBlobstore.GetBlobIDs).To(Equal([]string{"fake-blob-id"}))
+			Expect(fileName).To(Equal(fixturePath))
+		})
+
+		It("returns error if plain sha1 does not match", func() {
+			innerBlobstore.GetFileName = fixturePath
+
+			_, err := digestVerifiableBlobstore.Get("fake-blob-id", incorrectSHA1)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("SHA1 mismatch"))
+		})
+
+		It("cleans up downloaded blob and keeps mismatch error if digest does not match", func() {
+			innerBlobstore.GetFileName = fixturePath
+
+			_, err := digestVerifiableBlobstore.Get("fake-blob-id", "sha256:"+incorrectSHA256)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(boshcrypto.DigestMismatchError{}))
+
+			Expect(innerBlobstore.CleanUpFileName).To(Equal(fixturePath))
+		})
+
+		It("does not clean up blob if digest matches", func() {
+			innerBlobstore.GetFileName = fixturePath
+
+			_, err := digestVerifiableBlobstore.Get("fake-blob-id", fixtureSHA1)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(innerBlobstore.CleanUpFileName).To(BeEmpty())
+		})
+
+		It("returns without an error if sha256 matches", func() {
+			innerBlobstore.GetFileName = fixturePath
+
+			fileName, err := digestVerifiableBlobstore.Get("fake-blob-id", "sha256:"+fixtureSHA256)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal(fixturePath))
+		})
+
+		It("verifies the strongest digest when several are given", func() {
+			innerBlobstore.GetFileName = fixturePath
+
+			_, err := digestVerifiableBlobstore.Get("fake-blob-id", "sha1:"+fixtureSHA1+";sha256:"+incorrectSHA256)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("SHA256 mismatch"))
+
+			_, err = digestVerifiableBlobstore.Get("fake-blob-id", "sha1:"+incorrectSHA1+";sha256:"+fixtureSHA256)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error without getting blob if fingerprint is invalid", func() {
+			_, err := digestVerifiableBlobstore.Get("fake-blob-id", "some-incorrect-sha1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing fingerprint of blob fake-blob-id"))
+
+			Expect(innerBlobstore.GetBlobIDs).To(BeEmpty())
+		})
+
+		It("returns error if inner blobstore getting fails", func() {
+			innerBlobstore.GetError = errors.New("fake-get-error")
+
+			_, err := digestVerifiableBlobstore.Get("fake-blob-id", fixtureSHA1)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+		})
+
+		It("skips verification and returns without an error if fingerprint is empty", func() {
+			innerBlobstore.GetFileName = fixturePath
+
+			fileName, err := digestVerifiableBlobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fileName).To(Equal(fixturePath))
+		})
+	})
+
+	Describe("CleanUp", func() {
+		It("delegates to inner blobstore to clean up", func() {
+			err := digestVerifiableBlobstore.CleanUp("/some/file")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(innerBlobstore.CleanUpFileName).To(Equal("/some/file"))
+		})
+
+		It("returns error if inner blobstore cleaning up fails", func() {
+			innerBlobstore.CleanUpErr = errors.New("fake-clean-up-error")
+
+			err := digestVerifiableBlobstore.CleanUp("/some/file")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-clean-up-error"))
+		})
+	})
+
+	Describe("Create", func() {
+		It("delegates to inner blobstore to create blob and returns sha1 and sha256 of created blob", func() {
+			innerBlobstore.CreateBlobID = "fake-blob-id"
+
+			blobID, fingerprint, err := digestVerifiableBlobstore.Create(fixturePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+			Expect(fingerprint).To(Equal("sha1:" + fixtureSHA1 + ";sha256:" + fixtureSHA256))
+
+			Expect(innerBlobstore.CreateFileName).To(Equal(fixturePath))
+		})
+
+		It("returns error if inner blobstore blob creation fails", func() {
+			innerBlobstore.CreateErr = errors.New("fake-create-error")
+
+			_, _, err := digestVerifiableBlobstore.Create(fixturePath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-create-error"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("delegates to inner blobstore to validate", func() {
+			err := digestVerifiableBlobstore.Validate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if inner blobstore validation fails", func() {
+			innerBlobstore.ValidateError = bosherr.New("fake-validate-error")
+
+			err := digestVerifiableBlobstore.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-validate-error"))
+		})
+	})
+})
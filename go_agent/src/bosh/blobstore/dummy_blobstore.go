@@ -18,6 +18,14 @@ func (b dummyBlobstore) Create(fileName string) (string, string, error) {
 	return "", "", nil
 }
 
+func (b dummyBlobstore) Delete(blobID string) error {
+	return nil
+}
+
+func (b dummyBlobstore) Exists(blobID string) (bool, error) {
+	return false, nil
+}
+
 func (b dummyBlobstore) Validate() error {
 	return nil
 }
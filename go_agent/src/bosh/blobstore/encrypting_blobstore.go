@@ -0,0 +1,148 @@
+package blobstore
+
+import (
+	"bufio"
+	"os"
+
+	boshcrypto "bosh/crypto"
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+const encryptingBlobstoreLogTag = "encryptingBlobstore"
+
+// encryptingBlobstore encrypts uploaded blobs and decrypts downloaded ones.
+// Downloaded blobs without encryption header (e.g. packages uploaded
+// by the director) are returned as is; their integrity is checked
+// against fingerprints by outer blobstores.
+type encryptingBlobstore struct {
+	blobstore Blobstore
+	cipher    boshcrypto.BlobCipher
+	fs        boshsys.FileSystem
+	logger    boshlog.Logger
+}
+
+func NewEncryptingBlobstore(
+	blobstore Blobstore,
+	cipher boshcrypto.BlobCipher,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) Blobstore {
+	return encryptingBlobstore{
+		blobstore: blobstore,
+		cipher:    cipher,
+		fs:        fs,
+		logger:    logger,
+	}
+}
+
+func (b encryptingBlobstore) Get(blobID, fingerprint string) (string, error) {
+	// Fingerprint describes decrypted content
+	fileName, err := b.blobstore.Get(blobID, "")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting blob from inner blobstore")
+	}
+
+	decryptedFileName, err := b.decrypt(blobID, fileName)
+	if err != nil {
+		b.blobstore.CleanUp(fileName)
+		return "", err
+	}
+
+	if decryptedFileName != fileName {
+		b.blobstore.CleanUp(fileName)
+	}
+
+	return decryptedFileName, nil
+}
+
+// CleanUp is delegated for decrypted files as well since inner
+// blobstores clean up by removing given file
+func (b encryptingBlobstore) CleanUp(fileName string) error {
+	return b.blobstore.CleanUp(fileName)
+}
+
+func (b encryptingBlobstore) Create(fileName string) (string, string, error) {
+	encryptedFileName, err := b.encrypt(fileName)
+	if err != nil {
+		return "", "", err
+	}
+
+	defer b.fs.RemoveAll(encryptedFileName)
+
+	blobID, fingerprint, err := b.blobstore.Create(encryptedFileName)
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Creating blob in inner blobstore")
+	}
+
+	return blobID, fingerprint, nil
+}
+
+func (b encryptingBlobstore) Delete(blobID string) error {
+	return b.blobstore.Delete(blobID)
+}
+
+func (b encryptingBlobstore) Exists(blobID string) (bool, error) {
+	return b.blobstore.Exists(blobID)
+}
+
+func (b encryptingBlobstore) Validate() error {
+	return b.blobstore.Validate()
+}
+
+// decrypt returns given file name when blob is not encrypted
+func (b encryptingBlobstore) decrypt(blobID, fileName string) (string, error) {
+	file, err := b.fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening blob %s", blobID)
+	}
+
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	if !boshcrypto.IsEncrypted(reader) {
+		b.logger.Debug(encryptingBlobstoreLogTag, "Blob %s is not encrypted", blobID)
+		return fileName, nil
+	}
+
+	decryptedFile, decryptedFileName, err := openTempFile(b.fs, "bosh-blobstore-encryptingBlobstore-Get")
+	if err != nil {
+		return "", err
+	}
+
+	defer decryptedFile.Close()
+
+	err = b.cipher.Decrypt(decryptedFile, reader)
+	if err != nil {
+		b.fs.RemoveAll(decryptedFileName)
+		return "", bosherr.WrapError(err, "Reading encrypted blob %s", blobID)
+	}
+
+	return decryptedFileName, nil
+}
+
+func (b encryptingBlobstore) encrypt(fileName string) (string, error) {
+	file, err := b.fs.OpenFile(fileName, os.O_RDONLY, 0)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening file to encrypt")
+	}
+
+	defer file.Close()
+
+	encryptedFile, encryptedFileName, err := openTempFile(b.fs, "bosh-blobstore-encryptingBlobstore-Create")
+	if err != nil {
+		return "", err
+	}
+
+	defer encryptedFile.Close()
+
+	err = b.cipher.Encrypt(encryptedFile, file)
+	if err != nil {
+		b.fs.RemoveAll(encryptedFileName)
+		return "", bosherr.WrapError(err, "Encrypting %s", fileName)
+	}
+
+	return encryptedFileName, nil
+}
@@ -0,0 +1,186 @@
+package blobstore_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/blobstore"
+	fakeblob "bosh/blobstore/fakes"
+	boshcrypto "bosh/crypto"
+	boshlog "bosh/logger"
+	boshsettings "bosh/settings"
+	boshsys "bosh/system"
+	fakesys "bosh/system/fakes"
+	fakeuuid "bosh/uuid/fakes"
+)
+
+var _ = Describe("encryptingBlobstore", func() {
+	const (
+		// Base64 encoded 128 bit keys
+		oldKey = "MDEyMzQ1Njc4OWFiY2RlZg=="
+		newKey = "ZmVkY2JhOTg3NjU0MzIxMA=="
+	)
+
+	var (
+		fs             boshsys.FileSystem
+		logger         boshlog.Logger
+		blobstorePath  string
+		innerBlobstore Blobstore
+		fileName       string
+	)
+
+	buildBlobstore := func(keys map[string]string, keyID string) Blobstore {
+		blobCipher, err := boshcrypto.NewBlobCipher(boshsettings.BlobstoreEncryption{Keys: keys, KeyID: keyID})
+		Expect(err).ToNot(HaveOccurred())
+		return NewEncryptingBlobstore(innerBlobstore, blobCipher, fs, logger)
+	}
+
+	readFile := func(fileName string) string {
+		content, err := ioutil.ReadFile(fileName)
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		var err error
+
+		blobstorePath, err = ioutil.TempDir("", "encrypting-blobstore")
+		Expect(err).ToNot(HaveOccurred())
+
+		uuidGen := &fakeuuid.FakeGenerator{GeneratedUuid: "fake-blob-id"}
+		innerBlobstore = NewLocalBlobstore(fs, uuidGen, map[string]interface{}{"blobstore_path": blobstorePath})
+
+		file, err := ioutil.TempFile("", "encrypting-blobstore-content")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = file.WriteString("fake-sensitive-content")
+		Expect(err).ToNot(HaveOccurred())
+
+		file.Close()
+		fileName = file.Name()
+	})
+
+	AfterEach(func() {
+		os.Remove(fileName)
+		os.RemoveAll(blobstorePath)
+	})
+
+	It("uploads encrypted content and downloads decrypted content", func() {
+		blobstore := buildBlobstore(map[string]string{"fake-key-id": newKey}, "fake-key-id")
+
+		blobID, _, err := blobstore.Create(fileName)
+		Expect(err).ToNot(HaveOccurred())
+
+		storedFileName, err := innerBlobstore.Get(blobID, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		storedContent := readFile(storedFileName)
+		Expect(storedContent).To(HavePrefix("BOSHENC1"))
+		Expect(storedContent).ToNot(ContainSubstring("fake-sensitive-content"))
+
+		downloadedFileName, err := blobstore.Get(blobID, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readFile(downloadedFileName)).To(Equal("fake-sensitive-content"))
+	})
+
+	It("returns unencrypted blobs as is", func() {
+		blobID, _, err := innerBlobstore.Create(fileName)
+		Expect(err).ToNot(HaveOccurred())
+
+		blobstore := buildBlobstore(map[string]string{"fake-key-id": newKey}, "fake-key-id")
+
+		downloadedFileName, err := blobstore.Get(blobID, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readFile(downloadedFileName)).To(Equal("fake-sensitive-content"))
+	})
+
+	It("decrypts blobs encrypted with previous key after key rotation", func() {
+		oldBlobstore := buildBlobstore(map[string]string{"fake-old-key-id": oldKey}, "fake-old-key-id")
+
+		blobID, _, err := oldBlobstore.Create(fileName)
+		Expect(err).ToNot(HaveOccurred())
+
+		blobstore := buildBlobstore(map[string]string{"fake-old-key-id": oldKey, "fake-new-key-id": newKey}, "fake-new-key-id")
+
+		downloadedFileName, err := blobstore.Get(blobID, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readFile(downloadedFileName)).To(Equal("fake-sensitive-content"))
+	})
+
+	It("returns error naming the key when blob is encrypted with unknown key", func() {
+		oldBlobstore := buildBlobstore(map[string]string{"fake-old-key-id": oldKey}, "fake-old-key-id")
+
+		blobID, _, err := oldBlobstore.Create(fileName)
+		Expect(err).ToNot(HaveOccurred())
+
+		blobstore := buildBlobstore(map[string]string{"fake-new-key-id": newKey}, "fake-new-key-id")
+
+		_, err = blobstore.Get(blobID, "")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Decrypting blob encrypted with key 'fake-old-key-id': key is unknown"))
+	})
+
+	It("returns error when encrypted content was modified", func() {
+		blobstore := buildBlobstore(map[string]string{"fake-key-id": newKey}, "fake-key-id")
+
+		blobID, _, err := blobstore.Create(fileName)
+		Expect(err).ToNot(HaveOccurred())
+
+		storedFileName := filepath.Join(blobstorePath, blobID)
+
+		storedContent := []byte(readFile(storedFileName))
+		storedContent[len(storedContent)-1] ^= 1
+
+		err = ioutil.WriteFile(storedFileName, storedContent, os.ModePerm)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = blobstore.Get(blobID, "")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("content failed authentication"))
+	})
+
+	It("reads and writes blobs through file system", func() {
+		fakeFs := fakesys.NewFakeFileSystem()
+		fakeInnerBlobstore := fakeblob.NewFakeBlobstore()
+
+		blobCipher, err := boshcrypto.NewBlobCipher(boshsettings.BlobstoreEncryption{
+			Keys:  map[string]string{"fake-key-id": newKey},
+			KeyID: "fake-key-id",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		blobstore := NewEncryptingBlobstore(fakeInnerBlobstore, blobCipher, fakeFs, logger)
+
+		err = fakeFs.WriteFileString("/fake-file", "fake-sensitive-content")
+		Expect(err).ToNot(HaveOccurred())
+
+		// Encrypted file is removed once uploaded
+		fakeInnerBlobstore.CreateCallBack = func() {
+			encrypted, err := fakeFs.ReadFile(fakeInnerBlobstore.CreateFileName)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fakeFs.WriteFile("/fake-stored-file", encrypted)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		_, _, err = blobstore.Create("/fake-file")
+		Expect(err).ToNot(HaveOccurred())
+
+		storedContent, err := fakeFs.ReadFileString("/fake-stored-file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(storedContent).To(HavePrefix("BOSHENC1"))
+
+		fakeInnerBlobstore.GetFileName = "/fake-stored-file"
+
+		downloadedFileName, err := blobstore.Get("fake-blob-id", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeFs.ReadFileString(downloadedFileName)).To(Equal("fake-sensitive-content"))
+	})
+})
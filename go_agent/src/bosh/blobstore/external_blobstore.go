@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	bosherr "bosh/errors"
 	boshsys "bosh/system"
@@ -45,7 +46,7 @@ func (b externalBlobstore) Get(blobID, _ string) (string, error) {
 
 	fileName := file.Name()
 
-	err = b.run("get", blobID, fileName)
+	_, err = b.run("get", blobID, fileName)
 	if err != nil {
 		b.fs.RemoveAll(fileName)
 		return "", err
@@ -69,7 +70,7 @@ func (b externalBlobstore) Create(fileName string) (string, string, error) {
 		return "", "", bosherr.WrapError(err, "Generating UUID")
 	}
 
-	err = b.run("put", filePath, blobID)
+	_, err = b.run("put", filePath, blobID)
 	if err != nil {
 		return "", "", bosherr.WrapError(err, "Making put command")
 	}
@@ -77,6 +78,32 @@ func (b externalBlobstore) Create(fileName string) (string, string, error) {
 	return blobID, "", nil
 }
 
+func (b externalBlobstore) Delete(blobID string) error {
+	_, err := b.run("delete", blobID)
+	if err != nil {
+		return bosherr.WrapError(err, "Making delete command")
+	}
+
+	return nil
+}
+
+// Exists expects executable to print true or false
+func (b externalBlobstore) Exists(blobID string) (bool, error) {
+	stdout, err := b.run("exists", blobID)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Making exists command")
+	}
+
+	switch strings.TrimSpace(stdout) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return false, bosherr.New("Unexpected exists command output '%s'", stdout)
+	}
+}
+
 func (b externalBlobstore) Validate() error {
 	if !b.runner.CommandExists(b.executable()) {
 		return bosherr.New("executable %s not found in PATH", b.executable())
@@ -99,13 +126,15 @@ func (b externalBlobstore) writeConfigFile() error {
 	return nil
 }
 
-func (b externalBlobstore) run(method, src, dst string) (err error) {
-	_, _, _, err = b.runner.RunCommand(b.executable(), "-c", b.configFilePath, method, src, dst)
+func (b externalBlobstore) run(method string, args ...string) (string, error) {
+	cmdArgs := append([]string{"-c", b.configFilePath, method}, args...)
+
+	stdout, _, _, err := b.runner.RunCommand(b.executable(), cmdArgs...)
 	if err != nil {
-		return bosherr.WrapError(err, "Shelling out to %s cli", b.executable())
+		return "", bosherr.WrapError(err, "Shelling out to %s cli", b.executable())
 	}
 
-	return nil
+	return stdout, nil
 }
 
 func (b externalBlobstore) executable() string {
@@ -151,4 +151,58 @@ var _ = Describe("externalBlobstore", func() {
 			}))
 		})
 	})
+
+	Describe("Delete", func() {
+		It("external delete", func() {
+			err := blobstore.Delete("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunCommands).To(Equal([][]string{{
+				"bosh-blobstore-fake-provider", "-c", configPath, "delete", "fake-blob-id",
+			}}))
+		})
+
+		It("external delete errs when external cli errs", func() {
+			expectedCmd := []string{"bosh-blobstore-fake-provider", "-c", configPath, "delete", "fake-blob-id"}
+			runner.AddCmdResult(strings.Join(expectedCmd, " "), fakesys.FakeCmdResult{Error: errors.New("fake-error")})
+
+			err := blobstore.Delete("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-error"))
+		})
+	})
+
+	Describe("Exists", func() {
+		var expectedCmd string
+
+		BeforeEach(func() {
+			expectedCmd = strings.Join([]string{
+				"bosh-blobstore-fake-provider", "-c", configPath, "exists", "fake-blob-id",
+			}, " ")
+		})
+
+		It("returns true when external cli prints true", func() {
+			runner.AddCmdResult(expectedCmd, fakesys.FakeCmdResult{Stdout: "true\n"})
+
+			exists, err := blobstore.Exists("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		})
+
+		It("returns false when external cli prints false", func() {
+			runner.AddCmdResult(expectedCmd, fakesys.FakeCmdResult{Stdout: "false\n"})
+
+			exists, err := blobstore.Exists("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("returns error when external cli prints something else", func() {
+			runner.AddCmdResult(expectedCmd, fakesys.FakeCmdResult{Stdout: "fake-output"})
+
+			_, err := blobstore.Exists("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unexpected exists command output 'fake-output'"))
+		})
+	})
 })
@@ -17,6 +17,13 @@ type FakeBlobstore struct {
 	CreateErr         error
 	CreateCallBack    func()
 
+	DeleteBlobIDs []string
+	DeleteErr     error
+
+	ExistsBlobIDs []string
+	ExistsResult  bool
+	ExistsErr     error
+
 	ValidateError error
 }
 
@@ -58,6 +65,16 @@ func (bs *FakeBlobstore) Create(fileName string) (string, string, error) {
 	return bs.CreateBlobID, bs.CreateFingerprint, bs.CreateErr
 }
 
+func (bs *FakeBlobstore) Delete(blobID string) error {
+	bs.DeleteBlobIDs = append(bs.DeleteBlobIDs, blobID)
+	return bs.DeleteErr
+}
+
+func (bs *FakeBlobstore) Exists(blobID string) (bool, error) {
+	bs.ExistsBlobIDs = append(bs.ExistsBlobIDs, blobID)
+	return bs.ExistsResult, bs.ExistsErr
+}
+
 func (bs *FakeBlobstore) Validate() error {
 	return bs.ValidateError
 }
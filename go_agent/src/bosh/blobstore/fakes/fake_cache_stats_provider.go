@@ -0,0 +1,13 @@
+package fakes
+
+import (
+	boshblob "bosh/blobstore"
+)
+
+type FakeCacheStatsProvider struct {
+	Stats boshblob.CacheStats
+}
+
+func (p *FakeCacheStatsProvider) GetCacheStats() boshblob.CacheStats {
+	return p.Stats
+}
@@ -0,0 +1,179 @@
+package fakes
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FakeS3Request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+}
+
+// FakeS3Server is an in-memory stand-in for S3 that only
+// understands path-style object requests used by the s3 blobstore
+type FakeS3Server struct {
+	Server *httptest.Server
+
+	Objects   map[string][]byte
+	Requests  []FakeS3Request
+	Uploads   map[string]map[int][]byte
+	Completed []string
+	Aborted   []string
+
+	// Respond with given status and S3 error code to matching requests
+	// e.g. FailMethod "PUT" fails object and part uploads
+	FailMethod string
+	FailStatus int
+	FailCode   string
+
+	lock         sync.Mutex
+	lastUploadID int
+}
+
+func NewFakeS3Server() *FakeS3Server {
+	s := &FakeS3Server{
+		Objects: map[string][]byte{},
+		Uploads: map[string]map[int][]byte{},
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Host returns host:port that should be used with s3_force_path_style
+func (s *FakeS3Server) Host() string {
+	return strings.TrimPrefix(s.Server.URL, "http://")
+}
+
+func (s *FakeS3Server) Close() {
+	s.Server.Close()
+}
+
+func (s *FakeS3Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.Requests = append(s.Requests, FakeS3Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Header: r.Header,
+	})
+
+	if s.FailMethod == r.Method {
+		s.writeError(w, s.FailStatus, s.FailCode)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+
+	if r.ContentLength != int64(len(body)) {
+		s.writeError(w, http.StatusLengthRequired, "MissingContentLength")
+		return
+	}
+
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+
+	switch {
+	case r.Method == "POST" && query["uploads"] != nil:
+		s.lastUploadID++
+		uploadID = fmt.Sprintf("fake-upload-id-%d", s.lastUploadID)
+		s.Uploads[uploadID] = map[int][]byte{}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", uploadID)
+
+	case r.Method == "PUT" && uploadID != "":
+		partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+		s.Uploads[uploadID][partNumber] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"fake-etag-%d"`, partNumber))
+
+	case r.Method == "POST" && uploadID != "":
+		s.completeUpload(w, r.URL.Path, uploadID, body)
+
+	case r.Method == "DELETE" && uploadID != "":
+		delete(s.Uploads, uploadID)
+		s.Aborted = append(s.Aborted, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == "PUT":
+		s.Objects[r.URL.Path] = body
+
+	case r.Method == "GET" || r.Method == "HEAD":
+		object, found := s.Objects[r.URL.Path]
+		if !found {
+			s.writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		// Handles Range headers
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(object))
+
+	case r.Method == "DELETE":
+		delete(s.Objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (s *FakeS3Server) completeUpload(w http.ResponseWriter, path, uploadID string, body []byte) {
+	var complete struct {
+		Parts []struct {
+			PartNumber int
+			ETag       string
+		} `xml:"Part"`
+	}
+
+	err := xml.Unmarshal(body, &complete)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+
+	parts := s.Uploads[uploadID]
+
+	partNumbers := []int{}
+	for _, part := range complete.Parts {
+		if part.ETag != fmt.Sprintf(`"fake-etag-%d"`, part.PartNumber) {
+			// Real S3 reports this error with 200 OK status
+			fmt.Fprintf(w, "<Error><Code>InvalidPart</Code><Message>part %d</Message></Error>", part.PartNumber)
+			return
+		}
+		partNumbers = append(partNumbers, part.PartNumber)
+	}
+
+	sort.Ints(partNumbers)
+
+	object := []byte{}
+	for _, partNumber := range partNumbers {
+		object = append(object, parts[partNumber]...)
+	}
+
+	s.Objects[path] = object
+	s.Completed = append(s.Completed, uploadID)
+	delete(s.Uploads, uploadID)
+
+	fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+}
+
+func (s *FakeS3Server) writeError(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>fake-message</Message></Error>", code)
+}
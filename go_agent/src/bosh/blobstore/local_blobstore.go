@@ -64,6 +64,19 @@ func (b localBlobstore) Create(fileName string) (blobID string, fingerprint stri
 	return
 }
 
+func (b localBlobstore) Delete(blobID string) error {
+	err := b.fs.RemoveAll(filepath.Join(b.path(), blobID))
+	if err != nil {
+		return bosherr.WrapError(err, "Removing blob from blobstore path")
+	}
+
+	return nil
+}
+
+func (b localBlobstore) Exists(blobID string) (bool, error) {
+	return b.fs.FileExists(filepath.Join(b.path(), blobID)), nil
+}
+
 func (b localBlobstore) Validate() error {
 	path, found := b.options["blobstore_path"]
 	if !found {
@@ -0,0 +1,306 @@
+package blobstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	davclient "bosh/davcli/client"
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshtime "bosh/time"
+)
+
+const (
+	parallelDownloaderLogTag = "parallelDownloader"
+
+	defaultDownloadPartSize    = 16 * 1024 * 1024
+	defaultDownloadConcurrency = 4
+
+	// Each part is retried on its own before the whole download fails;
+	// retryableBlobstore retries the whole download again, so a part is
+	// requested at most downloadPartAttempts * max_attempts times
+	downloadPartAttempts   = 3
+	downloadPartRetryDelay = 1 * time.Second
+)
+
+// ParallelDownloadOptions are understood by HTTP based blobstores (s3, dav)
+type ParallelDownloadOptions struct {
+	// Blobs larger than part size are downloaded as byte ranges
+	// of part size; defaults to 16 MiB
+	PartSize int64 `json:"download_part_size"`
+
+	// Number of parts downloaded at the same time; defaults to 4
+	Concurrency int `json:"download_concurrency"`
+}
+
+func parseParallelDownloadOptions(options map[string]interface{}) (ParallelDownloadOptions, error) {
+	var downloadOptions ParallelDownloadOptions
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return downloadOptions, bosherr.WrapError(err, "Marshalling download options")
+	}
+
+	err = json.Unmarshal(optionsJSON, &downloadOptions)
+	if err != nil {
+		return downloadOptions, bosherr.WrapError(err, "Unmarshalling download options")
+	}
+
+	if downloadOptions.PartSize == 0 {
+		downloadOptions.PartSize = defaultDownloadPartSize
+	}
+
+	if downloadOptions.Concurrency == 0 {
+		downloadOptions.Concurrency = defaultDownloadConcurrency
+	}
+
+	if downloadOptions.PartSize < 0 {
+		return downloadOptions, bosherr.New("download_part_size must be positive")
+	}
+
+	if downloadOptions.Concurrency < 0 {
+		return downloadOptions, bosherr.New("download_concurrency must be positive")
+	}
+
+	return downloadOptions, nil
+}
+
+// rangeGetter sends GET request with Range header for bytes
+// from start to end inclusive; caller must close response body
+type rangeGetter func(start, end int64) (*http.Response, error)
+
+type parallelDownloader struct {
+	options     ParallelDownloadOptions
+	timeService boshtime.Service
+	logger      boshlog.Logger
+}
+
+func newParallelDownloader(
+	options ParallelDownloadOptions,
+	timeService boshtime.Service,
+	logger boshlog.Logger,
+) parallelDownloader {
+	return parallelDownloader{
+		options:     options,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+// Download writes blob into file. Response to the first part tells blob size
+// so that small blobs take a single request; when server ignores Range header
+// the whole blob is written from that response.
+func (d parallelDownloader) Download(blobID string, file *os.File, getRange rangeGetter) error {
+	var size int64
+
+	err := d.retryPart(blobID, 1, func() (err error) {
+		size, err = d.downloadFirstPart(file, getRange)
+		return
+	})
+	if err != nil {
+		// S3 rejects ranges of empty blobs
+		if isRangeNotSatisfiableError(err) {
+			return nil
+		}
+		return err
+	}
+
+	if size <= d.options.PartSize {
+		return nil
+	}
+
+	d.logger.Debug(parallelDownloaderLogTag, "Downloading blob %s of %d bytes in parts of %d bytes",
+		blobID, size, d.options.PartSize)
+
+	return d.downloadRemainingParts(blobID, file, getRange, size)
+}
+
+// downloadFirstPart returns blob size, or 0 when server
+// ignored Range header and sent the whole blob
+func (d parallelDownloader) downloadFirstPart(file *os.File, getRange rangeGetter) (int64, error) {
+	resp, err := getRange(0, d.options.PartSize-1)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		_, err = writeFileAt(file, 0, resp.Body, resp.ContentLength)
+		return 0, err
+	}
+
+	start, end, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return 0, err
+	}
+
+	if start != 0 || end != minInt64(d.options.PartSize, size)-1 {
+		return 0, bosherr.New("Unexpected content range %d-%d/%d", start, end, size)
+	}
+
+	_, err = writeFileAt(file, 0, resp.Body, end+1)
+	if err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// downloadRemainingParts stops handing out parts after the first failure
+func (d parallelDownloader) downloadRemainingParts(blobID string, file *os.File, getRange rangeGetter, size int64) error {
+	var (
+		wg       sync.WaitGroup
+		errLock  sync.Mutex
+		firstErr error
+	)
+
+	failed := func() bool {
+		errLock.Lock()
+		defer errLock.Unlock()
+		return firstErr != nil
+	}
+
+	offsets := make(chan int64)
+
+	for i := 0; i < d.options.Concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for offset := range offsets {
+				err := d.downloadPart(blobID, file, getRange, offset, size)
+				if err != nil {
+					errLock.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					errLock.Unlock()
+				}
+			}
+		}()
+	}
+
+	for offset := d.options.PartSize; offset < size && !failed(); offset += d.options.PartSize {
+		offsets <- offset
+	}
+
+	close(offsets)
+	wg.Wait()
+
+	return firstErr
+}
+
+func (d parallelDownloader) downloadPart(blobID string, file *os.File, getRange rangeGetter, offset, size int64) error {
+	end := minInt64(offset+d.options.PartSize, size) - 1
+	partNumber := int(offset/d.options.PartSize) + 1
+
+	return d.retryPart(blobID, partNumber, func() error {
+		resp, err := getRange(offset, end)
+		if err != nil {
+			return err
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusPartialContent {
+			return bosherr.New("Expected partial content, got response status %d", resp.StatusCode)
+		}
+
+		gotStart, gotEnd, gotSize, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return err
+		}
+
+		// Size changes when blob is replaced during download
+		if gotStart != offset || gotEnd != end || gotSize != size {
+			return bosherr.New("Unexpected content range %d-%d/%d", gotStart, gotEnd, gotSize)
+		}
+
+		_, err = writeFileAt(file, offset, resp.Body, end-offset+1)
+		return err
+	})
+}
+
+func (d parallelDownloader) retryPart(blobID string, partNumber int, download func() error) error {
+	delay := downloadPartRetryDelay
+
+	for attempt := 1; ; attempt++ {
+		err := download()
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= downloadPartAttempts || !isRetryableError(err) {
+			return bosherr.WrapError(err, "Downloading part %d of blob %s", partNumber, blobID)
+		}
+
+		d.logger.Info(parallelDownloaderLogTag, "Downloading part %d of blob %s failed in attempt %d of %d, retrying in %s: %s",
+			partNumber, blobID, attempt, downloadPartAttempts, delay, err.Error())
+
+		d.timeService.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// writeFileAt returns number of written bytes; length is not checked when negative
+func writeFileAt(file *os.File, offset int64, content io.Reader, length int64) (int64, error) {
+	written, err := io.Copy(&offsetWriter{file: file, offset: offset}, content)
+	if err != nil {
+		return written, bosherr.WrapError(err, "Writing response body")
+	}
+
+	if length >= 0 && written != length {
+		return written, bosherr.New("Expected %d bytes, got %d", length, written)
+	}
+
+	return written, nil
+}
+
+// offsetWriter allows parts to be written into the same file concurrently
+type offsetWriter struct {
+	file   *os.File
+	offset int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.file.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
+
+// parseContentRange parses "bytes <start>-<end>/<size>"
+func parseContentRange(contentRange string) (int64, int64, int64, error) {
+	var start, end, size int64
+
+	_, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &size)
+	if err != nil || start < 0 || end < start || size <= end {
+		return 0, 0, 0, bosherr.New("Invalid content range '%s'", contentRange)
+	}
+
+	return start, end, size, nil
+}
+
+func isRangeNotSatisfiableError(err error) bool {
+	switch typedCause := bosherr.Cause(err).(type) {
+	case s3StatusError:
+		return typedCause.StatusCode == http.StatusRequestedRangeNotSatisfiable
+	case davclient.ResponseError:
+		return typedCause.StatusCode == http.StatusRequestedRangeNotSatisfiable
+	}
+
+	return false
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"path/filepath"
 
+	boshcrypto "bosh/crypto"
 	bosherr "bosh/errors"
 	boshlog "bosh/logger"
 	boshplatform "bosh/platform"
 	boshsettings "bosh/settings"
 	boshdir "bosh/settings/directories"
+	boshtime "bosh/time"
 	boshuuid "bosh/uuid"
 )
 
@@ -16,6 +18,7 @@ type Provider struct {
 	platform    boshplatform.Platform
 	dirProvider boshdir.DirectoriesProvider
 	uuidGen     boshuuid.Generator
+	timeService boshtime.Service
 	logger      boshlog.Logger
 }
 
@@ -25,6 +28,7 @@ func NewProvider(
 	logger boshlog.Logger,
 ) (p Provider) {
 	p.uuidGen = boshuuid.NewGenerator()
+	p.timeService = boshtime.NewConcreteService()
 	p.platform = platform
 	p.dirProvider = dirProvider
 	p.logger = logger
@@ -46,6 +50,24 @@ func (p Provider) Get(settings boshsettings.Blobstore) (blobstore Blobstore, err
 			settings.Options,
 		)
 
+	case boshsettings.BlobstoreTypeDav:
+		blobstore = NewDavBlobstore(
+			settings.Options,
+			p.platform.GetFs(),
+			p.uuidGen,
+			p.timeService,
+			p.logger,
+		)
+
+	case boshsettings.BlobstoreTypeS3:
+		blobstore = NewS3Blobstore(
+			settings.Options,
+			p.platform.GetFs(),
+			p.uuidGen,
+			p.timeService,
+			p.logger,
+		)
+
 	default:
 		blobstore = NewExternalBlobstore(
 			settings.Type,
@@ -57,9 +79,19 @@ func (p Provider) Get(settings boshsettings.Blobstore) (blobstore Blobstore, err
 		)
 	}
 
-	blobstore = NewSHA1VerifiableBlobstore(blobstore)
+	if len(settings.Encryption.Keys) > 0 {
+		blobCipher, err := boshcrypto.NewBlobCipher(settings.Encryption)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Building blob cipher")
+		}
+
+		// Placed inside digest verification so that fingerprints describe unencrypted content
+		blobstore = NewEncryptingBlobstore(blobstore, blobCipher, p.platform.GetFs(), p.logger)
+	}
+
+	blobstore = NewDigestVerifiableBlobstore(blobstore)
 
-	blobstore = NewRetryableBlobstore(blobstore, 3, p.logger)
+	blobstore = NewRetryableBlobstore(blobstore, settings.Retry, p.timeService, p.logger)
 
 	err = blobstore.Validate()
 	if err != nil {
@@ -5,10 +5,12 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "bosh/blobstore"
+	boshcrypto "bosh/crypto"
 	boshlog "bosh/logger"
 	fakeplatform "bosh/platform/fakes"
 	boshsettings "bosh/settings"
 	boshdir "bosh/settings/directories"
+	boshtime "bosh/time"
 	boshuuid "bosh/uuid"
 )
 
@@ -35,6 +37,37 @@ var _ = Describe("Provider", func() {
 			Expect(blobstore).ToNot(BeNil())
 		})
 
+		It("get s3 without requiring external command", func() {
+			platform.Runner.CommandExistsValue = false
+
+			blobstore, err := provider.Get(boshsettings.Blobstore{
+				Type:    boshsettings.BlobstoreTypeS3,
+				Options: map[string]interface{}{"bucket_name": "fake-bucket"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobstore).ToNot(BeNil())
+		})
+
+		It("get s3 errs when options are invalid", func() {
+			_, err := provider.Get(boshsettings.Blobstore{
+				Type:    boshsettings.BlobstoreTypeS3,
+				Options: map[string]interface{}{},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("missing bucket_name"))
+		})
+
+		It("get dav without requiring external command", func() {
+			platform.Runner.CommandExistsValue = false
+
+			blobstore, err := provider.Get(boshsettings.Blobstore{
+				Type:    boshsettings.BlobstoreTypeDav,
+				Options: map[string]interface{}{"endpoint": "http://fake-endpoint"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobstore).ToNot(BeNil())
+		})
+
 		It("get external when external command in path", func() {
 			options := map[string]interface{}{"key": "value"}
 
@@ -54,14 +87,70 @@ var _ = Describe("Provider", func() {
 				boshuuid.NewGenerator(),
 				"/var/vcap/bosh/etc/blobstore-fake-external-type.json",
 			)
-			expectedBlobstore = NewSHA1VerifiableBlobstore(expectedBlobstore)
-			expectedBlobstore = NewRetryableBlobstore(expectedBlobstore, 3, logger)
+			expectedBlobstore = NewDigestVerifiableBlobstore(expectedBlobstore)
+			expectedBlobstore = NewRetryableBlobstore(expectedBlobstore, boshsettings.BlobstoreRetry{}, boshtime.NewConcreteService(), logger)
 			Expect(blobstore).To(Equal(expectedBlobstore))
 
 			err = expectedBlobstore.Validate()
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("retries with limits from settings", func() {
+			retry := boshsettings.BlobstoreRetry{MaxAttempts: 5, DeadlineSeconds: 600}
+
+			options := map[string]interface{}{"blobstore_path": "/fake-blobstore-path"}
+
+			blobstore, err := provider.Get(boshsettings.Blobstore{
+				Type:    boshsettings.BlobstoreTypeLocal,
+				Options: options,
+				Retry:   retry,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			expectedBlobstore := NewLocalBlobstore(platform.GetFs(), boshuuid.NewGenerator(), options)
+			expectedBlobstore = NewDigestVerifiableBlobstore(expectedBlobstore)
+			expectedBlobstore = NewRetryableBlobstore(expectedBlobstore, retry, boshtime.NewConcreteService(), logger)
+			Expect(blobstore).To(Equal(expectedBlobstore))
+		})
+
+		It("encrypts blobs when encryption keys are given", func() {
+			encryption := boshsettings.BlobstoreEncryption{
+				Keys:  map[string]string{"fake-key-id": "MDEyMzQ1Njc4OWFiY2RlZg=="},
+				KeyID: "fake-key-id",
+			}
+
+			options := map[string]interface{}{"blobstore_path": "/fake-blobstore-path"}
+
+			blobstore, err := provider.Get(boshsettings.Blobstore{
+				Type:       boshsettings.BlobstoreTypeLocal,
+				Options:    options,
+				Encryption: encryption,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			blobCipher, err := boshcrypto.NewBlobCipher(encryption)
+			Expect(err).ToNot(HaveOccurred())
+
+			expectedBlobstore := NewLocalBlobstore(platform.GetFs(), boshuuid.NewGenerator(), options)
+			expectedBlobstore = NewEncryptingBlobstore(expectedBlobstore, blobCipher, platform.GetFs(), logger)
+			expectedBlobstore = NewDigestVerifiableBlobstore(expectedBlobstore)
+			expectedBlobstore = NewRetryableBlobstore(expectedBlobstore, boshsettings.BlobstoreRetry{}, boshtime.NewConcreteService(), logger)
+			Expect(blobstore).To(Equal(expectedBlobstore))
+		})
+
+		It("errs when encryption key id is not one of encryption keys", func() {
+			_, err := provider.Get(boshsettings.Blobstore{
+				Type:    boshsettings.BlobstoreTypeLocal,
+				Options: map[string]interface{}{"blobstore_path": "/fake-blobstore-path"},
+				Encryption: boshsettings.BlobstoreEncryption{
+					Keys:  map[string]string{"fake-key-id": "MDEyMzQ1Njc4OWFiY2RlZg=="},
+					KeyID: "fake-other-key-id",
+				},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Encryption key id 'fake-other-key-id' is not one of given keys"))
+		})
+
 		It("get external errs when external command not in path", func() {
 			options := map[string]interface{}{"key": "value"}
 
@@ -1,41 +1,73 @@
 package blobstore
 
 import (
+	"math/rand"
+	"net/http"
+	"os"
+	"time"
+
+	boshcrypto "bosh/crypto"
+	davclient "bosh/davcli/client"
 	bosherr "bosh/errors"
 	boshlog "bosh/logger"
+	boshsettings "bosh/settings"
+	boshtime "bosh/time"
 )
 
-const retryableBlobstoreLogTag = "retryableBlobstore"
+const (
+	retryableBlobstoreLogTag = "retryableBlobstore"
+
+	defaultRetryMaxAttempts = 3
+
+	// Delay is doubled after every failed attempt up to max delay
+	retryInitialDelay = 1 * time.Second
+	retryMaxDelay     = 30 * time.Second
+)
 
+// retryableBlobstore retries operations that failed with errors
+// that may go away (e.g. network errors, 5xx responses) using exponential
+// backoff with jitter. Permanent failures (e.g. 404, authentication
+// errors, digest mismatch, decryption failures) are returned right away.
 type retryableBlobstore struct {
-	blobstore Blobstore
-	maxTries  int
-	logger    boshlog.Logger
+	blobstore   Blobstore
+	maxAttempts int
+	deadline    time.Duration
+	timeService boshtime.Service
+	logger      boshlog.Logger
 }
 
-func NewRetryableBlobstore(blobstore Blobstore, maxTries int, logger boshlog.Logger) Blobstore {
+func NewRetryableBlobstore(
+	blobstore Blobstore,
+	retry boshsettings.BlobstoreRetry,
+	timeService boshtime.Service,
+	logger boshlog.Logger,
+) Blobstore {
+	maxAttempts := retry.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = defaultRetryMaxAttempts
+	}
+
 	return retryableBlobstore{
-		blobstore: blobstore,
-		maxTries:  maxTries,
-		logger:    logger,
+		blobstore:   blobstore,
+		maxAttempts: maxAttempts,
+		deadline:    time.Duration(retry.DeadlineSeconds) * time.Second,
+		timeService: timeService,
+		logger:      logger,
 	}
 }
 
 func (b retryableBlobstore) Get(blobID, fingerprint string) (string, error) {
 	var fileName string
-	var lastErr error
-
-	for i := 0; i < b.maxTries; i++ {
-		fileName, lastErr = b.blobstore.Get(blobID, fingerprint)
-		if lastErr == nil {
-			return fileName, nil
-		}
 
-		b.logger.Info(retryableBlobstoreLogTag,
-			"Failed to get blob with error %s, attempt %d", lastErr.Error(), i)
+	err := b.retry("Getting blob "+blobID, func() (err error) {
+		fileName, err = b.blobstore.Get(blobID, fingerprint)
+		return
+	})
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting blob from inner blobstore")
 	}
 
-	return "", bosherr.WrapError(lastErr, "Getting blob from inner blobstore")
+	return fileName, nil
 }
 
 func (b retryableBlobstore) CleanUp(fileName string) error {
@@ -43,13 +75,137 @@ func (b retryableBlobstore) CleanUp(fileName string) error {
 }
 
 func (b retryableBlobstore) Create(fileName string) (string, string, error) {
-	return b.blobstore.Create(fileName)
+	var blobID, fingerprint string
+
+	err := b.retry("Creating blob from "+fileName, func() (err error) {
+		blobID, fingerprint, err = b.blobstore.Create(fileName)
+		return
+	})
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Creating blob in inner blobstore")
+	}
+
+	return blobID, fingerprint, nil
+}
+
+func (b retryableBlobstore) Delete(blobID string) error {
+	err := b.retry("Deleting blob "+blobID, func() error {
+		return b.blobstore.Delete(blobID)
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Deleting blob in inner blobstore")
+	}
+
+	return nil
+}
+
+func (b retryableBlobstore) Exists(blobID string) (bool, error) {
+	var exists bool
+
+	err := b.retry("Checking existence of blob "+blobID, func() (err error) {
+		exists, err = b.blobstore.Exists(blobID)
+		return
+	})
+	if err != nil {
+		return false, bosherr.WrapError(err, "Checking blob existence in inner blobstore")
+	}
+
+	return exists, nil
 }
 
 func (b retryableBlobstore) Validate() error {
-	if b.maxTries < 1 {
-		return bosherr.New("Max tries must be > 0")
+	if b.maxAttempts < 1 {
+		return bosherr.New("Max attempts must be > 0")
+	}
+
+	if b.deadline < 0 {
+		return bosherr.New("Deadline must not be negative")
 	}
 
 	return b.blobstore.Validate()
 }
+
+// retry returns last error when attempts are used up or
+// when waiting for the next attempt would exceed the deadline
+func (b retryableBlobstore) retry(description string, operation func() error) error {
+	startTime := b.timeService.Now()
+	delay := retryInitialDelay
+
+	for attempt := 1; ; attempt++ {
+		attemptStartTime := b.timeService.Now()
+
+		err := operation()
+
+		duration := b.timeService.Now().Sub(attemptStartTime)
+
+		if err == nil {
+			b.logger.Debug(retryableBlobstoreLogTag,
+				"%s: attempt %d of %d succeeded in %s", description, attempt, b.maxAttempts, duration)
+			return nil
+		}
+
+		b.logger.Info(retryableBlobstoreLogTag,
+			"%s: attempt %d of %d failed in %s with error %s", description, attempt, b.maxAttempts, duration, err.Error())
+
+		if !isRetryableError(err) {
+			b.logger.Info(retryableBlobstoreLogTag, "%s: not retrying permanent error", description)
+			return err
+		}
+
+		if attempt >= b.maxAttempts {
+			return err
+		}
+
+		wait := jitterDelay(delay)
+
+		if b.deadline > 0 && b.timeService.Now().Add(wait).Sub(startTime) > b.deadline {
+			b.logger.Info(retryableBlobstoreLogTag, "%s: not retrying since deadline of %s would be exceeded", description, b.deadline)
+			return bosherr.WrapError(err, "Retry deadline of %s exceeded", b.deadline)
+		}
+
+		b.logger.Info(retryableBlobstoreLogTag, "%s: retrying in %s", description, wait)
+
+		b.timeService.Sleep(wait)
+
+		delay *= 2
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+}
+
+// jitterDelay picks delay between half and full given delay
+// so that agents failing at the same time do not retry together
+func jitterDelay(delay time.Duration) time.Duration {
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// isRetryableError treats unknown errors as retryable
+// since e.g. external blobstore failures cannot be classified
+func isRetryableError(err error) bool {
+	cause := bosherr.Cause(err)
+
+	switch typedCause := cause.(type) {
+	case boshcrypto.DigestMismatchError, boshcrypto.DecryptionError, invalidFingerprintError:
+		return false
+
+	case s3StatusError:
+		return isRetryableStatus(typedCause.StatusCode)
+
+	case davclient.ResponseError:
+		return isRetryableStatus(typedCause.StatusCode)
+	}
+
+	if os.IsNotExist(cause) || os.IsPermission(cause) {
+		return false
+	}
+
+	return true
+}
+
+// Server errors, request timeouts and throttling (429) are retried;
+// other statuses such as 401, 403 and 404 are permanent
+func isRetryableStatus(statusCode int) bool {
+	return statusCode >= 500 || statusCode == http.StatusRequestTimeout || statusCode == 429
+}
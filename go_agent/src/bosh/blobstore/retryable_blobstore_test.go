@@ -2,27 +2,36 @@ package blobstore_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	boshblob "bosh/blobstore"
 	fakeblob "bosh/blobstore/fakes"
+	davclient "bosh/davcli/client"
 	bosherr "bosh/errors"
 	boshlog "bosh/logger"
+	boshsettings "bosh/settings"
+	faketime "bosh/time/fakes"
 )
 
 var _ = Describe("retryableBlobstore", func() {
 	var (
 		innerBlobstore     *fakeblob.FakeBlobstore
+		timeService        *faketime.FakeService
 		logger             boshlog.Logger
 		retryableBlobstore boshblob.Blobstore
 	)
 
 	BeforeEach(func() {
 		innerBlobstore = &fakeblob.FakeBlobstore{}
+		timeService = &faketime.FakeService{NowTime: time.Now()}
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		retryableBlobstore = boshblob.NewRetryableBlobstore(innerBlobstore, 3, logger)
+		retryableBlobstore = boshblob.NewRetryableBlobstore(innerBlobstore, boshsettings.BlobstoreRetry{}, timeService, logger)
 	})
 
 	Describe("Get", func() {
@@ -84,6 +93,99 @@ var _ = Describe("retryableBlobstore", func() {
 				))
 			})
 		})
+
+		It("waits with exponential backoff and jitter between attempts", func() {
+			retryableBlobstore = boshblob.NewRetryableBlobstore(
+				innerBlobstore, boshsettings.BlobstoreRetry{MaxAttempts: 4}, timeService, logger)
+
+			innerBlobstore.GetError = errors.New("fake-get-err")
+
+			_, err := retryableBlobstore.Get("fake-blob-id", "fake-fingerprint")
+			Expect(err).To(HaveOccurred())
+
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(4))
+			Expect(timeService.SleepDurations).To(HaveLen(3))
+
+			for i, maxDelay := range []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second} {
+				Expect(timeService.SleepDurations[i]).To(BeNumerically(">=", maxDelay/2))
+				Expect(timeService.SleepDurations[i]).To(BeNumerically("<=", maxDelay))
+			}
+		})
+
+		It("does not wait after the last attempt", func() {
+			innerBlobstore.GetError = errors.New("fake-get-err")
+
+			_, err := retryableBlobstore.Get("fake-blob-id", "fake-fingerprint")
+			Expect(err).To(HaveOccurred())
+
+			Expect(timeService.SleepDurations).To(HaveLen(2))
+		})
+
+		It("does not start attempts after deadline", func() {
+			retryableBlobstore = boshblob.NewRetryableBlobstore(
+				innerBlobstore, boshsettings.BlobstoreRetry{MaxAttempts: 10, DeadlineSeconds: 3}, timeService, logger)
+
+			innerBlobstore.GetError = errors.New("fake-get-err")
+
+			_, err := retryableBlobstore.Get("fake-blob-id", "fake-fingerprint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Retry deadline of 3s exceeded"))
+			Expect(err.Error()).To(ContainSubstring("fake-get-err"))
+
+			// Waits of up to 1s and 2s fit into deadline, another one of at least 2s does not
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(3))
+		})
+
+		It("does not retry when digest does not match", func() {
+			fileName := writeRetryableFixture("fake-content")
+			defer os.Remove(fileName)
+
+			innerBlobstore.GetFileName = fileName
+
+			retryableBlobstore = boshblob.NewRetryableBlobstore(
+				boshblob.NewDigestVerifiableBlobstore(innerBlobstore), boshsettings.BlobstoreRetry{}, timeService, logger)
+
+			_, err := retryableBlobstore.Get("fake-blob-id", "sha1:0000000000000000000000000000000000000000")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("SHA1 mismatch"))
+
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(1))
+			Expect(timeService.SleepDurations).To(BeEmpty())
+		})
+
+		It("does not retry when blob cannot be found", func() {
+			innerBlobstore.GetError = bosherr.WrapError(
+				davclient.ResponseError{StatusCode: http.StatusNotFound}, "fake-wrapped-err")
+
+			_, err := retryableBlobstore.Get("fake-blob-id", "fake-fingerprint")
+			Expect(err).To(HaveOccurred())
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(1))
+		})
+
+		It("does not retry authentication failures", func() {
+			innerBlobstore.GetError = davclient.ResponseError{StatusCode: http.StatusUnauthorized}
+
+			_, err := retryableBlobstore.Get("fake-blob-id", "fake-fingerprint")
+			Expect(err).To(HaveOccurred())
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(1))
+		})
+
+		It("does not retry missing files", func() {
+			_, statErr := os.Stat("/fake-missing-file")
+			innerBlobstore.GetError = bosherr.WrapError(statErr, "fake-wrapped-err")
+
+			_, err := retryableBlobstore.Get("fake-blob-id", "fake-fingerprint")
+			Expect(err).To(HaveOccurred())
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(1))
+		})
+
+		It("retries server errors", func() {
+			innerBlobstore.GetError = davclient.ResponseError{StatusCode: http.StatusServiceUnavailable}
+
+			_, err := retryableBlobstore.Get("fake-blob-id", "fake-fingerprint")
+			Expect(err).To(HaveOccurred())
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(3))
+		})
 	})
 
 	Describe("CleanUp", func() {
@@ -116,24 +218,69 @@ var _ = Describe("retryableBlobstore", func() {
 			Expect(innerBlobstore.CreateFileName).To(Equal("fake-blob-path"))
 		})
 
-		It("returns error if inner blobstore blob creation fails", func() {
+		It("retries and returns last error if inner blobstore blob creation keeps failing", func() {
 			innerBlobstore.CreateErr = errors.New("fake-create-error")
 
 			_, _, err := retryableBlobstore.Create("fake-blob-path")
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-create-error"))
+
+			Expect(timeService.SleepDurations).To(HaveLen(2))
+		})
+	})
+
+	Describe("Delete", func() {
+		It("delegates to inner blobstore to delete blob", func() {
+			err := retryableBlobstore.Delete("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(innerBlobstore.DeleteBlobIDs).To(Equal([]string{"fake-blob-id"}))
+		})
+
+		It("retries and returns last error if inner blobstore deleting keeps failing", func() {
+			innerBlobstore.DeleteErr = errors.New("fake-delete-error")
+
+			err := retryableBlobstore.Delete("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-delete-error"))
+			Expect(len(innerBlobstore.DeleteBlobIDs)).To(Equal(3))
+		})
+	})
+
+	Describe("Exists", func() {
+		It("delegates to inner blobstore to check blob existence", func() {
+			innerBlobstore.ExistsResult = true
+
+			exists, err := retryableBlobstore.Exists("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			Expect(innerBlobstore.ExistsBlobIDs).To(Equal([]string{"fake-blob-id"}))
+		})
+
+		It("retries and returns last error if inner blobstore keeps failing", func() {
+			innerBlobstore.ExistsErr = errors.New("fake-exists-error")
+
+			_, err := retryableBlobstore.Exists("fake-blob-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-exists-error"))
+			Expect(len(innerBlobstore.ExistsBlobIDs)).To(Equal(3))
 		})
 	})
 
 	Describe("Validate", func() {
-		It("returns error if max tries is < 1", func() {
-			err := boshblob.NewRetryableBlobstore(innerBlobstore, -1, logger).Validate()
+		It("returns error if max attempts is < 1", func() {
+			retry := boshsettings.BlobstoreRetry{MaxAttempts: -1}
+
+			err := boshblob.NewRetryableBlobstore(innerBlobstore, retry, timeService, logger).Validate()
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("Max tries must be > 0"))
+			Expect(err.Error()).To(ContainSubstring("Max attempts must be > 0"))
+		})
+
+		It("returns error if deadline is negative", func() {
+			retry := boshsettings.BlobstoreRetry{DeadlineSeconds: -1}
 
-			err = boshblob.NewRetryableBlobstore(innerBlobstore, 0, logger).Validate()
+			err := boshblob.NewRetryableBlobstore(innerBlobstore, retry, timeService, logger).Validate()
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("Max tries must be > 0"))
+			Expect(err.Error()).To(ContainSubstring("Deadline must not be negative"))
 		})
 
 		It("delegates to inner blobstore to validate", func() {
@@ -150,3 +297,15 @@ var _ = Describe("retryableBlobstore", func() {
 		})
 	})
 })
+
+func writeRetryableFixture(content string) string {
+	file, err := ioutil.TempFile("", "retryable-blobstore-test")
+	Expect(err).ToNot(HaveOccurred())
+
+	_, err = file.WriteString(content)
+	Expect(err).ToNot(HaveOccurred())
+
+	file.Close()
+
+	return file.Name()
+}
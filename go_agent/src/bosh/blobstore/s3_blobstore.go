@@ -0,0 +1,526 @@
+package blobstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+	boshtime "bosh/time"
+	boshuuid "bosh/uuid"
+)
+
+const (
+	s3BlobstoreLogTag = "s3Blobstore"
+
+	s3DefaultRegion = "us-east-1"
+
+	// S3 requires all parts but the last one to be at least 5 MiB
+	s3DefaultMultipartPartSize = 5 * 1024 * 1024
+)
+
+// S3Options match options of the external bosh-blobstore-s3 client
+// so that existing settings keep working with the native client
+type S3Options struct {
+	BucketName      string `json:"bucket_name"`
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	Region          string `json:"region"`
+
+	// Defaults to AWS endpoint of the region
+	Host string `json:"host"`
+	Port int    `json:"port"`
+
+	// Both default to true
+	UseSSL        *bool `json:"use_ssl"`
+	SSLVerifyPeer *bool `json:"ssl_verify_peer"`
+
+	// Path-style addressing (host/bucket/key) is needed by most
+	// S3-compatible servers and by bucket names that contain dots
+	ForcePathStyle bool `json:"s3_force_path_style"`
+
+	// AES256 or aws:kms; KMS key id is only used with aws:kms
+	ServerSideEncryption string `json:"server_side_encryption"`
+	SSEKMSKeyID          string `json:"sse_kms_key_id"`
+
+	// Files larger than part size are uploaded in parts
+	MultipartPartSize int64 `json:"multipart_part_size"`
+}
+
+type s3Blobstore struct {
+	options    S3Options
+	optionsErr error
+
+	client      *http.Client
+	signer      S3Signer
+	downloader  parallelDownloader
+	fs          boshsys.FileSystem
+	uuidGen     boshuuid.Generator
+	timeService boshtime.Service
+	logger      boshlog.Logger
+}
+
+func NewS3Blobstore(
+	options map[string]interface{},
+	fs boshsys.FileSystem,
+	uuidGen boshuuid.Generator,
+	timeService boshtime.Service,
+	logger boshlog.Logger,
+) Blobstore {
+	s3Options, err := parseS3Options(options)
+
+	downloadOptions, downloadErr := parseParallelDownloadOptions(options)
+	if err == nil {
+		err = downloadErr
+	}
+
+	return s3Blobstore{
+		options:    s3Options,
+		optionsErr: err,
+
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: s3Options.SSLVerifyPeer != nil && !*s3Options.SSLVerifyPeer,
+				},
+			},
+		},
+
+		signer: S3Signer{
+			AccessKeyID:     s3Options.AccessKeyID,
+			SecretAccessKey: s3Options.SecretAccessKey,
+			Region:          s3Options.Region,
+		},
+
+		downloader: newParallelDownloader(downloadOptions, timeService, logger),
+
+		fs:          fs,
+		uuidGen:     uuidGen,
+		timeService: timeService,
+		logger:      logger,
+	}
+}
+
+func parseS3Options(options map[string]interface{}) (S3Options, error) {
+	var s3Options S3Options
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return s3Options, bosherr.WrapError(err, "Marshalling s3 options")
+	}
+
+	err = json.Unmarshal(optionsJSON, &s3Options)
+	if err != nil {
+		return s3Options, bosherr.WrapError(err, "Unmarshalling s3 options")
+	}
+
+	if s3Options.Region == "" {
+		s3Options.Region = s3DefaultRegion
+	}
+
+	if s3Options.MultipartPartSize == 0 {
+		s3Options.MultipartPartSize = s3DefaultMultipartPartSize
+	}
+
+	if s3Options.BucketName == "" {
+		return s3Options, bosherr.New("missing bucket_name")
+	}
+
+	if (s3Options.AccessKeyID == "") != (s3Options.SecretAccessKey == "") {
+		return s3Options, bosherr.New("access_key_id and secret_access_key must be given together")
+	}
+
+	if s3Options.MultipartPartSize < 0 {
+		return s3Options, bosherr.New("multipart_part_size must be positive")
+	}
+
+	switch s3Options.ServerSideEncryption {
+	case "", "AES256", "aws:kms":
+	default:
+		return s3Options, bosherr.New("server_side_encryption must be AES256 or aws:kms")
+	}
+
+	return s3Options, nil
+}
+
+func (b s3Blobstore) Get(blobID, _ string) (string, error) {
+	file, err := b.fs.TempFile("bosh-blobstore-s3Blobstore-Get")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file")
+	}
+
+	fileName := file.Name()
+
+	err = b.download(blobID, file)
+	file.Close()
+
+	if err != nil {
+		b.fs.RemoveAll(fileName)
+		return "", bosherr.WrapError(err, "Downloading blob %s", blobID)
+	}
+
+	return fileName, nil
+}
+
+func (b s3Blobstore) CleanUp(fileName string) error {
+	return b.fs.RemoveAll(fileName)
+}
+
+func (b s3Blobstore) Create(fileName string) (string, string, error) {
+	blobID, err := b.uuidGen.Generate()
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Generating blobID")
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Opening file for upload")
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Getting file size")
+	}
+
+	if info.Size() > b.options.MultipartPartSize {
+		err = b.uploadMultipart(blobID, file, info.Size())
+	} else {
+		err = b.upload(blobID, file, info.Size())
+	}
+
+	if err != nil {
+		return "", "", bosherr.WrapError(err, "Uploading blob %s", blobID)
+	}
+
+	return blobID, "", nil
+}
+
+// Delete succeeds for missing blobs since S3 does not report them
+func (b s3Blobstore) Delete(blobID string) error {
+	resp, err := b.do("DELETE", blobID, url.Values{}, nil, 0, S3EmptyPayloadHash, nil)
+	if err != nil {
+		return bosherr.WrapError(err, "Deleting blob %s", blobID)
+	}
+
+	return resp.Body.Close()
+}
+
+func (b s3Blobstore) Exists(blobID string) (bool, error) {
+	resp, err := b.do("HEAD", blobID, url.Values{}, nil, 0, S3EmptyPayloadHash, nil)
+	if err != nil {
+		if statusErr, ok := err.(s3StatusError); ok && statusErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+
+		return false, bosherr.WrapError(err, "Checking existence of blob %s", blobID)
+	}
+
+	resp.Body.Close()
+
+	return true, nil
+}
+
+func (b s3Blobstore) Validate() error {
+	if b.optionsErr != nil {
+		return bosherr.WrapError(b.optionsErr, "Validating s3 options")
+	}
+
+	return nil
+}
+
+func (b s3Blobstore) download(blobID string, file *os.File) error {
+	return b.downloader.Download(blobID, file, func(start, end int64) (*http.Response, error) {
+		headers := http.Header{"Range": []string{fmt.Sprintf("bytes=%d-%d", start, end)}}
+		return b.do("GET", blobID, url.Values{}, nil, 0, S3EmptyPayloadHash, headers)
+	})
+}
+
+func (b s3Blobstore) upload(blobID string, file *os.File, size int64) error {
+	section := io.NewSectionReader(file, 0, size)
+
+	payloadHash, err := s3PayloadHash(section)
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.do("PUT", blobID, url.Values{}, io.NewSectionReader(file, 0, size), size, payloadHash, b.encryptionHeaders())
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+type s3InitiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+func (b s3Blobstore) uploadMultipart(blobID string, file *os.File, size int64) error {
+	resp, err := b.do("POST", blobID, url.Values{"uploads": []string{""}}, nil, 0, S3EmptyPayloadHash, b.encryptionHeaders())
+	if err != nil {
+		return bosherr.WrapError(err, "Initiating multipart upload")
+	}
+
+	var initiateResult s3InitiateMultipartUploadResult
+
+	err = xml.NewDecoder(resp.Body).Decode(&initiateResult)
+	resp.Body.Close()
+
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling initiate multipart upload result")
+	}
+
+	uploadID := initiateResult.UploadID
+
+	err = b.uploadParts(blobID, uploadID, file, size)
+	if err != nil {
+		// Uploaded parts are billed until the upload is aborted
+		abortErr := b.abortMultipart(blobID, uploadID)
+		if abortErr != nil {
+			b.logger.Error(s3BlobstoreLogTag, "Failed to abort multipart upload %s: %s", uploadID, abortErr.Error())
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (b s3Blobstore) uploadParts(blobID, uploadID string, file *os.File, size int64) error {
+	complete := s3CompleteMultipartUpload{}
+
+	for offset, partNumber := int64(0), 1; offset < size; offset, partNumber = offset+b.options.MultipartPartSize, partNumber+1 {
+		partSize := b.options.MultipartPartSize
+		if offset+partSize > size {
+			partSize = size - offset
+		}
+
+		payloadHash, err := s3PayloadHash(io.NewSectionReader(file, offset, partSize))
+		if err != nil {
+			return err
+		}
+
+		query := url.Values{
+			"partNumber": []string{strconv.Itoa(partNumber)},
+			"uploadId":   []string{uploadID},
+		}
+
+		b.logger.Debug(s3BlobstoreLogTag, "Uploading part %d of blob %s", partNumber, blobID)
+
+		resp, err := b.do("PUT", blobID, query, io.NewSectionReader(file, offset, partSize), partSize, payloadHash, nil)
+		if err != nil {
+			return bosherr.WrapError(err, "Uploading part %d", partNumber)
+		}
+
+		resp.Body.Close()
+
+		complete.Parts = append(complete.Parts, s3CompletedPart{
+			PartNumber: partNumber,
+			ETag:       resp.Header.Get("ETag"),
+		})
+	}
+
+	completeXML, err := xml.Marshal(complete)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling complete multipart upload")
+	}
+
+	query := url.Values{"uploadId": []string{uploadID}}
+
+	resp, err := b.do("POST", blobID, query, bytes.NewReader(completeXML), int64(len(completeXML)), hex.EncodeToString(s3SHA256(completeXML)), nil)
+	if err != nil {
+		return bosherr.WrapError(err, "Completing multipart upload")
+	}
+
+	defer resp.Body.Close()
+
+	// Completion may fail after 200 OK was already sent
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading complete multipart upload result")
+	}
+
+	return s3ErrorFromBody(body)
+}
+
+func (b s3Blobstore) abortMultipart(blobID, uploadID string) error {
+	query := url.Values{"uploadId": []string{uploadID}}
+
+	resp, err := b.do("DELETE", blobID, query, nil, 0, S3EmptyPayloadHash, nil)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func (b s3Blobstore) encryptionHeaders() http.Header {
+	headers := http.Header{}
+
+	if b.options.ServerSideEncryption != "" {
+		headers.Set("x-amz-server-side-encryption", b.options.ServerSideEncryption)
+	}
+
+	if b.options.ServerSideEncryption == "aws:kms" && b.options.SSEKMSKeyID != "" {
+		headers.Set("x-amz-server-side-encryption-aws-kms-key-id", b.options.SSEKMSKeyID)
+	}
+
+	return headers
+}
+
+// do returns an error for non-2xx responses; caller must close response body
+func (b s3Blobstore) do(
+	method, blobID string,
+	query url.Values,
+	body io.Reader,
+	contentLength int64,
+	payloadHash string,
+	headers http.Header,
+) (*http.Response, error) {
+	if b.optionsErr != nil {
+		return nil, bosherr.WrapError(b.optionsErr, "Validating s3 options")
+	}
+
+	// Non-nil body without length would be sent chunked which S3 rejects
+	if contentLength == 0 {
+		body = nil
+	}
+
+	req, err := http.NewRequest(method, b.objectURL(blobID, query), body)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Building %s request", method)
+	}
+
+	req.ContentLength = contentLength
+
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+
+	b.signer.Sign(req, payloadHash, b.timeService.Now())
+
+	b.logger.Debug(s3BlobstoreLogTag, "Sending %s %s", method, req.URL.String())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Sending %s request", method)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+
+		body, _ := ioutil.ReadAll(resp.Body)
+
+		err = s3ErrorFromBody(body)
+		if err == nil {
+			err = bosherr.New("Unexpected response status %d", resp.StatusCode)
+		}
+
+		return nil, s3StatusError{
+			StatusCode: resp.StatusCode,
+			err:        bosherr.WrapError(err, "Sending %s request", method),
+		}
+	}
+
+	return resp, nil
+}
+
+func (b s3Blobstore) objectURL(blobID string, query url.Values) string {
+	scheme := "https"
+	if b.options.UseSSL != nil && !*b.options.UseSSL {
+		scheme = "http"
+	}
+
+	host := b.options.Host
+	if host == "" {
+		host = s3RegionHost(b.options.Region)
+	}
+
+	if b.options.Port > 0 {
+		host = fmt.Sprintf("%s:%d", host, b.options.Port)
+	}
+
+	u := url.URL{Scheme: scheme, Host: host, Path: "/" + blobID}
+
+	if b.options.ForcePathStyle {
+		u.Path = "/" + b.options.BucketName + "/" + blobID
+	} else {
+		u.Host = b.options.BucketName + "." + host
+	}
+
+	// S3 accepts 'uploads=' in place of 'uploads'
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+func s3RegionHost(region string) string {
+	if region == s3DefaultRegion {
+		return "s3.amazonaws.com"
+	}
+	return fmt.Sprintf("s3.%s.amazonaws.com", region)
+}
+
+// s3StatusError allows checking status of unsuccessful responses
+type s3StatusError struct {
+	StatusCode int
+	err        error
+}
+
+func (e s3StatusError) Error() string {
+	return e.err.Error()
+}
+
+type s3ErrorResponse struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+// s3ErrorFromBody returns nil when body is not an S3 error document
+func s3ErrorFromBody(body []byte) error {
+	var errResp s3ErrorResponse
+
+	err := xml.Unmarshal(body, &errResp)
+	if err != nil || errResp.Code == "" {
+		return nil
+	}
+
+	return bosherr.New("S3 error %s: %s", errResp.Code, errResp.Message)
+}
+
+func s3PayloadHash(r io.Reader) (string, error) {
+	h := sha256.New()
+
+	_, err := io.Copy(h, r)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Calculating payload hash")
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
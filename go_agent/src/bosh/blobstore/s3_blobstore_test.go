@@ -0,0 +1,249 @@
+package blobstore_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/blobstore"
+	fakeblob "bosh/blobstore/fakes"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+	faketime "bosh/time/fakes"
+	fakeuuid "bosh/uuid/fakes"
+)
+
+var _ = Describe("s3Blobstore", func() {
+	var (
+		server    *fakeblob.FakeS3Server
+		fs        boshsys.FileSystem
+		uuidGen   *fakeuuid.FakeGenerator
+		options   map[string]interface{}
+		blobstore Blobstore
+	)
+
+	buildBlobstore := func() Blobstore {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		timeService := &faketime.FakeService{NowTime: time.Now()}
+		return NewS3Blobstore(options, fs, uuidGen, timeService, logger)
+	}
+
+	writeTempFile := func(content string) string {
+		file, err := ioutil.TempFile("", "s3-blobstore-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		defer file.Close()
+
+		_, err = file.WriteString(content)
+		Expect(err).ToNot(HaveOccurred())
+
+		return file.Name()
+	}
+
+	BeforeEach(func() {
+		server = fakeblob.NewFakeS3Server()
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+		uuidGen = &fakeuuid.FakeGenerator{GeneratedUuid: "fake-blob-id"}
+
+		hostAndPort := strings.Split(server.Host(), ":")
+		port, err := strconv.Atoi(hostAndPort[1])
+		Expect(err).ToNot(HaveOccurred())
+
+		options = map[string]interface{}{
+			"bucket_name":         "fake-bucket",
+			"access_key_id":       "fake-access-key-id",
+			"secret_access_key":   "fake-secret-access-key",
+			"host":                hostAndPort[0],
+			"port":                port,
+			"use_ssl":             false,
+			"s3_force_path_style": true,
+			"multipart_part_size": 10,
+		}
+
+		blobstore = buildBlobstore()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Validate", func() {
+		It("returns no error when options are valid", func() {
+			Expect(blobstore.Validate()).ToNot(HaveOccurred())
+		})
+
+		It("returns error when bucket name is missing", func() {
+			delete(options, "bucket_name")
+
+			err := buildBlobstore().Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("missing bucket_name"))
+		})
+
+		It("returns error when only one of the credentials is given", func() {
+			delete(options, "secret_access_key")
+
+			err := buildBlobstore().Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("access_key_id and secret_access_key must be given together"))
+		})
+
+		It("returns error when server side encryption is unknown", func() {
+			options["server_side_encryption"] = "fake-encryption"
+
+			err := buildBlobstore().Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("server_side_encryption must be AES256 or aws:kms"))
+		})
+	})
+
+	Describe("Create", func() {
+		It("uploads small file with single signed request", func() {
+			fileName := writeTempFile("fake-content")
+			defer os.Remove(fileName)
+
+			options["multipart_part_size"] = 100
+
+			blobID, fingerprint, err := buildBlobstore().Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+			Expect(fingerprint).To(BeEmpty())
+
+			Expect(string(server.Objects["/fake-bucket/fake-blob-id"])).To(Equal("fake-content"))
+			Expect(len(server.Requests)).To(Equal(1))
+			Expect(server.Requests[0].Method).To(Equal("PUT"))
+			Expect(server.Requests[0].Header.Get("Authorization")).To(ContainSubstring("Credential=fake-access-key-id/"))
+		})
+
+		It("uploads empty file", func() {
+			fileName := writeTempFile("")
+			defer os.Remove(fileName)
+
+			_, _, err := blobstore.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(server.Objects["/fake-bucket/fake-blob-id"]).To(BeEmpty())
+		})
+
+		It("uploads large file in parts", func() {
+			fileName := writeTempFile("0123456789abcdefghij-tail")
+			defer os.Remove(fileName)
+
+			_, _, err := blobstore.Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(server.Objects["/fake-bucket/fake-blob-id"])).To(Equal("0123456789abcdefghij-tail"))
+			Expect(server.Completed).To(Equal([]string{"fake-upload-id-1"}))
+
+			Expect(len(server.Requests)).To(Equal(5))
+			Expect(server.Requests[0].Query).To(Equal("uploads="))
+			Expect(server.Requests[1].Query).To(Equal("partNumber=1&uploadId=fake-upload-id-1"))
+			Expect(server.Requests[3].Query).To(Equal("partNumber=3&uploadId=fake-upload-id-1"))
+			Expect(server.Requests[4].Query).To(Equal("uploadId=fake-upload-id-1"))
+		})
+
+		It("aborts multipart upload when uploading a part fails", func() {
+			fileName := writeTempFile("0123456789abcdefghij-tail")
+			defer os.Remove(fileName)
+
+			server.FailMethod = "PUT"
+			server.FailStatus = 500
+			server.FailCode = "InternalError"
+
+			_, _, err := blobstore.Create(fileName)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("S3 error InternalError"))
+
+			Expect(server.Aborted).To(Equal([]string{"fake-upload-id-1"}))
+			Expect(server.Objects).To(BeEmpty())
+		})
+
+		It("requests server side encryption", func() {
+			fileName := writeTempFile("fake-content")
+			defer os.Remove(fileName)
+
+			options["multipart_part_size"] = 100
+			options["server_side_encryption"] = "aws:kms"
+			options["sse_kms_key_id"] = "fake-kms-key-id"
+
+			_, _, err := buildBlobstore().Create(fileName)
+			Expect(err).ToNot(HaveOccurred())
+
+			header := server.Requests[0].Header
+			Expect(header.Get("x-amz-server-side-encryption")).To(Equal("aws:kms"))
+			Expect(header.Get("x-amz-server-side-encryption-aws-kms-key-id")).To(Equal("fake-kms-key-id"))
+			Expect(header.Get("Authorization")).To(ContainSubstring("x-amz-server-side-encryption"))
+		})
+	})
+
+	Describe("Get", func() {
+		It("downloads blob into temporary file", func() {
+			server.Objects["/fake-bucket/fake-blob-id"] = []byte("fake-content")
+
+			fileName, err := blobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer blobstore.CleanUp(fileName)
+
+			content, err := ioutil.ReadFile(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("fake-content"))
+		})
+
+		It("downloads large blob as byte ranges", func() {
+			options["download_part_size"] = 5
+			server.Objects["/fake-bucket/fake-blob-id"] = []byte("fake-large-content")
+
+			fileName, err := buildBlobstore().Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer os.Remove(fileName)
+
+			content, err := ioutil.ReadFile(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("fake-large-content"))
+
+			ranges := []string{}
+			for _, request := range server.Requests {
+				ranges = append(ranges, request.Header.Get("Range"))
+			}
+			Expect(ranges).To(ConsistOf("bytes=0-4", "bytes=5-9", "bytes=10-14", "bytes=15-17"))
+		})
+
+		It("downloads empty blob", func() {
+			server.Objects["/fake-bucket/fake-blob-id"] = []byte{}
+
+			fileName, err := blobstore.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer os.Remove(fileName)
+
+			content, err := ioutil.ReadFile(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(BeEmpty())
+		})
+
+		It("returns error with S3 error code when blob is not found", func() {
+			_, err := blobstore.Get("fake-missing-blob-id", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("S3 error NoSuchKey"))
+		})
+
+		It("sends anonymous requests when credentials are not given", func() {
+			delete(options, "access_key_id")
+			delete(options, "secret_access_key")
+			server.Objects["/fake-bucket/fake-blob-id"] = []byte("fake-content")
+
+			fileName, err := buildBlobstore().Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer os.Remove(fileName)
+
+			Expect(server.Requests[0].Header.Get("Authorization")).To(BeEmpty())
+		})
+	})
+})
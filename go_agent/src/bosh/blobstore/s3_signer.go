@@ -0,0 +1,176 @@
+package blobstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3SigningAlgorithm = "AWS4-HMAC-SHA256"
+	s3DateFormat       = "20060102"
+	s3TimeFormat       = "20060102T150405Z"
+
+	// S3EmptyPayloadHash is SHA256 of an empty request body
+	S3EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Signer signs requests with AWS Signature Version 4.
+// Requests are left unsigned when no credentials are given
+// so that public buckets can be read anonymously.
+type S3Signer struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	Region          string
+}
+
+// Sign adds x-amz-date, x-amz-content-sha256 and Authorization headers;
+// all other headers already set on the request are signed as well.
+func (s S3Signer) Sign(req *http.Request, payloadHash string, now time.Time) {
+	if s.AccessKeyID == "" {
+		return
+	}
+
+	now = now.UTC()
+
+	req.Header.Set("x-amz-date", now.Format(s3TimeFormat))
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders, canonicalHeaders := s3CanonicalHeaders(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3CanonicalURI(req.URL),
+		s3CanonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(s3DateFormat), s.Region, "s3", "aws4_request"}, "/")
+
+	stringToSign := strings.Join([]string{
+		s3SigningAlgorithm,
+		now.Format(s3TimeFormat),
+		scope,
+		hex.EncodeToString(s3SHA256([]byte(canonicalRequest))),
+	}, "\n")
+
+	signingKey := s3HMAC([]byte("AWS4"+s.SecretAccessKey), now.Format(s3DateFormat))
+	signingKey = s3HMAC(signingKey, s.Region)
+	signingKey = s3HMAC(signingKey, "s3")
+	signingKey = s3HMAC(signingKey, "aws4_request")
+
+	signature := hex.EncodeToString(s3HMAC(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningAlgorithm, s.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func s3CanonicalHeaders(req *http.Request) (string, string) {
+	values := map[string]string{"host": req.URL.Host}
+	if req.Host != "" {
+		values["host"] = req.Host
+	}
+
+	for name, headerValues := range req.Header {
+		name = strings.ToLower(name)
+		if name == "authorization" || name == "user-agent" {
+			continue
+		}
+
+		trimmed := []string{}
+		for _, value := range headerValues {
+			trimmed = append(trimmed, strings.Join(strings.Fields(value), " "))
+		}
+
+		values[name] = strings.Join(trimmed, ",")
+	}
+
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	lines := []string{}
+	for _, name := range names {
+		lines = append(lines, name+":"+values[name]+"\n")
+	}
+
+	return strings.Join(names, ";"), strings.Join(lines, "")
+}
+
+func s3CanonicalURI(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func s3CanonicalQuery(u *url.URL) string {
+	query := u.Query()
+
+	names := []string{}
+	for name := range query {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	pairs := []string{}
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(name)+"="+s3Escape(value))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape percent-encodes everything except RFC 3986 unreserved characters
+func s3Escape(s string) string {
+	escaped := ""
+
+	for _, b := range []byte(s) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' {
+			escaped += string(b)
+		} else {
+			escaped += fmt.Sprintf("%%%02X", b)
+		}
+	}
+
+	return escaped
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func s3SHA256(data []byte) []byte {
+	h := sha256.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
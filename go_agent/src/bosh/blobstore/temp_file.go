@@ -0,0 +1,28 @@
+package blobstore
+
+import (
+	"os"
+
+	bosherr "bosh/errors"
+	boshsys "bosh/system"
+)
+
+// openTempFile opens new temporary file for writing through file system
+// so that its contents are seen by everything else using file system
+func openTempFile(fs boshsys.FileSystem, prefix string) (boshsys.File, string, error) {
+	tempFile, err := fs.TempFile(prefix)
+	if err != nil {
+		return nil, "", bosherr.WrapError(err, "Creating temporary file")
+	}
+
+	fileName := tempFile.Name()
+	tempFile.Close()
+
+	file, err := fs.OpenFile(fileName, os.O_WRONLY|os.O_TRUNC, os.FileMode(0600))
+	if err != nil {
+		fs.RemoveAll(fileName)
+		return nil, "", bosherr.WrapError(err, "Opening temporary file")
+	}
+
+	return file, fileName, nil
+}
@@ -0,0 +1,209 @@
+package crypto
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	bosherr "bosh/errors"
+)
+
+const (
+	DigestAlgorithmSHA1   = "sha1"
+	DigestAlgorithmSHA256 = "sha256"
+	DigestAlgorithmSHA512 = "sha512"
+)
+
+// Ordered from weakest to strongest
+var digestAlgorithms = []string{
+	DigestAlgorithmSHA1,
+	DigestAlgorithmSHA256,
+	DigestAlgorithmSHA512,
+}
+
+var digestHashes = map[string]func() hash.Hash{
+	DigestAlgorithmSHA1:   sha1.New,
+	DigestAlgorithmSHA256: sha256.New,
+	DigestAlgorithmSHA512: sha512.New,
+}
+
+// DefaultDigestAlgorithms are computed for uploaded blobs;
+// SHA1 is included for directors that do not understand other algorithms
+var DefaultDigestAlgorithms = []string{DigestAlgorithmSHA1, DigestAlgorithmSHA256}
+
+type Digest struct {
+	Algorithm string
+
+	// Lower case hex encoded
+	Value string
+}
+
+func (d Digest) String() string {
+	return d.Algorithm + ":" + d.Value
+}
+
+// MultipleDigest describes content with one or more digests
+// e.g. "sha1:<hex>;sha256:<hex>"
+type MultipleDigest []Digest
+
+// ParseMultipleDigest accepts "<algorithm>:<hex>" digests separated by ";".
+// Digest without algorithm is SHA1 to stay compatible with plain SHA1 fingerprints.
+// Digests of unknown algorithms are ignored as long as one supported digest is given.
+func ParseMultipleDigest(fingerprint string) (MultipleDigest, error) {
+	digests := MultipleDigest{}
+
+	for _, piece := range strings.Split(fingerprint, ";") {
+		piece = strings.TrimSpace(piece)
+		if piece == "" {
+			continue
+		}
+
+		algorithm := DigestAlgorithmSHA1
+		value := piece
+
+		if i := strings.Index(piece, ":"); i >= 0 {
+			algorithm = strings.ToLower(piece[:i])
+			value = piece[i+1:]
+		}
+
+		newHash, found := digestHashes[algorithm]
+		if !found {
+			continue
+		}
+
+		value = strings.ToLower(value)
+
+		decoded, err := hex.DecodeString(value)
+		if err != nil || len(decoded) != newHash().Size() {
+			return nil, bosherr.New("Invalid %s digest '%s'", algorithm, value)
+		}
+
+		digests = append(digests, Digest{Algorithm: algorithm, Value: value})
+	}
+
+	if len(digests) == 0 {
+		return nil, bosherr.New("No supported digest found in '%s'", fingerprint)
+	}
+
+	return digests, nil
+}
+
+// NewMultipleDigest reads content once to compute digests of given algorithms
+func NewMultipleDigest(content io.Reader, algorithms ...string) (MultipleDigest, error) {
+	hashes := []hash.Hash{}
+	writers := []io.Writer{}
+
+	for _, algorithm := range algorithms {
+		newHash, found := digestHashes[algorithm]
+		if !found {
+			return nil, bosherr.New("Unsupported digest algorithm '%s'", algorithm)
+		}
+
+		h := newHash()
+		hashes = append(hashes, h)
+		writers = append(writers, h)
+	}
+
+	_, err := io.Copy(io.MultiWriter(writers...), content)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Calculating digest")
+	}
+
+	digests := MultipleDigest{}
+
+	for i, algorithm := range algorithms {
+		digests = append(digests, Digest{
+			Algorithm: algorithm,
+			Value:     fmt.Sprintf("%x", hashes[i].Sum(nil)),
+		})
+	}
+
+	return digests, nil
+}
+
+func NewMultipleDigestFromFile(fileName string, algorithms ...string) (MultipleDigest, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Opening file for digest calculation")
+	}
+
+	defer file.Close()
+
+	return NewMultipleDigest(file, algorithms...)
+}
+
+func (m MultipleDigest) String() string {
+	pieces := []string{}
+
+	for _, digest := range m {
+		pieces = append(pieces, digest.String())
+	}
+
+	return strings.Join(pieces, ";")
+}
+
+// Strongest returns digest of the strongest algorithm;
+// multiple digest must not be empty
+func (m MultipleDigest) Strongest() Digest {
+	strongest := m[0]
+
+	for _, digest := range m[1:] {
+		if digestStrength(digest.Algorithm) > digestStrength(strongest.Algorithm) {
+			strongest = digest
+		}
+	}
+
+	return strongest
+}
+
+func (m MultipleDigest) Digest(algorithm string) (Digest, bool) {
+	for _, digest := range m {
+		if digest.Algorithm == algorithm {
+			return digest, true
+		}
+	}
+
+	return Digest{}, false
+}
+
+// VerifyFile checks file against the strongest digest
+func (m MultipleDigest) VerifyFile(fileName string) error {
+	expected := m.Strongest()
+
+	actual, err := NewMultipleDigestFromFile(fileName, expected.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	if actual[0] != expected {
+		return DigestMismatchError{Expected: expected, Actual: actual[0], FileName: fileName}
+	}
+
+	return nil
+}
+
+type DigestMismatchError struct {
+	Expected Digest
+	Actual   Digest
+	FileName string
+}
+
+func (e DigestMismatchError) Error() string {
+	return fmt.Sprintf("%s mismatch. Expected %s, got %s for blob %s",
+		strings.ToUpper(e.Expected.Algorithm), e.Expected.Value, e.Actual.Value, e.FileName)
+}
+
+func digestStrength(algorithm string) int {
+	for i, a := range digestAlgorithms {
+		if a == algorithm {
+			return i
+		}
+	}
+	return -1
+}
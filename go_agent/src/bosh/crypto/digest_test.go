@@ -0,0 +1,147 @@
+package crypto_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/crypto"
+)
+
+var _ = Describe("MultipleDigest", func() {
+	const (
+		// Digests of "fake-content"
+		contentSHA1   = "50fe6e45709c690c0737343ecd613813d8dd2d53"
+		contentSHA256 = "9c87681ea7ba17d350f3cb62894935d8f77c0aacc678966d51638d584a6eaee0"
+		contentSHA512 = "7cce9a3c1347ac61621d7a4a37013fc92d114c28a3bffed4c84a2916d9e0a43d8f39a7dbac9dc396e1b36c57146ccef580809d06521c9006a6f65bd109ff07d2"
+	)
+
+	Describe("ParseMultipleDigest", func() {
+		It("parses plain SHA1", func() {
+			digests, err := ParseMultipleDigest(contentSHA1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digests).To(Equal(MultipleDigest{
+				{Algorithm: DigestAlgorithmSHA1, Value: contentSHA1},
+			}))
+		})
+
+		It("parses digest with algorithm", func() {
+			digests, err := ParseMultipleDigest("sha256:" + contentSHA256)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digests).To(Equal(MultipleDigest{
+				{Algorithm: DigestAlgorithmSHA256, Value: contentSHA256},
+			}))
+		})
+
+		It("parses several digests and lower cases them", func() {
+			digests, err := ParseMultipleDigest("sha1:" + contentSHA1 + "; SHA512:" + strings.ToUpper(contentSHA512))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digests).To(Equal(MultipleDigest{
+				{Algorithm: DigestAlgorithmSHA1, Value: contentSHA1},
+				{Algorithm: DigestAlgorithmSHA512, Value: contentSHA512},
+			}))
+		})
+
+		It("ignores digests of unknown algorithms", func() {
+			digests, err := ParseMultipleDigest("md5:fake-md5;sha256:" + contentSHA256)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digests).To(HaveLen(1))
+		})
+
+		It("returns error when no supported digest is given", func() {
+			_, err := ParseMultipleDigest("md5:fake-md5")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No supported digest found in 'md5:fake-md5'"))
+		})
+
+		It("returns error when digest does not match algorithm", func() {
+			_, err := ParseMultipleDigest("sha256:" + contentSHA1)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid sha256 digest"))
+
+			_, err = ParseMultipleDigest("fake-sha1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid sha1 digest 'fake-sha1'"))
+		})
+	})
+
+	Describe("NewMultipleDigest", func() {
+		It("computes digests of all algorithms", func() {
+			digests, err := NewMultipleDigest(strings.NewReader("fake-content"),
+				DigestAlgorithmSHA1, DigestAlgorithmSHA256, DigestAlgorithmSHA512)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digests.String()).To(Equal(
+				"sha1:" + contentSHA1 + ";sha256:" + contentSHA256 + ";sha512:" + contentSHA512))
+		})
+
+		It("returns error for unknown algorithm", func() {
+			_, err := NewMultipleDigest(strings.NewReader("fake-content"), "md5")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unsupported digest algorithm 'md5'"))
+		})
+	})
+
+	Describe("Strongest", func() {
+		It("returns digest of the strongest algorithm", func() {
+			digests := MultipleDigest{
+				{Algorithm: DigestAlgorithmSHA256, Value: contentSHA256},
+				{Algorithm: DigestAlgorithmSHA512, Value: contentSHA512},
+				{Algorithm: DigestAlgorithmSHA1, Value: contentSHA1},
+			}
+			Expect(digests.Strongest()).To(Equal(Digest{Algorithm: DigestAlgorithmSHA512, Value: contentSHA512}))
+		})
+	})
+
+	Describe("Digest", func() {
+		It("returns digest of given algorithm", func() {
+			digests := MultipleDigest{{Algorithm: DigestAlgorithmSHA1, Value: contentSHA1}}
+
+			digest, found := digests.Digest(DigestAlgorithmSHA1)
+			Expect(found).To(BeTrue())
+			Expect(digest.Value).To(Equal(contentSHA1))
+
+			_, found = digests.Digest(DigestAlgorithmSHA256)
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("VerifyFile", func() {
+		var fileName string
+
+		BeforeEach(func() {
+			file, err := ioutil.TempFile("", "digest-test")
+			Expect(err).ToNot(HaveOccurred())
+
+			file.WriteString("fake-content")
+			file.Close()
+
+			fileName = file.Name()
+		})
+
+		AfterEach(func() {
+			os.Remove(fileName)
+		})
+
+		It("verifies the strongest digest", func() {
+			// SHA1 is not checked since SHA256 is stronger
+			digests := MultipleDigest{
+				{Algorithm: DigestAlgorithmSHA1, Value: "0000000000000000000000000000000000000000"},
+				{Algorithm: DigestAlgorithmSHA256, Value: contentSHA256},
+			}
+			Expect(digests.VerifyFile(fileName)).ToNot(HaveOccurred())
+		})
+
+		It("returns mismatch error", func() {
+			digests := MultipleDigest{{Algorithm: DigestAlgorithmSHA256, Value: strings.Repeat("0", 64)}}
+
+			err := digests.VerifyFile(fileName)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(DigestMismatchError{}))
+			Expect(err.Error()).To(Equal("SHA256 mismatch. Expected " + strings.Repeat("0", 64) +
+				", got " + contentSHA256 + " for blob " + fileName))
+		})
+	})
+})
@@ -0,0 +1,221 @@
+package crypto
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	bosherr "bosh/errors"
+	boshsettings "bosh/settings"
+)
+
+// Encrypted content starts with a header
+//
+//	"BOSHENC1" | key id length (1 byte) | key id | nonce prefix (8 bytes)
+//
+// followed by AES-GCM sealed chunks of up to 64 KiB of content. Nonce of each
+// chunk is the nonce prefix followed by big endian chunk index. Header and a flag
+// marking the last chunk are authenticated with every chunk so that chunks cannot
+// be reordered, dropped or moved between blobs. Last chunk is always shorter than
+// chunk size and may be empty.
+const (
+	encryptionMagic           = "BOSHENC1"
+	encryptionChunkSize       = 64 * 1024
+	encryptionNoncePrefixSize = 8
+	encryptionMaxKeyIDLength  = math.MaxUint8
+)
+
+// BlobCipher encrypts content with the current key
+// and decrypts content encrypted with any of its keys
+type BlobCipher struct {
+	keys  map[string]cipher.AEAD
+	keyID string
+}
+
+func NewBlobCipher(encryption boshsettings.BlobstoreEncryption) (BlobCipher, error) {
+	keys := map[string]cipher.AEAD{}
+
+	for keyID, encodedKey := range encryption.Keys {
+		if len(keyID) == 0 || len(keyID) > encryptionMaxKeyIDLength {
+			return BlobCipher{}, bosherr.New("Encryption key id '%s' must be 1 to %d bytes long", keyID, encryptionMaxKeyIDLength)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return BlobCipher{}, bosherr.WrapError(err, "Decoding encryption key '%s'", keyID)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return BlobCipher{}, bosherr.WrapError(err, "Building AES cipher with key '%s'", keyID)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return BlobCipher{}, bosherr.WrapError(err, "Building AES-GCM cipher with key '%s'", keyID)
+		}
+
+		keys[keyID] = aead
+	}
+
+	if _, found := keys[encryption.KeyID]; !found {
+		return BlobCipher{}, bosherr.New("Encryption key id '%s' is not one of given keys", encryption.KeyID)
+	}
+
+	return BlobCipher{keys: keys, keyID: encryption.KeyID}, nil
+}
+
+// IsEncrypted checks content for encryption header without consuming it
+func IsEncrypted(reader *bufio.Reader) bool {
+	magic, err := reader.Peek(len(encryptionMagic))
+	return err == nil && string(magic) == encryptionMagic
+}
+
+func (c BlobCipher) Encrypt(dst io.Writer, src io.Reader) error {
+	aead := c.keys[c.keyID]
+
+	noncePrefix := make([]byte, encryptionNoncePrefixSize)
+
+	_, err := io.ReadFull(rand.Reader, noncePrefix)
+	if err != nil {
+		return bosherr.WrapError(err, "Generating nonce")
+	}
+
+	header := []byte(encryptionMagic)
+	header = append(header, byte(len(c.keyID)))
+	header = append(header, c.keyID...)
+	header = append(header, noncePrefix...)
+
+	_, err = dst.Write(header)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing encryption header")
+	}
+
+	chunk := make([]byte, encryptionChunkSize)
+
+	for index := uint32(0); ; index++ {
+		n, err := io.ReadFull(src, chunk)
+
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return bosherr.WrapError(err, "Reading content to encrypt")
+		}
+
+		if !last && index == math.MaxUint32 {
+			return bosherr.New("Content is too large to encrypt")
+		}
+
+		sealedChunk := aead.Seal(nil, chunkNonce(noncePrefix, index), chunk[:n], chunkAdditionalData(header, last))
+
+		_, err = dst.Write(sealedChunk)
+		if err != nil {
+			return bosherr.WrapError(err, "Writing encrypted content")
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// Decrypt expects content that starts with encryption header
+func (c BlobCipher) Decrypt(dst io.Writer, src io.Reader) error {
+	keyID, noncePrefix, header, err := readEncryptionHeader(src)
+	if err != nil {
+		return err
+	}
+
+	aead, found := c.keys[keyID]
+	if !found {
+		return DecryptionError{KeyID: keyID, Reason: "key is unknown"}
+	}
+
+	sealedChunk := make([]byte, encryptionChunkSize+aead.Overhead())
+
+	for index := uint32(0); ; index++ {
+		n, err := io.ReadFull(src, sealedChunk)
+
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return bosherr.WrapError(err, "Reading encrypted content")
+		}
+
+		if last && n < aead.Overhead() {
+			return DecryptionError{KeyID: keyID, Reason: "content is truncated"}
+		}
+
+		chunk, err := aead.Open(nil, chunkNonce(noncePrefix, index), sealedChunk[:n], chunkAdditionalData(header, last))
+		if err != nil {
+			return DecryptionError{KeyID: keyID, Reason: "content failed authentication"}
+		}
+
+		_, err = dst.Write(chunk)
+		if err != nil {
+			return bosherr.WrapError(err, "Writing decrypted content")
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+func readEncryptionHeader(src io.Reader) (string, []byte, []byte, error) {
+	header := make([]byte, len(encryptionMagic)+1)
+
+	_, err := io.ReadFull(src, header)
+	if err != nil || string(header[:len(encryptionMagic)]) != encryptionMagic {
+		return "", nil, nil, DecryptionError{Reason: "header is malformed"}
+	}
+
+	keyIDLength := int(header[len(encryptionMagic)])
+	rest := make([]byte, keyIDLength+encryptionNoncePrefixSize)
+
+	_, err = io.ReadFull(src, rest)
+	if err != nil {
+		return "", nil, nil, DecryptionError{Reason: "header is malformed"}
+	}
+
+	header = append(header, rest...)
+
+	return string(rest[:keyIDLength]), rest[keyIDLength:], header, nil
+}
+
+func chunkNonce(noncePrefix []byte, index uint32) []byte {
+	nonce := make([]byte, encryptionNoncePrefixSize+4)
+	copy(nonce, noncePrefix)
+	binary.BigEndian.PutUint32(nonce[encryptionNoncePrefixSize:], index)
+	return nonce
+}
+
+func chunkAdditionalData(header []byte, last bool) []byte {
+	additionalData := make([]byte, len(header)+1)
+	copy(additionalData, header)
+
+	if last {
+		additionalData[len(header)] = 1
+	}
+
+	return additionalData
+}
+
+// DecryptionError is returned when content cannot be decrypted;
+// retrying would not help since content or keys would have to change
+type DecryptionError struct {
+	KeyID  string
+	Reason string
+}
+
+func (e DecryptionError) Error() string {
+	if e.KeyID == "" {
+		return fmt.Sprintf("Decrypting blob: %s", e.Reason)
+	}
+
+	return fmt.Sprintf("Decrypting blob encrypted with key '%s': %s", e.KeyID, e.Reason)
+}
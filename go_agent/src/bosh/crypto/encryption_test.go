@@ -0,0 +1,158 @@
+package crypto_test
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/crypto"
+	boshsettings "bosh/settings"
+)
+
+var _ = Describe("BlobCipher", func() {
+	const (
+		// Base64 encoded 128 and 256 bit keys
+		key128 = "MDEyMzQ1Njc4OWFiY2RlZg=="
+		key256 = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	)
+
+	buildCipher := func(keys map[string]string, keyID string) BlobCipher {
+		blobCipher, err := NewBlobCipher(boshsettings.BlobstoreEncryption{Keys: keys, KeyID: keyID})
+		Expect(err).ToNot(HaveOccurred())
+		return blobCipher
+	}
+
+	encrypt := func(blobCipher BlobCipher, content string) []byte {
+		encrypted := &bytes.Buffer{}
+		err := blobCipher.Encrypt(encrypted, strings.NewReader(content))
+		Expect(err).ToNot(HaveOccurred())
+		return encrypted.Bytes()
+	}
+
+	decrypt := func(blobCipher BlobCipher, encrypted []byte) (string, error) {
+		decrypted := &bytes.Buffer{}
+		err := blobCipher.Decrypt(decrypted, bytes.NewReader(encrypted))
+		return decrypted.String(), err
+	}
+
+	Describe("NewBlobCipher", func() {
+		It("returns error when key is not base64 encoded", func() {
+			_, err := NewBlobCipher(boshsettings.BlobstoreEncryption{
+				Keys:  map[string]string{"fake-key-id": "fake-invalid-base64!"},
+				KeyID: "fake-key-id",
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decoding encryption key 'fake-key-id'"))
+		})
+
+		It("returns error when key has invalid AES key size", func() {
+			_, err := NewBlobCipher(boshsettings.BlobstoreEncryption{
+				Keys:  map[string]string{"fake-key-id": "c2hvcnQ="},
+				KeyID: "fake-key-id",
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Building AES cipher with key 'fake-key-id'"))
+		})
+
+		It("returns error when encryption key id is not one of given keys", func() {
+			_, err := NewBlobCipher(boshsettings.BlobstoreEncryption{
+				Keys:  map[string]string{"fake-key-id": key128},
+				KeyID: "fake-other-key-id",
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Encryption key id 'fake-other-key-id' is not one of given keys"))
+		})
+	})
+
+	Describe("Encrypt & Decrypt", func() {
+		It("round trips empty, small and multi chunk content", func() {
+			blobCipher := buildCipher(map[string]string{"fake-key-id": key256}, "fake-key-id")
+
+			for _, size := range []int{0, 1, 64 * 1024, 64*1024 + 1, 3*64*1024 - 7} {
+				content := strings.Repeat("x", size)
+
+				encrypted := encrypt(blobCipher, content)
+				Expect(bytes.Contains(encrypted, []byte("fake-key-id"))).To(BeTrue())
+
+				decrypted, err := decrypt(blobCipher, encrypted)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(decrypted).To(Equal(content))
+			}
+		})
+
+		It("uses different nonces for every encryption", func() {
+			blobCipher := buildCipher(map[string]string{"fake-key-id": key128}, "fake-key-id")
+			Expect(encrypt(blobCipher, "fake-content")).ToNot(Equal(encrypt(blobCipher, "fake-content")))
+		})
+
+		It("decrypts content encrypted with any of its keys", func() {
+			oldCipher := buildCipher(map[string]string{"fake-old-key-id": key128}, "fake-old-key-id")
+			newCipher := buildCipher(map[string]string{"fake-old-key-id": key128, "fake-new-key-id": key256}, "fake-new-key-id")
+
+			decrypted, err := decrypt(newCipher, encrypt(oldCipher, "fake-content"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypted).To(Equal("fake-content"))
+		})
+
+		It("returns decryption error when key is unknown", func() {
+			oldCipher := buildCipher(map[string]string{"fake-old-key-id": key128}, "fake-old-key-id")
+			newCipher := buildCipher(map[string]string{"fake-new-key-id": key256}, "fake-new-key-id")
+
+			_, err := decrypt(newCipher, encrypt(oldCipher, "fake-content"))
+			Expect(err).To(Equal(DecryptionError{KeyID: "fake-old-key-id", Reason: "key is unknown"}))
+			Expect(err.Error()).To(Equal("Decrypting blob encrypted with key 'fake-old-key-id': key is unknown"))
+		})
+
+		It("returns decryption error when content was modified", func() {
+			blobCipher := buildCipher(map[string]string{"fake-key-id": key128}, "fake-key-id")
+
+			encrypted := encrypt(blobCipher, "fake-content")
+			encrypted[len(encrypted)-20] ^= 1
+
+			_, err := decrypt(blobCipher, encrypted)
+			Expect(err).To(Equal(DecryptionError{KeyID: "fake-key-id", Reason: "content failed authentication"}))
+		})
+
+		It("returns decryption error when trailing chunks were dropped", func() {
+			blobCipher := buildCipher(map[string]string{"fake-key-id": key128}, "fake-key-id")
+
+			encrypted := encrypt(blobCipher, strings.Repeat("x", 2*64*1024))
+
+			// Header is followed by two full chunks and an empty last chunk
+			_, err := decrypt(blobCipher, encrypted[:len(encrypted)-16])
+			Expect(err).To(Equal(DecryptionError{KeyID: "fake-key-id", Reason: "content is truncated"}))
+
+			_, err = decrypt(blobCipher, encrypted[:len(encrypted)-16-(64*1024+16)])
+			Expect(err).To(Equal(DecryptionError{KeyID: "fake-key-id", Reason: "content is truncated"}))
+		})
+
+		It("returns decryption error when header is malformed", func() {
+			blobCipher := buildCipher(map[string]string{"fake-key-id": key128}, "fake-key-id")
+
+			_, err := decrypt(blobCipher, []byte("BOSHENC1"))
+			Expect(err).To(Equal(DecryptionError{Reason: "header is malformed"}))
+		})
+	})
+
+	Describe("IsEncrypted", func() {
+		It("detects encryption header without consuming content", func() {
+			blobCipher := buildCipher(map[string]string{"fake-key-id": key128}, "fake-key-id")
+
+			reader := bufio.NewReader(bytes.NewReader(encrypt(blobCipher, "fake-content")))
+			Expect(IsEncrypted(reader)).To(BeTrue())
+
+			decrypted := &bytes.Buffer{}
+			err := blobCipher.Decrypt(decrypted, reader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypted.String()).To(Equal("fake-content"))
+		})
+
+		It("does not detect unencrypted content", func() {
+			Expect(IsEncrypted(bufio.NewReader(strings.NewReader("fake-content")))).To(BeFalse())
+			Expect(IsEncrypted(bufio.NewReader(strings.NewReader("")))).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package fakes
+
+type FakeSignatureVerifier struct {
+	VerifyFileFileNames  []string
+	VerifyFileSignatures []string
+	VerifyFileErr        error
+}
+
+func (v *FakeSignatureVerifier) VerifyFile(fileName, signature string) error {
+	v.VerifyFileFileNames = append(v.VerifyFileFileNames, fileName)
+	v.VerifyFileSignatures = append(v.VerifyFileSignatures, signature)
+	return v.VerifyFileErr
+}
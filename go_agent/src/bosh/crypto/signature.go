@@ -0,0 +1,175 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsettings "bosh/settings"
+)
+
+const signatureVerifierLogTag = "signatureVerifier"
+
+type SignatureVerifier interface {
+	// VerifyFile returns an error if file must not be installed.
+	// Signature is "<key id>:<base64 signature>" or empty for unsigned files.
+	VerifyFile(fileName, signature string) error
+}
+
+// publicKeySignatureVerifier checks RSA (PKCS #1 v1.5) and ECDSA
+// signatures of SHA256 digest of file contents
+type publicKeySignatureVerifier struct {
+	keys   map[string]interface{}
+	strict bool
+	logger boshlog.Logger
+}
+
+func NewSignatureVerifier(signing boshsettings.BlobSigning, logger boshlog.Logger) (SignatureVerifier, error) {
+	if signing.Strict && len(signing.TrustedKeys) == 0 {
+		return nil, bosherr.New("Strict blob signing requires at least one trusted key")
+	}
+
+	keys := map[string]interface{}{}
+
+	for keyID, encodedKey := range signing.TrustedKeys {
+		key, err := parsePublicKey(encodedKey)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing trusted key '%s'", keyID)
+		}
+
+		keys[keyID] = key
+	}
+
+	return publicKeySignatureVerifier{
+		keys:   keys,
+		strict: signing.Strict,
+		logger: logger,
+	}, nil
+}
+
+func (v publicKeySignatureVerifier) VerifyFile(fileName, signature string) error {
+	if signature == "" {
+		if v.strict {
+			v.logger.Error(signatureVerifierLogTag, "Rejecting unsigned blob %s", fileName)
+			return bosherr.New("Blob is not signed and strict signature verification is enabled")
+		}
+
+		if len(v.keys) > 0 {
+			v.logger.Info(signatureVerifierLogTag, "Accepting unsigned blob %s", fileName)
+		}
+
+		return nil
+	}
+
+	if len(v.keys) == 0 {
+		v.logger.Debug(signatureVerifierLogTag, "Not verifying signature of blob %s since no trusted keys are configured", fileName)
+		return nil
+	}
+
+	keyID, value, err := parseSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	key, found := v.keys[keyID]
+	if !found {
+		v.logger.Error(signatureVerifierLogTag, "Rejecting blob %s signed by untrusted key '%s'", fileName, keyID)
+		return bosherr.New("Blob is signed by untrusted key '%s'", keyID)
+	}
+
+	digest, err := sha256File(fileName)
+	if err != nil {
+		return err
+	}
+
+	if !verifyDigestSignature(key, digest, value) {
+		v.logger.Error(signatureVerifierLogTag, "Rejecting blob %s with invalid signature by key '%s'", fileName, keyID)
+		return bosherr.New("Blob signature by key '%s' does not match blob contents", keyID)
+	}
+
+	v.logger.Debug(signatureVerifierLogTag, "Verified signature of blob %s by key '%s'", fileName, keyID)
+
+	return nil
+}
+
+// parseSignature splits on the last ":" since base64 does not contain it
+func parseSignature(signature string) (string, []byte, error) {
+	i := strings.LastIndex(signature, ":")
+	if i <= 0 {
+		return "", nil, bosherr.New("Invalid blob signature '%s': expected '<key id>:<base64 signature>'", signature)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(signature[i+1:])
+	if err != nil || len(value) == 0 {
+		return "", nil, bosherr.New("Invalid blob signature '%s': signature is not base64 encoded", signature)
+	}
+
+	return signature[:i], value, nil
+}
+
+func parsePublicKey(encodedKey string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(encodedKey))
+	if block == nil {
+		return nil, bosherr.New("Key is not PEM encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing public key")
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, bosherr.New("Unsupported public key type %T", key)
+	}
+}
+
+func sha256File(fileName string) ([]byte, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Opening blob for signature verification")
+	}
+
+	defer file.Close()
+
+	h := sha256.New()
+
+	_, err = io.Copy(h, file)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading blob for signature verification")
+	}
+
+	return h.Sum(nil), nil
+}
+
+func verifyDigestSignature(key interface{}, digest, signature []byte) bool {
+	switch typedKey := key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(typedKey, crypto.SHA256, digest, signature) == nil
+
+	case *ecdsa.PublicKey:
+		var ecdsaSignature struct{ R, S *big.Int }
+
+		rest, err := asn1.Unmarshal(signature, &ecdsaSignature)
+		if err != nil || len(rest) > 0 {
+			return false
+		}
+
+		return ecdsa.Verify(typedKey, digest, ecdsaSignature.R, ecdsaSignature.S)
+	}
+
+	return false
+}
@@ -0,0 +1,176 @@
+package crypto_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/crypto"
+	boshlog "bosh/logger"
+	boshsettings "bosh/settings"
+)
+
+var _ = Describe("SignatureVerifier", func() {
+	var (
+		rsaKey   *rsa.PrivateKey
+		ecdsaKey *ecdsa.PrivateKey
+		fileName string
+		digest   []byte
+		logger   boshlog.Logger
+	)
+
+	encodePublicKey := func(key interface{}) string {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		Expect(err).ToNot(HaveOccurred())
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+
+	signRSA := func(keyID string) string {
+		signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest)
+		Expect(err).ToNot(HaveOccurred())
+		return keyID + ":" + base64.StdEncoding.EncodeToString(signature)
+	}
+
+	signECDSA := func(keyID string) string {
+		r, s, err := ecdsa.Sign(rand.Reader, ecdsaKey, digest)
+		Expect(err).ToNot(HaveOccurred())
+
+		signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+		Expect(err).ToNot(HaveOccurred())
+
+		return keyID + ":" + base64.StdEncoding.EncodeToString(signature)
+	}
+
+	buildVerifier := func(strict bool) SignatureVerifier {
+		verifier, err := NewSignatureVerifier(boshsettings.BlobSigning{
+			TrustedKeys: map[string]string{
+				"fake-rsa-key-id":   encodePublicKey(&rsaKey.PublicKey),
+				"fake-ecdsa-key-id": encodePublicKey(&ecdsaKey.PublicKey),
+			},
+			Strict: strict,
+		}, logger)
+		Expect(err).ToNot(HaveOccurred())
+		return verifier
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 1024)
+		Expect(err).ToNot(HaveOccurred())
+
+		ecdsaKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		file, err := ioutil.TempFile("", "signature-verifier")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = file.Write([]byte("fake-content"))
+		Expect(err).ToNot(HaveOccurred())
+
+		file.Close()
+		fileName = file.Name()
+
+		sum := sha256.Sum256([]byte("fake-content"))
+		digest = sum[:]
+
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	AfterEach(func() {
+		os.Remove(fileName)
+	})
+
+	Describe("NewSignatureVerifier", func() {
+		It("returns error when strict mode has no trusted keys", func() {
+			_, err := NewSignatureVerifier(boshsettings.BlobSigning{Strict: true}, logger)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Strict blob signing requires at least one trusted key"))
+		})
+
+		It("returns error when trusted key is not PEM encoded", func() {
+			_, err := NewSignatureVerifier(boshsettings.BlobSigning{
+				TrustedKeys: map[string]string{"fake-key-id": "fake-invalid-key"},
+			}, logger)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing trusted key 'fake-key-id'"))
+		})
+	})
+
+	Describe("VerifyFile", func() {
+		It("accepts file signed with trusted RSA key", func() {
+			err := buildVerifier(true).VerifyFile(fileName, signRSA("fake-rsa-key-id"))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("accepts file signed with trusted ECDSA key", func() {
+			err := buildVerifier(true).VerifyFile(fileName, signECDSA("fake-ecdsa-key-id"))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects file whose contents do not match signature", func() {
+			signature := signRSA("fake-rsa-key-id")
+
+			err := ioutil.WriteFile(fileName, []byte("fake-tampered-content"), os.ModePerm)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = buildVerifier(false).VerifyFile(fileName, signature)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Blob signature by key 'fake-rsa-key-id' does not match blob contents"))
+		})
+
+		It("rejects file signed by other key than given key id", func() {
+			err := buildVerifier(false).VerifyFile(fileName, signRSA("fake-ecdsa-key-id"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not match blob contents"))
+		})
+
+		It("rejects file signed by untrusted key", func() {
+			err := buildVerifier(false).VerifyFile(fileName, signRSA("fake-unknown-key-id"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Blob is signed by untrusted key 'fake-unknown-key-id'"))
+		})
+
+		It("rejects malformed signature", func() {
+			err := buildVerifier(false).VerifyFile(fileName, "fake-rsa-key-id:not-base64!")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("signature is not base64 encoded"))
+
+			err = buildVerifier(false).VerifyFile(fileName, "fake-signature-without-key-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("expected '<key id>:<base64 signature>'"))
+		})
+
+		It("rejects unsigned file in strict mode", func() {
+			err := buildVerifier(true).VerifyFile(fileName, "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Blob is not signed and strict signature verification is enabled"))
+		})
+
+		It("accepts unsigned file when not in strict mode", func() {
+			err := buildVerifier(false).VerifyFile(fileName, "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not verify signatures when no trusted keys are configured", func() {
+			verifier, err := NewSignatureVerifier(boshsettings.BlobSigning{}, logger)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = verifier.VerifyFile(fileName, "fake-key-id:fake-signature")
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
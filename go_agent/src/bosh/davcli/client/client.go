@@ -3,79 +3,273 @@ package client
 import (
 	davconf "bosh/davcli/config"
 	"crypto/sha1"
+	"crypto/tls"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"time"
 )
 
+// Only the beginning of error responses is included in errors
+const maxErrorBodyLength = 1024
+
 type Client interface {
+	// Get returns error when blob cannot be found
 	Get(path string) (content io.ReadCloser, err error)
-	Put(path string, content io.ReadCloser) (err error)
+
+	// GetRange requests bytes from start to end inclusive. Response status
+	// is 206 when server supports ranges and 200 with whole blob otherwise;
+	// caller must close response body.
+	GetRange(path string, start, end int64) (resp *http.Response, err error)
+
+	// Put reads content again on retry; caller must close it.
+	// Returned SHA1 is calculated while uploading.
+	Put(path string, content io.ReadSeeker) (sha1 string, err error)
+
+	// Delete does not return error when blob does not exist
+	Delete(path string) (err error)
+
+	Exists(path string) (exists bool, err error)
+
+	// Sign returns URL that allows action (GET or PUT)
+	// without credentials until expiration
+	Sign(path, action string, expiration time.Duration) (signedURL string, err error)
+}
+
+type ResponseError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e ResponseError) Error() string {
+	return fmt.Sprintf("%s %s: Wrong response code: %d; body: %s", e.Method, e.Path, e.StatusCode, e.Body)
 }
 
+// NewClient returns client that fails every request
+// when HTTP client cannot be built from config
 func NewClient(config davconf.Config) (c Client) {
+	httpClient, err := newHTTPClient(config)
+
 	return client{
-		config:     config,
-		httpClient: http.DefaultClient,
+		config:        config,
+		httpClient:    httpClient,
+		httpClientErr: err,
+	}
+}
+
+func newHTTPClient(config davconf.Config) (*http.Client, error) {
+	certPool, err := config.CertPool()
+	if err != nil {
+		return nil, err
 	}
+
+	dialer := &net.Dialer{Timeout: config.ConnectTimeout()}
+
+	return &http.Client{
+		Timeout: config.RequestTimeout(),
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			Dial:  dialer.Dial,
+			TLSClientConfig: &tls.Config{
+				RootCAs:            certPool,
+				InsecureSkipVerify: config.InsecureSkipVerify,
+			},
+		},
+	}, nil
 }
 
 type client struct {
-	config     davconf.Config
-	httpClient *http.Client
+	config        davconf.Config
+	httpClient    *http.Client
+	httpClientErr error
 }
 
 func (c client) Get(path string) (content io.ReadCloser, err error) {
-	req, err := c.createReq("GET", path, nil)
+	resp, err := c.do("GET", path, nil, nil)
 	if err != nil {
 		return
 	}
 
-	resp, err := c.httpClient.Do(req)
+	content = resp.Body
+	return
+}
+
+func (c client) GetRange(path string, start, end int64) (*http.Response, error) {
+	headers := http.Header{"Range": []string{fmt.Sprintf("bytes=%d-%d", start, end)}}
+	return c.do("GET", path, nil, headers)
+}
+
+func (c client) Put(path string, content io.ReadSeeker) (string, error) {
+	size, err := content.Seek(0, 2)
+	if err != nil {
+		return "", fmt.Errorf("Getting content size: %s", err.Error())
+	}
+
+	digester := sha1.New()
+
+	body := func() (io.Reader, int64, error) {
+		_, err := content.Seek(0, 0)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Rewinding content: %s", err.Error())
+		}
+
+		digester.Reset()
+
+		return io.TeeReader(content, digester), size, nil
+	}
+
+	resp, err := c.do("PUT", path, body, nil)
 	if err != nil {
-		return
+		return "", err
 	}
 
-	content = resp.Body
-	return
+	resp.Body.Close()
+
+	return fmt.Sprintf("%x", digester.Sum(nil)), nil
+}
+
+func (c client) Delete(path string) error {
+	resp, err := c.do("DELETE", path, nil, nil)
+	if err != nil {
+		if respErr, ok := err.(ResponseError); ok && respErr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func (c client) Exists(path string) (bool, error) {
+	resp, err := c.do("HEAD", path, nil, nil)
+	if err != nil {
+		if respErr, ok := err.(ResponseError); ok && respErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	resp.Body.Close()
+
+	return true, nil
+}
+
+// do retries network errors and 5xx responses with exponential backoff;
+// body func is called before each attempt to provide request body
+func (c client) do(
+	method, blobID string,
+	body func() (io.Reader, int64, error),
+	headers http.Header,
+) (*http.Response, error) {
+	if c.httpClientErr != nil {
+		return nil, fmt.Errorf("Building HTTP client: %s", c.httpClientErr.Error())
+	}
+
+	attempts, delay := c.config.Retries()
+
+	var lastErr error
+
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		req, err := c.createReq(method, blobID)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, values := range headers {
+			req.Header[name] = values
+		}
+
+		if body != nil {
+			reader, size, err := body()
+			if err != nil {
+				return nil, err
+			}
+
+			// Content is not closed by the transport so that it can be retried;
+			// empty body is left out since it would be sent chunked
+			if size > 0 {
+				req.Body = ioutil.NopCloser(reader)
+				req.ContentLength = size
+			}
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			return resp, nil
+		}
+
+		lastErr = newResponseError(req, resp)
+
+		if resp.StatusCode < 500 {
+			return nil, lastErr
+		}
+	}
+
+	return nil, lastErr
+}
+
+func newResponseError(req *http.Request, resp *http.Response) ResponseError {
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+
+	return ResponseError{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
 }
 
-func (c client) Put(path string, content io.ReadCloser) (err error) {
-	req, err := c.createReq("PUT", path, content)
+func (c client) createReq(method, blobID string) (req *http.Request, err error) {
+	blobURL, err := c.blobURL(blobID, "")
+	if err != nil {
+		return
+	}
+
+	req, err = http.NewRequest(method, blobURL.String(), nil)
 	if err != nil {
 		return
 	}
-	defer content.Close()
 
-	_, err = c.httpClient.Do(req)
+	req.SetBasicAuth(c.config.User, c.config.Password)
 	return
 }
 
-func (c client) createReq(method, blobID string, body io.Reader) (req *http.Request, err error) {
+// blobURL places blobs under first byte of SHA1 of their ID
+func (c client) blobURL(blobID, pathPrefix string) (*url.URL, error) {
 	blobURL, err := url.Parse(c.config.Endpoint)
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	digester := sha1.New()
 	digester.Write([]byte(blobID))
 	blobPrefix := fmt.Sprintf("%02x", digester.Sum(nil)[0])
 
-	newPath := path.Join(blobURL.Path, blobPrefix, blobID)
+	newPath := path.Join(blobURL.Path, pathPrefix, blobPrefix, blobID)
 	if !strings.HasPrefix(newPath, "/") {
 		newPath = "/" + newPath
 	}
 
 	blobURL.Path = newPath
 
-	req, err = http.NewRequest(method, blobURL.String(), body)
-	if err != nil {
-		return
-	}
-
-	req.SetBasicAuth(c.config.User, c.config.Password)
-	return
+	return blobURL, nil
 }
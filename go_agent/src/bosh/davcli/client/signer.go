@@ -0,0 +1,49 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Sign builds URL under /signed path verified by the server
+// (e.g. nginx secure_link) with HMAC-SHA256 of action, blob path,
+// timestamp and expiration using shared secret
+func (c client) Sign(blobID, action string, expiration time.Duration) (string, error) {
+	action = strings.ToUpper(action)
+	if action != "GET" && action != "PUT" {
+		return "", fmt.Errorf("Action '%s' cannot be signed, must be GET or PUT", action)
+	}
+
+	if c.config.Secret == "" {
+		return "", errors.New("Signing URL requires secret")
+	}
+
+	blobURL, err := c.blobURL(blobID, "signed")
+	if err != nil {
+		return "", err
+	}
+
+	timestamp := time.Now().Unix()
+	expirationSeconds := int64(expiration.Seconds())
+
+	query := blobURL.Query()
+	query.Set("st", SignURLString(c.config.Secret, action, blobURL.Path, timestamp, expirationSeconds))
+	query.Set("ts", fmt.Sprintf("%d", timestamp))
+	query.Set("e", fmt.Sprintf("%d", expirationSeconds))
+	blobURL.RawQuery = query.Encode()
+
+	return blobURL.String(), nil
+}
+
+// SignURLString returns unpadded URL-safe base64 signature
+func SignURLString(secret, action, path string, timestamp, expirationSeconds int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%s%s%d%d", action, path, timestamp, expirationSeconds)))
+
+	return strings.TrimRight(base64.URLEncoding.EncodeToString(mac.Sum(nil)), "=")
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"errors"
+
+	davclient "bosh/davcli/client"
+)
+
+type DeleteCmd struct {
+	client davclient.Client
+}
+
+func newDeleteCmd(client davclient.Client) (cmd DeleteCmd) {
+	cmd.client = client
+	return
+}
+
+func (cmd DeleteCmd) Run(args []string) error {
+	if len(args) != 1 {
+		return errors.New("Incorrect usage, delete needs remote blob path")
+	}
+
+	return cmd.client.Delete(args[0])
+}
@@ -0,0 +1,75 @@
+package cmd_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/davcli/cmd"
+	davconf "bosh/davcli/config"
+)
+
+func runDelete(config davconf.Config, args []string) error {
+	factory := NewFactory(ioutil.Discard)
+	factory.SetConfig(config)
+
+	cmd, err := factory.Create("delete")
+	Expect(err).ToNot(HaveOccurred())
+
+	return cmd.Run(args)
+}
+
+var _ = Describe("DeleteCmd", func() {
+	var (
+		status   int
+		requests []*http.Request
+		ts       *httptest.Server
+	)
+
+	BeforeEach(func() {
+		requests = []*http.Request{}
+
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			w.WriteHeader(status)
+		}))
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	It("deletes blob", func() {
+		status = http.StatusNoContent
+
+		err := runDelete(davconf.Config{Endpoint: ts.URL}, []string{"fake-blob-id"})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(requests[0].Method).To(Equal("DELETE"))
+		Expect(requests[0].URL.Path).To(Equal("/80/fake-blob-id"))
+	})
+
+	It("does not return error when blob does not exist", func() {
+		status = http.StatusNotFound
+
+		err := runDelete(davconf.Config{Endpoint: ts.URL}, []string{"fake-blob-id"})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("returns error when server rejects request", func() {
+		status = http.StatusForbidden
+
+		err := runDelete(davconf.Config{Endpoint: ts.URL}, []string{"fake-blob-id"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Wrong response code: 403"))
+	})
+
+	It("returns error with incorrect arg count", func() {
+		err := runDelete(davconf.Config{}, []string{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Incorrect usage"))
+	})
+})
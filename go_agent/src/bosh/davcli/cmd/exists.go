@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	davclient "bosh/davcli/client"
+)
+
+type ExistsCmd struct {
+	client davclient.Client
+	out    io.Writer
+}
+
+func newExistsCmd(client davclient.Client, out io.Writer) (cmd ExistsCmd) {
+	cmd.client = client
+	cmd.out = out
+	return
+}
+
+// Run prints true or false; errors are only returned
+// when existence cannot be determined
+func (cmd ExistsCmd) Run(args []string) error {
+	if len(args) != 1 {
+		return errors.New("Incorrect usage, exists needs remote blob path")
+	}
+
+	exists, err := cmd.client.Exists(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(cmd.out, exists)
+	return err
+}
@@ -0,0 +1,72 @@
+package cmd_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/davcli/cmd"
+	davconf "bosh/davcli/config"
+)
+
+func runExists(config davconf.Config, args []string) (string, error) {
+	out := bytes.NewBuffer([]byte{})
+
+	factory := NewFactory(out)
+	factory.SetConfig(config)
+
+	cmd, err := factory.Create("exists")
+	Expect(err).ToNot(HaveOccurred())
+
+	err = cmd.Run(args)
+
+	return out.String(), err
+}
+
+var _ = Describe("ExistsCmd", func() {
+	var (
+		status int
+		method string
+		ts     *httptest.Server
+	)
+
+	BeforeEach(func() {
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			w.WriteHeader(status)
+		}))
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	It("prints true when blob exists", func() {
+		status = http.StatusOK
+
+		out, err := runExists(davconf.Config{Endpoint: ts.URL}, []string{"fake-blob-id"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("true\n"))
+		Expect(method).To(Equal("HEAD"))
+	})
+
+	It("prints false when blob does not exist", func() {
+		status = http.StatusNotFound
+
+		out, err := runExists(davconf.Config{Endpoint: ts.URL}, []string{"fake-blob-id"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("false\n"))
+	})
+
+	It("returns error when existence cannot be determined", func() {
+		status = http.StatusUnauthorized
+
+		out, err := runExists(davconf.Config{Endpoint: ts.URL}, []string{"fake-blob-id"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Wrong response code: 401"))
+		Expect(out).To(BeEmpty())
+	})
+})
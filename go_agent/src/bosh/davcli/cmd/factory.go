@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 
 	davclient "bosh/davcli/client"
 	davconf "bosh/davcli/config"
@@ -12,13 +13,15 @@ type Factory interface {
 	SetConfig(config davconf.Config)
 }
 
-func NewFactory() (f Factory) {
-	return &factory{cmds: make(map[string]Cmd)}
+// NewFactory returns factory of commands that print their results to out
+func NewFactory(out io.Writer) (f Factory) {
+	return &factory{cmds: make(map[string]Cmd), out: out}
 }
 
 type factory struct {
 	config davconf.Config
 	cmds   map[string]Cmd
+	out    io.Writer
 }
 
 func (f *factory) Create(name string) (cmd Cmd, err error) {
@@ -33,7 +36,10 @@ func (f *factory) SetConfig(config davconf.Config) {
 	client := davclient.NewClient(config)
 
 	f.cmds = map[string]Cmd{
-		"put": newPutCmd(client),
-		"get": newGetCmd(client),
+		"put":    newPutCmd(client, f.out),
+		"get":    newGetCmd(client),
+		"delete": newDeleteCmd(client),
+		"exists": newExistsCmd(client, f.out),
+		"sign":   newSignCmd(client, f.out),
 	}
 }
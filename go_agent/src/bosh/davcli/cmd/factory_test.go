@@ -1,6 +1,7 @@
 package cmd_test
 
 import (
+	"io/ioutil"
 	"reflect"
 
 	. "github.com/onsi/ginkgo"
@@ -12,7 +13,7 @@ import (
 
 func buildFactory() (factory Factory) {
 	config := davconf.Config{User: "some user"}
-	factory = NewFactory()
+	factory = NewFactory(ioutil.Discard)
 	factory.SetConfig(config)
 	return
 }
@@ -34,6 +35,21 @@ func init() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(reflect.TypeOf(cmd)).To(Equal(reflect.TypeOf(GetCmd{})))
 		})
+		It("factory create delete, exists and sign commands", func() {
+			factory := buildFactory()
+
+			cmd, err := factory.Create("delete")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reflect.TypeOf(cmd)).To(Equal(reflect.TypeOf(DeleteCmd{})))
+
+			cmd, err = factory.Create("exists")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reflect.TypeOf(cmd)).To(Equal(reflect.TypeOf(ExistsCmd{})))
+
+			cmd, err = factory.Create("sign")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reflect.TypeOf(cmd)).To(Equal(reflect.TypeOf(SignCmd{})))
+		})
 		It("factory create when cmd is unknown", func() {
 
 			factory := buildFactory()
@@ -32,6 +32,7 @@ func (cmd GetCmd) Run(args []string) (err error) {
 	if err != nil {
 		return
 	}
+	defer targetFile.Close()
 
 	_, err = io.Copy(targetFile, readCloser)
 	return
@@ -16,7 +16,7 @@ import (
 )
 
 func runGet(config davconf.Config, args []string) error {
-	factory := NewFactory()
+	factory := NewFactory(ioutil.Discard)
 	factory.SetConfig(config)
 
 	cmd, err := factory.Create("get")
@@ -69,6 +69,22 @@ func init() {
 			Expect(getFileContent(targetFilePath)).To(Equal("this is your blob"))
 		})
 
+		It("get run returns error when blob is not found", func() {
+			targetFilePath := filepath.Join(os.TempDir(), "testRunGetCommand.txt")
+			defer os.RemoveAll(targetFilePath)
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}
+
+			ts := httptest.NewServer(http.HandlerFunc(handler))
+			defer ts.Close()
+
+			err := runGet(davconf.Config{Endpoint: ts.URL}, []string{"fake-blob-id", targetFilePath})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Wrong response code: 404"))
+		})
+
 		It("get run with incorrect arg count", func() {
 			err := runGet(davconf.Config{}, []string{})
 			Expect(err).To(HaveOccurred())
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"os"
 
 	davclient "bosh/davcli/client"
@@ -9,13 +11,16 @@ import (
 
 type PutCmd struct {
 	client davclient.Client
+	out    io.Writer
 }
 
-func newPutCmd(client davclient.Client) (cmd PutCmd) {
+func newPutCmd(client davclient.Client, out io.Writer) (cmd PutCmd) {
 	cmd.client = client
+	cmd.out = out
 	return
 }
 
+// Run prints SHA1 of uploaded content
 func (cmd PutCmd) Run(args []string) error {
 	if len(args) != 2 {
 		return errors.New("Incorrect usage, put needs local file and remote blob destination")
@@ -26,5 +31,13 @@ func (cmd PutCmd) Run(args []string) error {
 		return err
 	}
 
-	return cmd.client.Put(args[1], file)
+	defer file.Close()
+
+	sha1, err := cmd.client.Put(args[1], file)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(cmd.out, sha1)
+	return err
 }
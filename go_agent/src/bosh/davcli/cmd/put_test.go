@@ -1,6 +1,7 @@
 package cmd_test
 
 import (
+	"bytes"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -15,14 +16,18 @@ import (
 	davconf "bosh/davcli/config"
 )
 
-func runPut(config davconf.Config, args []string) error {
-	factory := NewFactory()
+func runPut(config davconf.Config, args []string) (string, error) {
+	out := bytes.NewBuffer([]byte{})
+
+	factory := NewFactory(out)
 	factory.SetConfig(config)
 
 	cmd, err := factory.Create("put")
 	Expect(err).ToNot(HaveOccurred())
 
-	return cmd.Run(args)
+	err = cmd.Run(args)
+
+	return out.String(), err
 }
 
 func fileBytes(path string) []byte {
@@ -72,13 +77,69 @@ var _ = Describe("PutCmd", func() {
 				Endpoint: ts.URL,
 			}
 
-			err = runPut(config, []string{sourceFilePath, targetBlob})
+			out, err := runPut(config, []string{sourceFilePath, targetBlob})
 			Expect(err).ToNot(HaveOccurred())
 			Expect(serverWasHit).To(BeTrue())
+			Expect(out).To(Equal("fb34af06263f66d3939f384c58b9cf5aea4fe129\n"))
+		})
+
+		It("retries server errors and returns last server response", func() {
+			pwd, err := os.Getwd()
+			Expect(err).ToNot(HaveOccurred())
+
+			sourceFilePath := filepath.Join(pwd, "../../../../fixtures/cat.jpg")
+			expectedBytes := fileBytes(sourceFilePath)
+			attempts := 0
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+
+				actualBytes, _ := ioutil.ReadAll(r.Body)
+				Expect(actualBytes).To(Equal(expectedBytes))
+
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("fake-server-error"))
+			}
+
+			ts := httptest.NewServer(http.HandlerFunc(handler))
+			defer ts.Close()
+
+			config := davconf.Config{
+				Endpoint:               ts.URL,
+				RetryAttempts:          3,
+				RetryDelayMilliseconds: 1,
+			}
+
+			_, err = runPut(config, []string{sourceFilePath, "fake-blob-id"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Wrong response code: 503; body: fake-server-error"))
+			Expect(attempts).To(Equal(3))
+		})
+
+		It("does not retry client errors", func() {
+			pwd, err := os.Getwd()
+			Expect(err).ToNot(HaveOccurred())
+
+			attempts := 0
+
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(http.StatusForbidden)
+			}
+
+			ts := httptest.NewServer(http.HandlerFunc(handler))
+			defer ts.Close()
+
+			config := davconf.Config{Endpoint: ts.URL, RetryDelayMilliseconds: 1}
+
+			_, err = runPut(config, []string{filepath.Join(pwd, "../../../../fixtures/cat.jpg"), "fake-blob-id"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Wrong response code: 403"))
+			Expect(attempts).To(Equal(1))
 		})
 
 		It("with incorrect arg count", func() {
-			err := runPut(davconf.Config{}, []string{})
+			_, err := runPut(davconf.Config{}, []string{})
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("Incorrect usage"))
 		})
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	davclient "bosh/davcli/client"
+)
+
+type SignCmd struct {
+	client davclient.Client
+	out    io.Writer
+}
+
+func newSignCmd(client davclient.Client, out io.Writer) (cmd SignCmd) {
+	cmd.client = client
+	cmd.out = out
+	return
+}
+
+// Run prints signed URL
+func (cmd SignCmd) Run(args []string) error {
+	if len(args) != 3 {
+		return errors.New("Incorrect usage, sign needs remote blob path, action (get or put) and expiration (e.g. 60s)")
+	}
+
+	expiration, err := time.ParseDuration(args[2])
+	if err != nil {
+		return fmt.Errorf("Parsing expiration: %s", err.Error())
+	}
+
+	signedURL, err := cmd.client.Sign(args[0], args[1], expiration)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(cmd.out, signedURL)
+	return err
+}
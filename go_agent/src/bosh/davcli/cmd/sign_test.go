@@ -0,0 +1,80 @@
+package cmd_test
+
+import (
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	davclient "bosh/davcli/client"
+	. "bosh/davcli/cmd"
+	davconf "bosh/davcli/config"
+)
+
+func runSign(config davconf.Config, args []string) (string, error) {
+	out := bytes.NewBuffer([]byte{})
+
+	factory := NewFactory(out)
+	factory.SetConfig(config)
+
+	cmd, err := factory.Create("sign")
+	Expect(err).ToNot(HaveOccurred())
+
+	err = cmd.Run(args)
+
+	return out.String(), err
+}
+
+var _ = Describe("SignCmd", func() {
+	var config davconf.Config
+
+	BeforeEach(func() {
+		config = davconf.Config{
+			Endpoint: "https://fake-host/fake-path",
+			Secret:   "fake-secret",
+		}
+	})
+
+	It("prints URL signed with shared secret", func() {
+		out, err := runSign(config, []string{"fake-blob-id", "get", "60s"})
+		Expect(err).ToNot(HaveOccurred())
+
+		signedURL, err := url.Parse(strings.TrimSpace(out))
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(signedURL.Host).To(Equal("fake-host"))
+		Expect(signedURL.Path).To(Equal("/fake-path/signed/80/fake-blob-id"))
+
+		query := signedURL.Query()
+		Expect(query.Get("e")).To(Equal("60"))
+
+		timestamp, err := strconv.ParseInt(query.Get("ts"), 10, 64)
+		Expect(err).ToNot(HaveOccurred())
+
+		expectedSignature := davclient.SignURLString("fake-secret", "GET", "/fake-path/signed/80/fake-blob-id", timestamp, 60)
+		Expect(query.Get("st")).To(Equal(expectedSignature))
+	})
+
+	It("returns error when action cannot be signed", func() {
+		_, err := runSign(config, []string{"fake-blob-id", "delete", "60s"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("must be GET or PUT"))
+	})
+
+	It("returns error when secret is not configured", func() {
+		config.Secret = ""
+
+		_, err := runSign(config, []string{"fake-blob-id", "put", "60s"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Signing URL requires secret"))
+	})
+
+	It("returns error when expiration is invalid", func() {
+		_, err := runSign(config, []string{"fake-blob-id", "get", "fake-expiration"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing expiration"))
+	})
+})
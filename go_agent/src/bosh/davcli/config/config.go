@@ -1,7 +1,87 @@
 package config
 
+import (
+	"crypto/x509"
+	"errors"
+	"time"
+)
+
+const (
+	defaultConnectTimeout = 30 * time.Second
+	defaultRetryAttempts  = 3
+	defaultRetryDelay     = 1 * time.Second
+)
+
 type Config struct {
 	User     string
 	Password string
 	Endpoint string
+
+	// PEM encoded certificates used to verify HTTPS endpoint
+	// instead of system certificates
+	CACert string `json:"ca_cert"`
+
+	// Disables verification of HTTPS endpoint certificate
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+
+	// Defaults to 30 seconds
+	ConnectTimeoutSeconds int `json:"connect_timeout_seconds"`
+
+	// Time allowed for the whole request including body transfer;
+	// requests are not limited by default since blobs can be large
+	RequestTimeoutSeconds int `json:"request_timeout_seconds"`
+
+	// Number of attempts made for requests failing with network errors
+	// or 5xx responses; defaults to 3
+	RetryAttempts int `json:"retry_attempts"`
+
+	// Delay before the first retry, doubled for each following one;
+	// defaults to 1 second
+	RetryDelayMilliseconds int `json:"retry_delay_milliseconds"`
+
+	// Shared with the server to sign URLs that do not require credentials
+	Secret string `json:"secret"`
+}
+
+// CertPool returns nil pool when no CA certificate is configured
+func (c Config) CertPool() (*x509.CertPool, error) {
+	if c.CACert == "" {
+		return nil, nil
+	}
+
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM([]byte(c.CACert)) {
+		return nil, errors.New("Parsing CA certificate: no PEM encoded certificates found")
+	}
+
+	return pool, nil
+}
+
+func (c Config) ConnectTimeout() time.Duration {
+	if c.ConnectTimeoutSeconds <= 0 {
+		return defaultConnectTimeout
+	}
+	return time.Duration(c.ConnectTimeoutSeconds) * time.Second
+}
+
+func (c Config) RequestTimeout() time.Duration {
+	if c.RequestTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(c.RequestTimeoutSeconds) * time.Second
+}
+
+func (c Config) Retries() (int, time.Duration) {
+	attempts := c.RetryAttempts
+	if attempts <= 0 {
+		attempts = defaultRetryAttempts
+	}
+
+	delay := time.Duration(c.RetryDelayMilliseconds) * time.Millisecond
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	return attempts, delay
 }
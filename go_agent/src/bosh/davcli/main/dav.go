@@ -9,7 +9,7 @@ import (
 )
 
 func main() {
-	cmdFactory := cmd.NewFactory()
+	cmdFactory := cmd.NewFactory(os.Stdout)
 
 	cmdRunner := cmd.NewRunner(cmdFactory)
 
@@ -50,3 +50,14 @@ func WrapComplexError(cause, delegate error) error {
 		cause:    cause,
 	}
 }
+
+// Cause returns the innermost error wrapped with WrapError or WrapComplexError
+func Cause(err error) error {
+	for {
+		typedErr, ok := err.(complexError)
+		if !ok {
+			return err
+		}
+		err = typedErr.cause
+	}
+}
@@ -90,3 +90,17 @@ var _ = Describe("WrapComplexError", func() {
 		Expect(typedErr.ShortError()).To(Equal("delegate-short1: cause-short1"))
 	})
 })
+
+var _ = Describe("Cause", func() {
+	It("returns innermost wrapped error", func() {
+		cause := &testShortError{fullMsg: "fake-cause"}
+
+		err := WrapError(WrapError(cause, "fake-message-1"), "fake-message-2")
+		Expect(Cause(err)).To(Equal(cause))
+	})
+
+	It("returns error itself when it is not wrapped", func() {
+		err := New("fake-message")
+		Expect(Cause(err)).To(Equal(err))
+	})
+})
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+
+	bosherr "bosh/errors"
+)
+
+// ResponseChunk is a piece of a response that was too large to be sent
+// as a single message. Concatenating Data of all chunks ordered by
+// Index yields the original JSON response.
+type ResponseChunk struct {
+	Chunk ResponseChunkHeader `json:"chunk"`
+	Data  []byte              `json:"data"`
+}
+
+type ResponseChunkHeader struct {
+	Index int `json:"index"`
+	Count int `json:"count"`
+
+	// Size and SHA1 describe the whole reassembled response
+	Size int    `json:"size"`
+	SHA1 string `json:"sha1"`
+}
+
+// SplitIntoChunks returns marshalled chunks each carrying
+// at most chunkSize bytes of respJSON
+func SplitIntoChunks(respJSON []byte, chunkSize int) ([][]byte, error) {
+	if chunkSize <= 0 {
+		return nil, bosherr.New("Invalid chunk size %d", chunkSize)
+	}
+
+	count := (len(respJSON) + chunkSize - 1) / chunkSize
+	if count == 0 {
+		count = 1
+	}
+
+	header := ResponseChunkHeader{
+		Count: count,
+		Size:  len(respJSON),
+		SHA1:  fmt.Sprintf("%x", sha1.Sum(respJSON)),
+	}
+
+	chunks := make([][]byte, 0, count)
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(respJSON) {
+			end = len(respJSON)
+		}
+
+		header.Index = i
+
+		chunkJSON, err := json.Marshal(ResponseChunk{
+			Chunk: header,
+			Data:  respJSON[i*chunkSize : end],
+		})
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Marshalling chunk %d", i)
+		}
+
+		chunks = append(chunks, chunkJSON)
+	}
+
+	return chunks, nil
+}
@@ -0,0 +1,65 @@
+package handler_test
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/handler"
+)
+
+var _ = Describe("SplitIntoChunks", func() {
+	unmarshalChunks := func(chunks [][]byte) []ResponseChunk {
+		respChunks := []ResponseChunk{}
+
+		for _, chunkJSON := range chunks {
+			var chunk ResponseChunk
+			err := json.Unmarshal(chunkJSON, &chunk)
+			Expect(err).ToNot(HaveOccurred())
+			respChunks = append(respChunks, chunk)
+		}
+
+		return respChunks
+	}
+
+	It("splits response into numbered chunks that can be reassembled", func() {
+		respJSON := []byte(`{"value":"fake-long-value"}`)
+
+		chunks, err := SplitIntoChunks(respJSON, 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(chunks)).To(Equal(3))
+
+		reassembled := []byte{}
+
+		for i, chunk := range unmarshalChunks(chunks) {
+			Expect(chunk.Chunk).To(Equal(ResponseChunkHeader{
+				Index: i,
+				Count: 3,
+				Size:  len(respJSON),
+				SHA1:  fmt.Sprintf("%x", sha1.Sum(respJSON)),
+			}))
+			reassembled = append(reassembled, chunk.Data...)
+		}
+
+		Expect(reassembled).To(Equal(respJSON))
+	})
+
+	It("returns single chunk when response fits", func() {
+		chunks, err := SplitIntoChunks([]byte(`{"value":1}`), 100)
+		Expect(err).ToNot(HaveOccurred())
+
+		respChunks := unmarshalChunks(chunks)
+		Expect(len(respChunks)).To(Equal(1))
+		Expect(respChunks[0].Chunk.Count).To(Equal(1))
+		Expect(respChunks[0].Data).To(Equal([]byte(`{"value":1}`)))
+	})
+
+	It("returns error when chunk size is not positive", func() {
+		_, err := SplitIntoChunks([]byte(`{}`), 0)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Invalid chunk size 0"))
+	})
+})
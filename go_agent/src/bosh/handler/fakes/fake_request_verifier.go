@@ -0,0 +1,15 @@
+package fakes
+
+import (
+	boshhandler "bosh/handler"
+)
+
+type FakeRequestVerifier struct {
+	VerifyRequests []boshhandler.Request
+	VerifyErr      error
+}
+
+func (v *FakeRequestVerifier) Verify(request boshhandler.Request) error {
+	v.VerifyRequests = append(v.VerifyRequests, request)
+	return v.VerifyErr
+}
@@ -2,18 +2,35 @@ package handler
 
 import (
 	"encoding/json"
+	"regexp"
 
 	bosherr "bosh/errors"
 	boshlog "bosh/logger"
+	boshuuid "bosh/uuid"
 )
 
 const (
 	mbusHandlerLogTag       = "MBus Handler"
+	requestAuditLogTag      = "Request Audit"
 	responseMaxLengthErrMsg = "Response exceeded maximum allowed length"
 	UnlimitedResponseLength = -1
+
+	maxCorrelationIDLength = 64
 )
 
-func PerformHandlerWithJSON(rawJSON []byte, handler HandlerFunc, maxResponseLength int, logger boshlog.Logger) ([]byte, Request, error) {
+// Request IDs end up in log lines and environment variables
+var correlationIDUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// PerformHandlerWithJSON does not limit response length for requests
+// that accept chunked replies; such responses should be split with SplitIntoChunks.
+// Requests are only dispatched when verifier is nil or accepts them.
+func PerformHandlerWithJSON(
+	rawJSON []byte,
+	handler HandlerFunc,
+	verifier RequestVerifier,
+	maxResponseLength int,
+	logger boshlog.Logger,
+) ([]byte, Request, error) {
 	var request Request
 
 	err := json.Unmarshal(rawJSON, &request)
@@ -22,16 +39,42 @@ func PerformHandlerWithJSON(rawJSON []byte, handler HandlerFunc, maxResponseLeng
 	}
 
 	request.Payload = rawJSON
+	request.CorrelationID = buildCorrelationID(request.RequestID, logger)
+
+	logger = logger.WithCorrelationID(request.CorrelationID)
 
 	logger.Info(mbusHandlerLogTag, "Received request with action %s", request.Method)
 	logger.DebugWithDetails(mbusHandlerLogTag, "Payload", request.Payload)
 
-	response := handler(request)
+	var response Response
+
+	if verifier != nil {
+		err = verifier.Verify(request)
+		if err != nil {
+			logger.Error(
+				requestAuditLogTag,
+				"Rejected request with action %s, reply_to '%s', key_id '%s': %s",
+				request.Method, request.ReplyTo, request.KeyID, err.Error(),
+			)
+			response = NewTypedExceptionResponse(ExceptionTypeUnauthorizedRequest, bosherr.WrapError(err, "Verifying request"))
+		}
+	}
+
+	if response == nil {
+		response = handler(request)
+	}
+
 	if response == nil {
 		logger.Info(mbusHandlerLogTag, "Nil response returned from handler")
 		return []byte{}, request, nil
 	}
 
+	response = withCorrelationID(response, request.CorrelationID)
+
+	if request.ChunkedReplies {
+		maxResponseLength = UnlimitedResponseLength
+	}
+
 	respJSON, err := marshalResponse(response, maxResponseLength, logger)
 	if err != nil {
 		return respJSON, request, err
@@ -85,3 +128,23 @@ func marshalResponse(response Response, maxResponseLength int, logger boshlog.Lo
 
 	return respJSON, nil
 }
+
+// buildCorrelationID uses request ID provided by director when possible
+func buildCorrelationID(requestID string, logger boshlog.Logger) string {
+	correlationID := correlationIDUnsafeChars.ReplaceAllString(requestID, "")
+
+	if len(correlationID) > maxCorrelationIDLength {
+		correlationID = correlationID[:maxCorrelationIDLength]
+	}
+
+	if correlationID != "" {
+		return correlationID
+	}
+
+	correlationID, err := boshuuid.NewGenerator().Generate()
+	if err != nil {
+		logger.Error(mbusHandlerLogTag, "Generating correlation ID: %s", err.Error())
+	}
+
+	return correlationID
+}
@@ -0,0 +1,74 @@
+package handler_test
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/handler"
+	boshlog "bosh/logger"
+)
+
+var _ = Describe("PerformHandlerWithJSON", func() {
+	var (
+		logger          boshlog.Logger
+		handledRequests []Request
+		response        Response
+	)
+
+	handler := func(req Request) Response {
+		handledRequests = append(handledRequests, req)
+		return response
+	}
+
+	BeforeEach(func() {
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		handledRequests = []Request{}
+		response = NewValueResponse("fake-value")
+	})
+
+	It("uses request id as correlation id", func() {
+		_, req, err := PerformHandlerWithJSON(
+			[]byte(`{"method":"ping","arguments":[],"request_id":"fake-request-id"}`),
+			handler, nil, UnlimitedResponseLength, logger,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(req.RequestID).To(Equal("fake-request-id"))
+		Expect(req.CorrelationID).To(Equal("fake-request-id"))
+		Expect(handledRequests[0].CorrelationID).To(Equal("fake-request-id"))
+	})
+
+	It("removes unsafe characters from request id and limits its length", func() {
+		requestID := "fake request;id\n" + strings.Repeat("a", 100)
+
+		_, req, err := PerformHandlerWithJSON(
+			[]byte(`{"method":"ping","arguments":[],"request_id":"`+strings.Replace(requestID, "\n", `\n`, -1)+`"}`),
+			handler, nil, UnlimitedResponseLength, logger,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(req.CorrelationID).To(HavePrefix("fakerequestid"))
+		Expect(req.CorrelationID).To(HaveLen(64))
+	})
+
+	It("includes correlation id in exception responses", func() {
+		response = NewExceptionResponse(errors.New("fake-error"))
+
+		respJSON, _, err := PerformHandlerWithJSON(
+			[]byte(`{"method":"ping","arguments":[],"request_id":"fake-request-id"}`),
+			handler, nil, UnlimitedResponseLength, logger,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(respJSON)).To(Equal(`{"exception":{"message":"fake-error","correlation_id":"fake-request-id"}}`))
+	})
+
+	It("does not include correlation id in value responses", func() {
+		respJSON, _, err := PerformHandlerWithJSON(
+			[]byte(`{"method":"ping","arguments":[],"request_id":"fake-request-id"}`),
+			handler, nil, UnlimitedResponseLength, logger,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(respJSON)).To(Equal(`{"value":"fake-value"}`))
+	})
+})
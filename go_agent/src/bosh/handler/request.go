@@ -12,6 +12,21 @@ type Request struct {
 	ReplyTo string `json:"reply_to"`
 	Method  string
 	Payload []byte
+
+	// Set by directors that can reassemble chunked replies
+	ChunkedReplies bool `json:"chunked_replies"`
+
+	// Optionally set by directors to relate agent logs to their requests
+	RequestID string `json:"request_id"`
+
+	// Derived from RequestID or generated when request is received
+	CorrelationID string `json:"-"`
+
+	// Set by directors that sign requests
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	KeyID     string `json:"key_id"`
+	Signature string `json:"signature"`
 }
 
 func (r Request) GetPayload() []byte {
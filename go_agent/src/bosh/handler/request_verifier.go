@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bosherr "bosh/errors"
+	boshsettings "bosh/settings"
+	boshtime "bosh/time"
+)
+
+const defaultMaxClockSkew = 5 * time.Minute
+
+type RequestVerifier interface {
+	// Verify returns an error if request must not be dispatched
+	Verify(request Request) error
+}
+
+type hmacRequestVerifier struct {
+	keys         map[string][]byte
+	maxClockSkew time.Duration
+	timeService  boshtime.Service
+
+	// Expiration times of seen nonces; expired nonces
+	// are rejected by timestamp check so they can be forgotten
+	nonces     map[string]time.Time
+	noncesLock sync.Mutex
+}
+
+// NewHMACRequestVerifier returns nil verifier when no keys are configured
+func NewHMACRequestVerifier(signing boshsettings.RequestSigning, timeService boshtime.Service) (RequestVerifier, error) {
+	if len(signing.Keys) == 0 {
+		return nil, nil
+	}
+
+	keys := map[string][]byte{}
+
+	for keyID, encodedKey := range signing.Keys {
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Decoding request signing key '%s'", keyID)
+		}
+
+		if len(key) == 0 {
+			return nil, bosherr.New("Request signing key '%s' is empty", keyID)
+		}
+
+		keys[keyID] = key
+	}
+
+	maxClockSkew := time.Duration(signing.MaxClockSkewSeconds) * time.Second
+	if maxClockSkew <= 0 {
+		maxClockSkew = defaultMaxClockSkew
+	}
+
+	return &hmacRequestVerifier{
+		keys:         keys,
+		maxClockSkew: maxClockSkew,
+		timeService:  timeService,
+		nonces:       map[string]time.Time{},
+	}, nil
+}
+
+func (v *hmacRequestVerifier) Verify(request Request) error {
+	if request.Signature == "" {
+		return bosherr.New("Request is not signed")
+	}
+
+	key, found := v.keys[request.KeyID]
+	if !found {
+		return bosherr.New("Unknown signing key '%s'", request.KeyID)
+	}
+
+	if request.Nonce == "" {
+		return bosherr.New("Request nonce is missing")
+	}
+
+	signature, err := hex.DecodeString(request.Signature)
+	if err != nil {
+		return bosherr.New("Invalid request signature")
+	}
+
+	signedString, err := RequestSignedString(request)
+	if err != nil {
+		return bosherr.WrapError(err, "Building signed string")
+	}
+
+	if !hmac.Equal(signature, computeHMAC(key, signedString)) {
+		return bosherr.New("Invalid request signature")
+	}
+
+	now := v.timeService.Now()
+	timestamp := time.Unix(request.Timestamp, 0)
+
+	if timestamp.Before(now.Add(-v.maxClockSkew)) || timestamp.After(now.Add(v.maxClockSkew)) {
+		return bosherr.New("Request timestamp is outside of allowed clock skew")
+	}
+
+	return v.useNonce(request.Nonce, timestamp.Add(v.maxClockSkew), now)
+}
+
+func (v *hmacRequestVerifier) useNonce(nonce string, expiresAt, now time.Time) error {
+	v.noncesLock.Lock()
+	defer v.noncesLock.Unlock()
+
+	for seenNonce, seenExpiresAt := range v.nonces {
+		if seenExpiresAt.Before(now) {
+			delete(v.nonces, seenNonce)
+		}
+	}
+
+	if _, found := v.nonces[nonce]; found {
+		return bosherr.New("Request nonce '%s' was already used", nonce)
+	}
+
+	v.nonces[nonce] = expiresAt
+
+	return nil
+}
+
+// RequestSignedString returns the string signed by the director:
+// method, reply_to, timestamp, nonce and raw JSON arguments separated by new lines
+func RequestSignedString(request Request) (string, error) {
+	var payload struct {
+		Arguments json.RawMessage `json:"arguments"`
+	}
+
+	err := json.Unmarshal(request.Payload, &payload)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Unmarshalling arguments")
+	}
+
+	return strings.Join([]string{
+		request.Method,
+		request.ReplyTo,
+		strconv.FormatInt(request.Timestamp, 10),
+		request.Nonce,
+		string(payload.Arguments),
+	}, "\n"), nil
+}
+
+// SignRequestString returns hex encoded HMAC-SHA256 signature
+func SignRequestString(key []byte, signedString string) string {
+	return hex.EncodeToString(computeHMAC(key, signedString))
+}
+
+func computeHMAC(key []byte, signedString string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signedString))
+	return mac.Sum(nil)
+}
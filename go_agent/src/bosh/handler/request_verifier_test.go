@@ -0,0 +1,134 @@
+package handler_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/handler"
+	boshsettings "bosh/settings"
+	faketime "bosh/time/fakes"
+)
+
+var _ = Describe("hmacRequestVerifier", func() {
+	var (
+		key         []byte
+		timeService *faketime.FakeService
+		verifier    RequestVerifier
+	)
+
+	buildRequest := func(timestamp int64, nonce string) Request {
+		payload, err := json.Marshal(map[string]interface{}{
+			"method":    "ssh",
+			"arguments": []string{"fake-arg"},
+			"reply_to":  "fake-reply-to",
+			"timestamp": timestamp,
+			"nonce":     nonce,
+			"key_id":    "fake-key-id",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		var request Request
+		err = json.Unmarshal(payload, &request)
+		Expect(err).ToNot(HaveOccurred())
+
+		request.Payload = payload
+		return request
+	}
+
+	signRequest := func(request Request) Request {
+		signedString, err := RequestSignedString(request)
+		Expect(err).ToNot(HaveOccurred())
+
+		request.Signature = SignRequestString(key, signedString)
+		return request
+	}
+
+	BeforeEach(func() {
+		key = []byte("fake-key")
+		timeService = &faketime.FakeService{NowTime: time.Unix(1000000, 0)}
+
+		var err error
+		verifier, err = NewHMACRequestVerifier(boshsettings.RequestSigning{
+			Keys: map[string]string{"fake-key-id": base64.StdEncoding.EncodeToString(key)},
+		}, timeService)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("NewHMACRequestVerifier", func() {
+		It("returns nil verifier when no keys are configured", func() {
+			verifier, err := NewHMACRequestVerifier(boshsettings.RequestSigning{}, timeService)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(verifier).To(BeNil())
+		})
+
+		It("returns error when key is not base64 encoded", func() {
+			_, err := NewHMACRequestVerifier(boshsettings.RequestSigning{
+				Keys: map[string]string{"fake-key-id": "fake-invalid-base64!"},
+			}, timeService)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Decoding request signing key 'fake-key-id'"))
+		})
+	})
+
+	Describe("Verify", func() {
+		It("accepts correctly signed fresh request", func() {
+			err := verifier.Verify(signRequest(buildRequest(1000000, "fake-nonce")))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("signs method, reply_to, timestamp, nonce and arguments", func() {
+			signedString, err := RequestSignedString(buildRequest(1000000, "fake-nonce"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signedString).To(Equal("ssh\nfake-reply-to\n1000000\nfake-nonce\n[\"fake-arg\"]"))
+		})
+
+		It("rejects unsigned request", func() {
+			err := verifier.Verify(buildRequest(1000000, "fake-nonce"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Request is not signed"))
+		})
+
+		It("rejects request signed with unknown key", func() {
+			request := signRequest(buildRequest(1000000, "fake-nonce"))
+			request.KeyID = "fake-unknown-key-id"
+
+			err := verifier.Verify(request)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Unknown signing key 'fake-unknown-key-id'"))
+		})
+
+		It("rejects request with invalid signature", func() {
+			request := signRequest(buildRequest(1000000, "fake-nonce"))
+			request.Method = "apply"
+
+			err := verifier.Verify(request)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Invalid request signature"))
+		})
+
+		It("rejects request with timestamp outside of allowed clock skew", func() {
+			err := verifier.Verify(signRequest(buildRequest(1000000-301, "fake-nonce")))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Request timestamp is outside of allowed clock skew"))
+
+			err = verifier.Verify(signRequest(buildRequest(1000000+301, "fake-nonce")))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Request timestamp is outside of allowed clock skew"))
+		})
+
+		It("rejects replayed request", func() {
+			request := signRequest(buildRequest(1000000, "fake-nonce"))
+
+			err := verifier.Verify(request)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = verifier.Verify(request)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Request nonce 'fake-nonce' was already used"))
+		})
+	})
+})
@@ -22,9 +22,17 @@ func (r valueResponse) Shorten() Response {
 	return r
 }
 
+const (
+	// Exception type returned for requests that fail signature verification
+	ExceptionTypeUnauthorizedRequest = "unauthorized_request"
+)
+
 type exceptionResponse struct {
 	Exception struct {
 		Message string `json:"message,omitempty"`
+		Type    string `json:"type,omitempty"`
+
+		CorrelationID string `json:"correlation_id,omitempty"`
 	} `json:"exception"`
 
 	err error
@@ -37,13 +45,35 @@ func NewExceptionResponse(err error) (resp Response) {
 	return r
 }
 
+// NewTypedExceptionResponse allows clients to tell apart exceptions
+// raised before the action was dispatched
+func NewTypedExceptionResponse(exceptionType string, err error) Response {
+	r := exceptionResponse{}
+	r.Exception.Message = err.Error()
+	r.Exception.Type = exceptionType
+	r.err = err
+	return r
+}
+
 func (r exceptionResponse) Shorten() Response {
 	if typedErr, ok := r.err.(bosherr.ShortenableError); ok {
 		sr := exceptionResponse{}
 		sr.Exception.Message = typedErr.ShortError()
+		sr.Exception.Type = r.Exception.Type
+		sr.Exception.CorrelationID = r.Exception.CorrelationID
 		sr.err = typedErr
 		return sr
 	}
 
 	return r
 }
+
+// withCorrelationID includes correlation ID in exception responses
+func withCorrelationID(response Response, correlationID string) Response {
+	if r, ok := response.(exceptionResponse); ok {
+		r.Exception.CorrelationID = correlationID
+		return r
+	}
+
+	return response
+}
@@ -92,3 +92,16 @@ var _ = Describe("NewExceptionResponse", func() {
 		})
 	})
 })
+
+var _ = Describe("NewTypedExceptionResponse", func() {
+	It("can be serialized to JSON", func() {
+		resp := NewTypedExceptionResponse("fake-type", errors.New("fake-msg"))
+		boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"fake-msg","type":"fake-type"}}`)
+	})
+
+	It("keeps exception type when shortened", func() {
+		err := &testShortError{fullMsg: "fake-full-msg", shortMsgs: []string{"fake-short-msg"}}
+		resp := NewTypedExceptionResponse("fake-type", err)
+		boshassert.MatchesJSONString(GinkgoT(), resp.Shorten(), `{"exception":{"message":"fake-short-msg","type":"fake-type"}}`)
+	})
+})
@@ -2,6 +2,7 @@ package httpsdispatcher
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"net"
 	"net/http"
 	"net/url"
@@ -10,17 +11,41 @@ import (
 	boshlog "bosh/logger"
 )
 
+const (
+	defaultCertificatePath = "agent.cert"
+	defaultPrivateKeyPath  = "agent.key"
+)
+
+// Only forward secret AEAD cipher suites are allowed
+var cipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+}
+
+// TLSOptions holds PEM encoded certificates used by the dispatcher
+type TLSOptions struct {
+	// agent.cert and agent.key from working directory are used when empty
+	Certificate string
+	PrivateKey  string
+
+	// When set clients must present certificate signed by one of these CAs
+	ClientCA string
+}
+
 type HTTPSDispatcher struct {
 	logger     boshlog.Logger
 	httpServer *http.Server
 	mux        *http.ServeMux
 	listener   net.Listener
+	listenErr  error
+	tlsOptions TLSOptions
 }
 
 type HTTPHandlerFunc func(writer http.ResponseWriter, request *http.Request)
 
-func NewHTTPSDispatcher(baseURL *url.URL, logger boshlog.Logger) (dispatcher HTTPSDispatcher) {
+func NewHTTPSDispatcher(baseURL *url.URL, tlsOptions TLSOptions, logger boshlog.Logger) (dispatcher HTTPSDispatcher) {
 	dispatcher.logger = logger
+	dispatcher.tlsOptions = tlsOptions
 
 	dispatcher.httpServer = &http.Server{}
 	dispatcher.mux = http.NewServeMux()
@@ -28,7 +53,7 @@ func NewHTTPSDispatcher(baseURL *url.URL, logger boshlog.Logger) (dispatcher HTT
 
 	listener, err := net.Listen("tcp", baseURL.Host)
 	if err != nil {
-		err = bosherr.WrapError(err, "Create HTTP listener")
+		dispatcher.listenErr = bosherr.WrapError(err, "Create HTTP listener")
 		return
 	}
 	dispatcher.listener = listener
@@ -36,15 +61,29 @@ func NewHTTPSDispatcher(baseURL *url.URL, logger boshlog.Logger) (dispatcher HTT
 	return
 }
 
-func (h HTTPSDispatcher) Start() error {
-	cert, err := tls.LoadX509KeyPair("agent.cert", "agent.key")
+// Validate reports errors that would make Start fail right away
+func (h HTTPSDispatcher) Validate() error {
+	if h.listenErr != nil {
+		return h.listenErr
+	}
+
+	_, err := h.tlsConfig()
 	if err != nil {
-		return bosherr.WrapError(err, "creating cert")
+		return bosherr.WrapError(err, "Building TLS config")
 	}
 
-	config := &tls.Config{}
-	config.NextProtos = []string{"http/1.1"}
-	config.Certificates = []tls.Certificate{cert}
+	return nil
+}
+
+func (h HTTPSDispatcher) Start() error {
+	if h.listenErr != nil {
+		return h.listenErr
+	}
+
+	config, err := h.tlsConfig()
+	if err != nil {
+		return bosherr.WrapError(err, "Building TLS config")
+	}
 
 	tlsListener := tls.NewListener(h.listener, config)
 
@@ -52,6 +91,10 @@ func (h HTTPSDispatcher) Start() error {
 }
 
 func (h *HTTPSDispatcher) Stop() {
+	if h.listener == nil {
+		return
+	}
+
 	h.listener.Close()
 	return
 }
@@ -59,3 +102,36 @@ func (h *HTTPSDispatcher) Stop() {
 func (h HTTPSDispatcher) AddRoute(route string, handler HTTPHandlerFunc) {
 	h.mux.HandleFunc(route, handler)
 }
+
+func (h HTTPSDispatcher) tlsConfig() (*tls.Config, error) {
+	var cert tls.Certificate
+	var err error
+
+	if h.tlsOptions.Certificate == "" && h.tlsOptions.PrivateKey == "" {
+		cert, err = tls.LoadX509KeyPair(defaultCertificatePath, defaultPrivateKeyPath)
+	} else {
+		cert, err = tls.X509KeyPair([]byte(h.tlsOptions.Certificate), []byte(h.tlsOptions.PrivateKey))
+	}
+	if err != nil {
+		return nil, bosherr.WrapError(err, "creating cert")
+	}
+
+	config := &tls.Config{}
+	config.NextProtos = []string{"http/1.1"}
+	config.Certificates = []tls.Certificate{cert}
+	config.MinVersion = tls.VersionTLS12
+	config.CipherSuites = cipherSuites
+	config.PreferServerCipherSuites = true
+
+	if h.tlsOptions.ClientCA != "" {
+		config.ClientCAs = x509.NewCertPool()
+
+		if !config.ClientCAs.AppendCertsFromPEM([]byte(h.tlsOptions.ClientCA)) {
+			return nil, bosherr.New("Parsing client CA certificates")
+		}
+
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
@@ -2,6 +2,8 @@ package httpsdispatcher_test
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"net"
 	"net/http"
 	"net/url"
 	"time"
@@ -9,6 +11,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	testcrypto "bosh/crypto/testing"
 	boshdispatcher "bosh/httpsdispatcher"
 	boshlog "bosh/logger"
 )
@@ -21,7 +24,7 @@ var _ = Describe("HTTPSDispatcher", func() {
 	BeforeEach(func() {
 		serverURL, _ := url.Parse("https://127.0.0.1:7788")
 		logger := boshlog.NewLogger(boshlog.LevelNone)
-		dispatcher = boshdispatcher.NewHTTPSDispatcher(serverURL, logger)
+		dispatcher = boshdispatcher.NewHTTPSDispatcher(serverURL, boshdispatcher.TLSOptions{}, logger)
 		go dispatcher.Start()
 		time.Sleep(1 * time.Second)
 	})
@@ -55,6 +58,120 @@ var _ = Describe("HTTPSDispatcher", func() {
 	})
 })
 
+var _ = Describe("HTTPSDispatcher with configured certificates", func() {
+	var (
+		ca         testcrypto.Certificate
+		serverCert testcrypto.Certificate
+		dispatcher boshdispatcher.HTTPSDispatcher
+	)
+
+	BeforeEach(func() {
+		ca = testcrypto.GenerateCertificate("fake-ca", nil)
+		serverCert = testcrypto.GenerateCertificate("127.0.0.1", &ca)
+
+		serverURL, _ := url.Parse("https://127.0.0.1:7789")
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		tlsOptions := boshdispatcher.TLSOptions{
+			Certificate: serverCert.CertPEM,
+			PrivateKey:  serverCert.KeyPEM,
+			ClientCA:    ca.CertPEM,
+		}
+		dispatcher = boshdispatcher.NewHTTPSDispatcher(serverURL, tlsOptions, logger)
+		dispatcher.AddRoute("/example", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(201)
+		})
+		go dispatcher.Start()
+		time.Sleep(1 * time.Second)
+	})
+
+	AfterEach(func() {
+		dispatcher.Stop()
+		time.Sleep(1 * time.Second)
+	})
+
+	clientFor := func(config *tls.Config) http.Client {
+		config.RootCAs = x509.NewCertPool()
+		config.RootCAs.AppendCertsFromPEM([]byte(ca.CertPEM))
+		return http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	}
+
+	It("accepts clients presenting certificate signed by client CA", func() {
+		clientCert := testcrypto.GenerateCertificate("fake-client", &ca)
+		client := clientFor(&tls.Config{Certificates: []tls.Certificate{clientCert.TLSCertificate()}})
+
+		response, err := client.Get("https://127.0.0.1:7789/example")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.StatusCode).To(Equal(201))
+	})
+
+	It("rejects clients without certificate", func() {
+		client := clientFor(&tls.Config{})
+
+		_, err := client.Get("https://127.0.0.1:7789/example")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects clients presenting certificate signed by another CA", func() {
+		otherCA := testcrypto.GenerateCertificate("fake-other-ca", nil)
+		clientCert := testcrypto.GenerateCertificate("fake-client", &otherCA)
+		client := clientFor(&tls.Config{Certificates: []tls.Certificate{clientCert.TLSCertificate()}})
+
+		_, err := client.Get("https://127.0.0.1:7789/example")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects clients that do not support TLS 1.2", func() {
+		clientCert := testcrypto.GenerateCertificate("fake-client", &ca)
+		client := clientFor(&tls.Config{
+			Certificates: []tls.Certificate{clientCert.TLSCertificate()},
+			MaxVersion:   tls.VersionTLS11,
+		})
+
+		_, err := client.Get("https://127.0.0.1:7789/example")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("HTTPSDispatcher with invalid certificates", func() {
+	It("returns error instead of serving", func() {
+		ca := testcrypto.GenerateCertificate("fake-ca", nil)
+		serverCert := testcrypto.GenerateCertificate("127.0.0.1", &ca)
+
+		serverURL, _ := url.Parse("https://127.0.0.1:7790")
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		tlsOptions := boshdispatcher.TLSOptions{
+			Certificate: serverCert.CertPEM,
+			PrivateKey:  ca.KeyPEM,
+		}
+		dispatcher := boshdispatcher.NewHTTPSDispatcher(serverURL, tlsOptions, logger)
+		defer dispatcher.Stop()
+
+		err := dispatcher.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Building TLS config"))
+
+		err = dispatcher.Start()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Building TLS config"))
+	})
+
+	It("returns error when address cannot be listened on", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		defer listener.Close()
+
+		serverURL := &url.URL{Scheme: "https", Host: listener.Addr().String()}
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		dispatcher := boshdispatcher.NewHTTPSDispatcher(serverURL, boshdispatcher.TLSOptions{}, logger)
+		defer dispatcher.Stop()
+
+		err = dispatcher.Start()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Create HTTP listener"))
+	})
+})
+
 func getHTTPClient() (httpClient http.Client) {
 	httpTransport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
 	httpClient = http.Client{Transport: httpTransport}
@@ -0,0 +1,15 @@
+package jobmetrics
+
+type Options struct {
+	// Maximum number of metrics published per job; defaults to 100
+	MaxMetricsPerJob int
+
+	// Metrics older than this are ignored; defaults to 600
+	MaxAgeSeconds int
+}
+
+type Collector interface {
+	// Collect returns metrics published by all jobs
+	// with names prefixed by the name of the publishing job
+	Collect() []Metric
+}
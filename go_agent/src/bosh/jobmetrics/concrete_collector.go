@@ -0,0 +1,215 @@
+package jobmetrics
+
+import (
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshdirs "bosh/settings/directories"
+	boshsys "bosh/system"
+	boshtime "bosh/time"
+)
+
+const (
+	collectorLogTag = "jobMetricsCollector"
+
+	defaultMaxMetricsPerJob = 100
+	defaultMaxAgeSeconds    = 600
+
+	// Allows for clocks of jobs and agent being slightly apart
+	maxFutureSeconds = 60
+)
+
+var metricNameRegexp = regexp.MustCompile(`\A[a-zA-Z_][a-zA-Z0-9_.]*\z`)
+
+// Jobs publish metrics by writing JSON files to
+// <base-dir>/sys/run/<job>/metrics/*.json.
+// Each file contains a list of metrics.
+// Counters with the same name are summed across files;
+// for gauges the most recently observed value is used.
+type concreteCollector struct {
+	fs          boshsys.FileSystem
+	dirProvider boshdirs.DirectoriesProvider
+	timeService boshtime.Service
+	options     Options
+	logger      boshlog.Logger
+}
+
+func NewConcreteCollector(
+	fs boshsys.FileSystem,
+	dirProvider boshdirs.DirectoriesProvider,
+	timeService boshtime.Service,
+	options Options,
+	logger boshlog.Logger,
+) concreteCollector {
+	if options.MaxMetricsPerJob <= 0 {
+		options.MaxMetricsPerJob = defaultMaxMetricsPerJob
+	}
+
+	if options.MaxAgeSeconds <= 0 {
+		options.MaxAgeSeconds = defaultMaxAgeSeconds
+	}
+
+	return concreteCollector{
+		fs:          fs,
+		dirProvider: dirProvider,
+		timeService: timeService,
+		options:     options,
+		logger:      logger,
+	}
+}
+
+func (c concreteCollector) Collect() []Metric {
+	metrics := []Metric{}
+
+	paths, err := c.fs.Glob(filepath.Join(c.dirProvider.SysRunDir(), "*", "metrics", "*.json"))
+	if err != nil {
+		c.logger.Error(collectorLogTag, "Failed to find job metrics: %s", err.Error())
+		return metrics
+	}
+
+	pathsByJob := map[string][]string{}
+
+	for _, path := range paths {
+		jobName := filepath.Base(filepath.Dir(filepath.Dir(path)))
+		pathsByJob[jobName] = append(pathsByJob[jobName], path)
+	}
+
+	jobNames := []string{}
+	for jobName := range pathsByJob {
+		jobNames = append(jobNames, jobName)
+	}
+
+	sort.Strings(jobNames)
+
+	for _, jobName := range jobNames {
+		for _, metric := range c.collectJob(jobName, pathsByJob[jobName]) {
+			metric.Name = jobName + "." + metric.Name
+			metrics = append(metrics, metric)
+		}
+	}
+
+	return metrics
+}
+
+func (c concreteCollector) collectJob(jobName string, paths []string) []Metric {
+	aggregated := map[string]Metric{}
+
+	for _, path := range paths {
+		metrics, err := c.readMetrics(path)
+		if err != nil {
+			c.logger.Error(collectorLogTag, "Failed to read metrics for job %s: %s", jobName, err.Error())
+			continue
+		}
+
+		for _, metric := range metrics {
+			err = c.validateMetric(metric)
+			if err != nil {
+				c.logger.Error(collectorLogTag, "Ignoring metric from %s: %s", path, err.Error())
+				continue
+			}
+
+			existing, found := aggregated[metric.Name]
+			if !found {
+				aggregated[metric.Name] = metric
+				continue
+			}
+
+			if existing.Type != metric.Type {
+				c.logger.Error(collectorLogTag, "Ignoring metric %s from %s: conflicting type", metric.Name, path)
+				continue
+			}
+
+			aggregated[metric.Name] = c.aggregate(existing, metric)
+		}
+	}
+
+	names := []string{}
+	for name := range aggregated {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	if len(names) > c.options.MaxMetricsPerJob {
+		c.logger.Error(
+			collectorLogTag,
+			"Job %s published %d metrics, only first %d are used",
+			jobName, len(names), c.options.MaxMetricsPerJob,
+		)
+		names = names[:c.options.MaxMetricsPerJob]
+	}
+
+	metrics := []Metric{}
+	for _, name := range names {
+		metrics = append(metrics, aggregated[name])
+	}
+
+	return metrics
+}
+
+func (c concreteCollector) readMetrics(path string) ([]Metric, error) {
+	bytes, err := c.fs.ReadFile(path)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading metrics file %s", path)
+	}
+
+	var metrics []Metric
+
+	err = json.Unmarshal(bytes, &metrics)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling metrics file %s", path)
+	}
+
+	return metrics, nil
+}
+
+func (c concreteCollector) validateMetric(metric Metric) error {
+	if !metricNameRegexp.MatchString(metric.Name) {
+		return bosherr.New("Invalid metric name '%s'", metric.Name)
+	}
+
+	if metric.Type != MetricTypeGauge && metric.Type != MetricTypeCounter {
+		return bosherr.New("Invalid type '%s' for metric %s", metric.Type, metric.Name)
+	}
+
+	if math.IsNaN(metric.Value) || math.IsInf(metric.Value, 0) {
+		return bosherr.New("Invalid value for metric %s", metric.Name)
+	}
+
+	if metric.Type == MetricTypeCounter && metric.Value < 0 {
+		return bosherr.New("Negative value for counter %s", metric.Name)
+	}
+
+	age := c.timeService.Now().Unix() - metric.Timestamp
+	if metric.Timestamp <= 0 || age > int64(c.options.MaxAgeSeconds) {
+		return bosherr.New("Stale value for metric %s", metric.Name)
+	}
+
+	// Otherwise metric would never become stale
+	if age < -maxFutureSeconds {
+		return bosherr.New("Timestamp in the future for metric %s", metric.Name)
+	}
+
+	return nil
+}
+
+func (c concreteCollector) aggregate(existing, metric Metric) Metric {
+	if metric.Type == MetricTypeCounter {
+		existing.Value += metric.Value
+		if metric.Timestamp > existing.Timestamp {
+			existing.Timestamp = metric.Timestamp
+		}
+		return existing
+	}
+
+	if metric.Timestamp > existing.Timestamp {
+		return metric
+	}
+
+	return existing
+}
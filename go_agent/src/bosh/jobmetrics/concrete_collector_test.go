@@ -0,0 +1,173 @@
+package jobmetrics_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/jobmetrics"
+	boshlog "bosh/logger"
+	boshdirs "bosh/settings/directories"
+	fakesys "bosh/system/fakes"
+	faketime "bosh/time/fakes"
+)
+
+var _ = Describe("concreteCollector", func() {
+	const globPattern = "/fake-base-dir/sys/run/*/metrics/*.json"
+
+	var (
+		fs          *fakesys.FakeFileSystem
+		timeService *faketime.FakeService
+		options     Options
+		collector   Collector
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		timeService = &faketime.FakeService{NowTime: time.Unix(1000, 0)}
+		options = Options{}
+	})
+
+	JustBeforeEach(func() {
+		collector = NewConcreteCollector(
+			fs,
+			boshdirs.NewDirectoriesProvider("/fake-base-dir"),
+			timeService,
+			options,
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+	})
+
+	writeMetrics := func(path, content string) {
+		err := fs.WriteFileString(path, content)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	It("returns metrics prefixed with job name", func() {
+		fs.SetGlob(globPattern, []string{
+			"/fake-base-dir/sys/run/job-b/metrics/b.json",
+			"/fake-base-dir/sys/run/job-a/metrics/a.json",
+		})
+		writeMetrics("/fake-base-dir/sys/run/job-a/metrics/a.json", `[
+			{"name": "requests", "type": "counter", "value": 10, "timestamp": 990},
+			{"name": "queue.depth", "type": "gauge", "value": 2.5, "timestamp": 995}
+		]`)
+		writeMetrics("/fake-base-dir/sys/run/job-b/metrics/b.json", `[
+			{"name": "connections", "type": "gauge", "value": 3, "timestamp": 1000}
+		]`)
+
+		Expect(collector.Collect()).To(Equal([]Metric{
+			Metric{Name: "job-a.queue.depth", Type: "gauge", Value: 2.5, Timestamp: 995},
+			Metric{Name: "job-a.requests", Type: "counter", Value: 10, Timestamp: 990},
+			Metric{Name: "job-b.connections", Type: "gauge", Value: 3, Timestamp: 1000},
+		}))
+	})
+
+	It("sums counters and keeps most recent gauges across files of the same job", func() {
+		fs.SetGlob(globPattern, []string{
+			"/fake-base-dir/sys/run/job/metrics/1.json",
+			"/fake-base-dir/sys/run/job/metrics/2.json",
+		})
+		writeMetrics("/fake-base-dir/sys/run/job/metrics/1.json", `[
+			{"name": "requests", "type": "counter", "value": 10, "timestamp": 990},
+			{"name": "temperature", "type": "gauge", "value": 20, "timestamp": 999}
+		]`)
+		writeMetrics("/fake-base-dir/sys/run/job/metrics/2.json", `[
+			{"name": "requests", "type": "counter", "value": 5, "timestamp": 995},
+			{"name": "temperature", "type": "gauge", "value": 25, "timestamp": 998}
+		]`)
+
+		Expect(collector.Collect()).To(Equal([]Metric{
+			Metric{Name: "job.requests", Type: "counter", Value: 15, Timestamp: 995},
+			Metric{Name: "job.temperature", Type: "gauge", Value: 20, Timestamp: 999},
+		}))
+	})
+
+	It("ignores invalid, stale and conflicting metrics", func() {
+		fs.SetGlob(globPattern, []string{"/fake-base-dir/sys/run/job/metrics/m.json"})
+		writeMetrics("/fake-base-dir/sys/run/job/metrics/m.json", `[
+			{"name": "valid", "type": "gauge", "value": 1, "timestamp": 1000},
+			{"name": "valid", "type": "counter", "value": 1, "timestamp": 1000},
+			{"name": "bad name", "type": "gauge", "value": 1, "timestamp": 1000},
+			{"name": "bad_type", "type": "histogram", "value": 1, "timestamp": 1000},
+			{"name": "negative_counter", "type": "counter", "value": -1, "timestamp": 1000},
+			{"name": "no_timestamp", "type": "gauge", "value": 1},
+			{"name": "stale", "type": "gauge", "value": 1, "timestamp": 399}
+		]`)
+
+		Expect(collector.Collect()).To(Equal([]Metric{
+			Metric{Name: "job.valid", Type: "gauge", Value: 1, Timestamp: 1000},
+		}))
+	})
+
+	It("ignores metrics with timestamps too far in the future", func() {
+		fs.SetGlob(globPattern, []string{"/fake-base-dir/sys/run/job/metrics/m.json"})
+		writeMetrics("/fake-base-dir/sys/run/job/metrics/m.json", `[
+			{"name": "slightly_ahead", "type": "gauge", "value": 1, "timestamp": 1060},
+			{"name": "future", "type": "gauge", "value": 1, "timestamp": 1061},
+			{"name": "far_future", "type": "gauge", "value": 1, "timestamp": 4102444800}
+		]`)
+
+		Expect(collector.Collect()).To(Equal([]Metric{
+			Metric{Name: "job.slightly_ahead", Type: "gauge", Value: 1, Timestamp: 1060},
+		}))
+	})
+
+	It("ignores files that cannot be parsed", func() {
+		fs.SetGlob(globPattern, []string{
+			"/fake-base-dir/sys/run/job/metrics/bad.json",
+			"/fake-base-dir/sys/run/job/metrics/good.json",
+		})
+		writeMetrics("/fake-base-dir/sys/run/job/metrics/bad.json", `fake-invalid-json`)
+		writeMetrics("/fake-base-dir/sys/run/job/metrics/good.json", `[
+			{"name": "valid", "type": "gauge", "value": 1, "timestamp": 1000}
+		]`)
+
+		Expect(collector.Collect()).To(Equal([]Metric{
+			Metric{Name: "job.valid", Type: "gauge", Value: 1, Timestamp: 1000},
+		}))
+	})
+
+	Context("when max age is configured", func() {
+		BeforeEach(func() {
+			options.MaxAgeSeconds = 10
+		})
+
+		It("ignores metrics older than max age", func() {
+			fs.SetGlob(globPattern, []string{"/fake-base-dir/sys/run/job/metrics/m.json"})
+			writeMetrics("/fake-base-dir/sys/run/job/metrics/m.json", `[
+				{"name": "fresh", "type": "gauge", "value": 1, "timestamp": 990},
+				{"name": "stale", "type": "gauge", "value": 1, "timestamp": 989}
+			]`)
+
+			Expect(collector.Collect()).To(Equal([]Metric{
+				Metric{Name: "job.fresh", Type: "gauge", Value: 1, Timestamp: 990},
+			}))
+		})
+	})
+
+	Context("when max metrics per job is configured", func() {
+		BeforeEach(func() {
+			options.MaxMetricsPerJob = 2
+		})
+
+		It("only returns first metrics by name", func() {
+			fs.SetGlob(globPattern, []string{"/fake-base-dir/sys/run/job/metrics/m.json"})
+			writeMetrics("/fake-base-dir/sys/run/job/metrics/m.json", `[
+				{"name": "c", "type": "gauge", "value": 3, "timestamp": 1000},
+				{"name": "a", "type": "gauge", "value": 1, "timestamp": 1000},
+				{"name": "b", "type": "gauge", "value": 2, "timestamp": 1000}
+			]`)
+
+			Expect(collector.Collect()).To(Equal([]Metric{
+				Metric{Name: "job.a", Type: "gauge", Value: 1, Timestamp: 1000},
+				Metric{Name: "job.b", Type: "gauge", Value: 2, Timestamp: 1000},
+			}))
+		})
+	})
+
+	It("returns empty list when no jobs publish metrics", func() {
+		Expect(collector.Collect()).To(Equal([]Metric{}))
+	})
+})
@@ -0,0 +1,13 @@
+package fakes
+
+import (
+	boshjobmetrics "bosh/jobmetrics"
+)
+
+type FakeCollector struct {
+	CollectMetrics []boshjobmetrics.Metric
+}
+
+func (c *FakeCollector) Collect() []boshjobmetrics.Metric {
+	return c.CollectMetrics
+}
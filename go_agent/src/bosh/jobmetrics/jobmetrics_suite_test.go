@@ -0,0 +1,13 @@
+package jobmetrics_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestJobmetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Jobmetrics Suite")
+}
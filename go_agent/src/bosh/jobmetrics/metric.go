@@ -0,0 +1,15 @@
+package jobmetrics
+
+const (
+	MetricTypeGauge   = "gauge"
+	MetricTypeCounter = "counter"
+)
+
+type Metric struct {
+	Name  string  `json:"name"`
+	Type  string  `json:"type"`
+	Value float64 `json:"value"`
+
+	// Unix time in seconds the value was observed at
+	Timestamp int64 `json:"timestamp"`
+}
@@ -30,6 +30,10 @@ func (s *dummyJobSupervisor) Status() (status string) {
 	return s.status
 }
 
+func (s *dummyJobSupervisor) Processes() ([]Process, error) {
+	return []Process{}, nil
+}
+
 func (s *dummyJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
 	return nil
 }
@@ -41,3 +45,7 @@ func (s *dummyJobSupervisor) RemoveAllJobs() error {
 func (s *dummyJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
 	return nil
 }
+
+func (s *dummyJobSupervisor) StopMonitoringJobFailures() error {
+	return nil
+}
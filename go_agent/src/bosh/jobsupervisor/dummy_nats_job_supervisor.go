@@ -48,6 +48,10 @@ func (d *dummyNatsJobSupervisor) Status() string {
 	return d.status
 }
 
+func (d *dummyNatsJobSupervisor) Processes() ([]Process, error) {
+	return []Process{}, nil
+}
+
 func (d *dummyNatsJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
 	d.jobFailureHandler = handler
 
@@ -56,6 +60,10 @@ func (d *dummyNatsJobSupervisor) MonitorJobFailures(handler JobFailureHandler) e
 	return nil
 }
 
+func (d *dummyNatsJobSupervisor) StopMonitoringJobFailures() error {
+	return nil
+}
+
 func (d *dummyNatsJobSupervisor) statusHandler(req boshhandler.Request) boshhandler.Response {
 	switch req.Method {
 	case "set_dummy_status":
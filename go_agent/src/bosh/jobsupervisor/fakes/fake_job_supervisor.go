@@ -25,7 +25,13 @@ type FakeJobSupervisor struct {
 
 	StatusStatus string
 
+	ProcessesProcesses []boshjobsuper.Process
+	ProcessesErr       error
+
 	JobFailureAlert *boshalert.MonitAlert
+
+	StoppedMonitoringJobFailures bool
+	StopMonitoringJobFailuresErr error
 }
 
 type AddJobArgs struct {
@@ -77,9 +83,18 @@ func (m *FakeJobSupervisor) Status() string {
 	return m.StatusStatus
 }
 
+func (m *FakeJobSupervisor) Processes() ([]boshjobsuper.Process, error) {
+	return m.ProcessesProcesses, m.ProcessesErr
+}
+
 func (m *FakeJobSupervisor) MonitorJobFailures(handler boshjobsuper.JobFailureHandler) error {
 	if m.JobFailureAlert != nil {
 		handler(*m.JobFailureAlert)
 	}
 	return nil
 }
+
+func (m *FakeJobSupervisor) StopMonitoringJobFailures() error {
+	m.StoppedMonitoringJobFailures = true
+	return m.StopMonitoringJobFailuresErr
+}
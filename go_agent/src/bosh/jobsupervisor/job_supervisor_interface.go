@@ -6,6 +6,12 @@ import (
 
 type JobFailureHandler func(boshalert.MonitAlert) error
 
+type Process struct {
+	Name      string
+	Monitored bool
+	Status    string
+}
+
 type JobSupervisor interface {
 	Reload() error
 
@@ -21,9 +27,15 @@ type JobSupervisor interface {
 
 	Status() string
 
+	// Status of each individual process managed by job supervisor
+	Processes() ([]Process, error)
+
 	// Job management
 	AddJob(jobName string, jobIndex int, configPath string) error
 	RemoveAllJobs() error
 
 	MonitorJobFailures(handler JobFailureHandler) error
+
+	// Makes MonitorJobFailures return; alerts are no longer received
+	StopMonitoringJobFailures() error
 }
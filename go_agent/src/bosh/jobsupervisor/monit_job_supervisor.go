@@ -2,7 +2,9 @@ package jobsupervisor
 
 import (
 	"fmt"
+	"net"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/pivotal/go-smtpd/smtpd"
@@ -26,9 +28,18 @@ type monitJobSupervisor struct {
 
 	jobFailuresServerPort int
 
+	// Shared by copies since methods have value receivers
+	jobFailuresListener *jobFailuresListener
+
 	reloadOptions MonitReloadOptions
 }
 
+type jobFailuresListener struct {
+	listener net.Listener
+	stopped  bool
+	lock     sync.Mutex
+}
+
 type MonitReloadOptions struct {
 	// Number of times `monit reload` will be executed
 	MaxTries int
@@ -58,6 +69,7 @@ func NewMonitJobSupervisor(
 		dirProvider: dirProvider,
 
 		jobFailuresServerPort: jobFailuresServerPort,
+		jobFailuresListener:   &jobFailuresListener{},
 
 		reloadOptions: reloadOptions,
 	}
@@ -180,6 +192,25 @@ func (m monitJobSupervisor) Status() (status string) {
 	return
 }
 
+func (m monitJobSupervisor) Processes() ([]Process, error) {
+	processes := []Process{}
+
+	monitStatus, err := m.client.Status()
+	if err != nil {
+		return processes, bosherr.WrapError(err, "Getting monit status")
+	}
+
+	for _, service := range monitStatus.ServicesInGroup("vcap") {
+		processes = append(processes, Process{
+			Name:      service.Name,
+			Monitored: service.Monitored,
+			Status:    service.Status,
+		})
+	}
+
+	return processes, nil
+}
+
 func (m monitJobSupervisor) getIncarnation() (int, error) {
 	monitStatus, err := m.client.Status()
 	if err != nil {
@@ -225,9 +256,46 @@ func (m monitJobSupervisor) MonitorJobFailures(handler JobFailureHandler) (err e
 		OnNewMail: alertHandler,
 	}
 
-	err = serv.ListenAndServe()
+	listener, err := net.Listen("tcp", serv.Addr)
 	if err != nil {
 		err = bosherr.WrapError(err, "Listen for SMTP")
+		return
 	}
-	return
+
+	m.jobFailuresListener.lock.Lock()
+
+	if m.jobFailuresListener.stopped {
+		m.jobFailuresListener.lock.Unlock()
+		listener.Close()
+		return
+	}
+
+	m.jobFailuresListener.listener = listener
+	m.jobFailuresListener.lock.Unlock()
+
+	err = serv.Serve(listener)
+
+	m.jobFailuresListener.lock.Lock()
+	defer m.jobFailuresListener.lock.Unlock()
+
+	// Serve returns an error once listener is closed
+	if err != nil && !m.jobFailuresListener.stopped {
+		err = bosherr.WrapError(err, "Serving SMTP")
+		return
+	}
+
+	return nil
+}
+
+func (m monitJobSupervisor) StopMonitoringJobFailures() error {
+	m.jobFailuresListener.lock.Lock()
+	defer m.jobFailuresListener.lock.Unlock()
+
+	m.jobFailuresListener.stopped = true
+
+	if m.jobFailuresListener.listener != nil {
+		return m.jobFailuresListener.listener.Close()
+	}
+
+	return nil
 }
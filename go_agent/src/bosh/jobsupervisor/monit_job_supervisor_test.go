@@ -229,6 +229,32 @@ var _ = Describe("monitJobSupervisor", func() {
 		})
 	})
 
+	Describe("Processes", func() {
+		It("returns status of each monit service in group vcap", func() {
+			client.StatusStatus = fakemonit.FakeMonitStatus{
+				Services: []boshmonit.Service{
+					boshmonit.Service{Name: "fake-service-1", Monitored: true, Status: "running"},
+					boshmonit.Service{Name: "fake-service-2", Monitored: false, Status: "unknown"},
+				},
+			}
+
+			processes, err := monit.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(Equal([]Process{
+				Process{Name: "fake-service-1", Monitored: true, Status: "running"},
+				Process{Name: "fake-service-2", Monitored: false, Status: "unknown"},
+			}))
+		})
+
+		It("returns error when monit status cannot be retrieved", func() {
+			client.StatusErr = errors.New("fake-monit-client-error")
+
+			_, err := monit.Processes()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-monit-client-error"))
+		})
+	})
+
 	Describe("MonitorJobFailures", func() {
 		It("monitor job failures", func() {
 			var handledAlert boshalert.MonitAlert
@@ -274,6 +300,22 @@ var _ = Describe("monitJobSupervisor", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(didHandleAlert).To(BeFalse())
 		})
+
+		It("returns without error once monitoring is stopped", func() {
+			failureHandler := func(alert boshalert.MonitAlert) (err error) { return }
+
+			errCh := make(chan error, 1)
+			go func() { errCh <- monit.MonitorJobFailures(failureHandler) }()
+
+			// Make sure server is listening
+			err := doJobFailureEmail(`fake-other-email`, jobFailuresServerPort)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = monit.StopMonitoringJobFailures()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(<-errCh).ToNot(HaveOccurred())
+		})
 	})
 
 	Describe("AddJob", func() {
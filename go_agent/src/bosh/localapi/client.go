@@ -0,0 +1,75 @@
+package localapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"time"
+
+	bosherr "bosh/errors"
+)
+
+type Client interface {
+	// Call returns raw JSON response which holds either value or exception
+	Call(method string, arguments []interface{}) ([]byte, error)
+}
+
+type request struct {
+	Method    string        `json:"method"`
+	Arguments []interface{} `json:"arguments"`
+	ReplyTo   string        `json:"reply_to"`
+}
+
+type unixSocketClient struct {
+	socketPath string
+	timeout    time.Duration
+}
+
+func NewUnixSocketClient(socketPath string, timeout time.Duration) unixSocketClient {
+	return unixSocketClient{socketPath: socketPath, timeout: timeout}
+}
+
+func (c unixSocketClient) Call(method string, arguments []interface{}) ([]byte, error) {
+	if arguments == nil {
+		arguments = []interface{}{}
+	}
+
+	reqBytes, err := json.Marshal(request{
+		Method:    method,
+		Arguments: arguments,
+		ReplyTo:   "local",
+	})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling request")
+	}
+
+	conn, err := net.DialTimeout("unix", c.socketPath, c.timeout)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Connecting to %s", c.socketPath)
+	}
+
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(c.timeout))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Setting deadline")
+	}
+
+	_, err = conn.Write(reqBytes)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Writing request")
+	}
+
+	// Agent reads request until end of stream
+	err = conn.(*net.UnixConn).CloseWrite()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Closing request stream")
+	}
+
+	respBytes, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading response")
+	}
+
+	return respBytes, nil
+}
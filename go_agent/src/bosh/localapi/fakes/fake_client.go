@@ -0,0 +1,14 @@
+package fakes
+
+type FakeClient struct {
+	CallMethod    string
+	CallArguments []interface{}
+	CallResponse  []byte
+	CallErr       error
+}
+
+func (c *FakeClient) Call(method string, arguments []interface{}) ([]byte, error) {
+	c.CallMethod = method
+	c.CallArguments = arguments
+	return c.CallResponse, c.CallErr
+}
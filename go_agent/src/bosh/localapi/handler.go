@@ -0,0 +1,223 @@
+package localapi
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bosherr "bosh/errors"
+	boshhandler "bosh/handler"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+const (
+	handlerLogTag = "Local API Handler"
+
+	// Requests are small; anything larger is not a valid request
+	requestMaxLength = 1024 * 1024
+)
+
+// unixSocketHandler serves the same JSON protocol as the message bus
+// over a unix socket accessible only to root. Each connection carries
+// a single request followed by a single response.
+type unixSocketHandler struct {
+	socketPath     string
+	allowedActions map[string]bool
+	ioTimeout      time.Duration
+	fs             boshsys.FileSystem
+	logger         boshlog.Logger
+
+	// Handler func given to Start is replaced when started again;
+	// additional handler funcs are tried after it
+	handlerFunc      boshhandler.HandlerFunc
+	handlerFuncs     []boshhandler.HandlerFunc
+	handlerFuncsLock sync.Mutex
+
+	listener     net.Listener
+	listenerLock sync.Mutex
+}
+
+func NewUnixSocketHandler(
+	socketPath string,
+	allowedActions []string,
+	ioTimeout time.Duration,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) *unixSocketHandler {
+	allowed := map[string]bool{}
+	for _, action := range allowedActions {
+		allowed[action] = true
+	}
+
+	return &unixSocketHandler{
+		socketPath:     socketPath,
+		allowedActions: allowed,
+		ioTimeout:      ioTimeout,
+		fs:             fs,
+		logger:         logger,
+	}
+}
+
+func (h *unixSocketHandler) Run(handlerFunc boshhandler.HandlerFunc) error {
+	listener, err := h.listen(handlerFunc)
+	if err != nil {
+		return bosherr.WrapError(err, "Starting local API handler")
+	}
+
+	h.serve(listener)
+
+	return nil
+}
+
+func (h *unixSocketHandler) Start(handlerFunc boshhandler.HandlerFunc) error {
+	listener, err := h.listen(handlerFunc)
+	if err != nil {
+		return bosherr.WrapError(err, "Starting local API handler")
+	}
+
+	go h.serve(listener)
+
+	return nil
+}
+
+func (h *unixSocketHandler) Stop() {
+	h.listenerLock.Lock()
+	defer h.listenerLock.Unlock()
+
+	if h.listener != nil {
+		h.listener.Close()
+	}
+}
+
+func (h *unixSocketHandler) RegisterAdditionalHandlerFunc(handlerFunc boshhandler.HandlerFunc) {
+	h.handlerFuncsLock.Lock()
+	defer h.handlerFuncsLock.Unlock()
+
+	h.handlerFuncs = append(h.handlerFuncs, handlerFunc)
+}
+
+// SendToHealthManager is not supported since there is no health manager on the other side
+func (h *unixSocketHandler) SendToHealthManager(topic string, payload interface{}) error {
+	return bosherr.New("Local API handler cannot send messages to health manager")
+}
+
+func (h *unixSocketHandler) listen(handlerFunc boshhandler.HandlerFunc) (net.Listener, error) {
+	h.handlerFuncsLock.Lock()
+	h.handlerFunc = handlerFunc
+	h.handlerFuncsLock.Unlock()
+
+	err := h.fs.MkdirAll(filepath.Dir(h.socketPath), os.FileMode(0700))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating socket directory")
+	}
+
+	// Socket file might be left over from previous run
+	err = h.fs.RemoveAll(h.socketPath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Removing stale socket")
+	}
+
+	listener, err := net.Listen("unix", h.socketPath)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listening on %s", h.socketPath)
+	}
+
+	err = h.fs.Chmod(h.socketPath, os.FileMode(0600))
+	if err != nil {
+		listener.Close()
+		return nil, bosherr.WrapError(err, "Restricting socket permissions")
+	}
+
+	h.listenerLock.Lock()
+	h.listener = listener
+	h.listenerLock.Unlock()
+
+	h.logger.Info(handlerLogTag, "Listening on %s", h.socketPath)
+
+	return listener, nil
+}
+
+func (h *unixSocketHandler) serve(listener net.Listener) {
+	defer h.logger.HandlePanic("Local API Handler")
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			h.logger.Debug(handlerLogTag, "Stopped accepting connections: %s", err.Error())
+			return
+		}
+
+		go h.handleConn(conn)
+	}
+}
+
+func (h *unixSocketHandler) handleConn(conn net.Conn) {
+	defer h.logger.HandlePanic("Local API Connection")
+	defer conn.Close()
+
+	// Clients that do not finish sending request are disconnected
+	err := conn.SetDeadline(time.Now().Add(h.ioTimeout))
+	if err != nil {
+		h.logger.Error(handlerLogTag, "Setting connection deadline: %s", err.Error())
+		return
+	}
+
+	reqBytes, err := ioutil.ReadAll(io.LimitReader(conn, requestMaxLength))
+	if err != nil {
+		h.logger.Error(handlerLogTag, "Reading request: %s", err.Error())
+		return
+	}
+
+	h.handlerFuncsLock.Lock()
+	handlerFuncs := append([]boshhandler.HandlerFunc{h.handlerFunc}, h.handlerFuncs...)
+	h.handlerFuncsLock.Unlock()
+
+	for _, handlerFunc := range handlerFuncs {
+		respBytes, _, err := boshhandler.PerformHandlerWithJSON(
+			reqBytes,
+			h.allowedOnly(handlerFunc),
+			nil,
+			boshhandler.UnlimitedResponseLength,
+			h.logger,
+		)
+		if err != nil {
+			h.logger.Error(handlerLogTag, "Running handler: %s", err.Error())
+			respBytes, err = boshhandler.BuildErrorWithJSON(err.Error(), h.logger)
+			if err != nil {
+				return
+			}
+		}
+
+		if len(respBytes) > 0 {
+			// Handler might have taken longer than read deadline allowed
+			err = conn.SetWriteDeadline(time.Now().Add(h.ioTimeout))
+			if err != nil {
+				h.logger.Error(handlerLogTag, "Setting connection deadline: %s", err.Error())
+				return
+			}
+
+			_, err = conn.Write(respBytes)
+			if err != nil {
+				h.logger.Error(handlerLogTag, "Writing response: %s", err.Error())
+			}
+			return
+		}
+	}
+}
+
+func (h *unixSocketHandler) allowedOnly(handlerFunc boshhandler.HandlerFunc) boshhandler.HandlerFunc {
+	return func(req boshhandler.Request) boshhandler.Response {
+		if !h.allowedActions[req.Method] {
+			return boshhandler.NewExceptionResponse(
+				bosherr.New("Action '%s' is not allowed over local API", req.Method),
+			)
+		}
+
+		return handlerFunc(req)
+	}
+}
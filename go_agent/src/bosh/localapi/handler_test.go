@@ -0,0 +1,118 @@
+package localapi_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshhandler "bosh/handler"
+	. "bosh/localapi"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+var _ = Describe("unixSocketHandler", func() {
+	var (
+		tmpDir          string
+		socketPath      string
+		handler         boshhandler.Handler
+		client          Client
+		receivedRequest boshhandler.Request
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tmpDir, err = ioutil.TempDir("", "localapi")
+		Expect(err).ToNot(HaveOccurred())
+
+		socketPath = filepath.Join(tmpDir, "run", "agent.sock")
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := boshsys.NewOsFileSystem(logger)
+		handler = NewUnixSocketHandler(socketPath, []string{"get_state"}, 100*time.Millisecond, fs, logger)
+
+		err = handler.Start(func(req boshhandler.Request) boshhandler.Response {
+			receivedRequest = req
+			return boshhandler.NewValueResponse("fake-state")
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		client = NewUnixSocketClient(socketPath, 5*time.Second)
+	})
+
+	AfterEach(func() {
+		handler.Stop()
+		os.RemoveAll(tmpDir)
+	})
+
+	It("creates socket accessible only to owner", func() {
+		info, err := os.Stat(socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode() & os.ModePerm).To(Equal(os.FileMode(0600)))
+	})
+
+	It("responds to allowed actions", func() {
+		respBytes, err := client.Call("get_state", []interface{}{"full"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(respBytes)).To(Equal(`{"value":"fake-state"}`))
+
+		Expect(receivedRequest.Method).To(Equal("get_state"))
+		Expect(string(receivedRequest.GetPayload())).To(ContainSubstring(`"arguments":["full"]`))
+	})
+
+	It("responds with exception to actions that are not allowed", func() {
+		respBytes, err := client.Call("apply", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(respBytes)).To(ContainSubstring(`"message":"Action 'apply' is not allowed over local API"`))
+	})
+
+	It("responds with exception to invalid requests", func() {
+		respBytes, err := client.Call("", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(respBytes)).To(ContainSubstring("exception"))
+	})
+
+	It("replaces stale socket file when started again", func() {
+		handler.Stop()
+
+		err := ioutil.WriteFile(socketPath, []byte{}, 0600)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = handler.Start(func(req boshhandler.Request) boshhandler.Response {
+			return boshhandler.NewValueResponse("fake-new-state")
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		// Handler func given to previous Start is no longer used
+		respBytes, err := client.Call("get_state", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(respBytes)).To(Equal(`{"value":"fake-new-state"}`))
+	})
+
+	It("disconnects clients that do not finish sending request", func() {
+		conn, err := net.Dial("unix", socketPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		defer conn.Close()
+
+		_, err = conn.Write([]byte(`{"method":"get_state"`))
+		Expect(err).ToNot(HaveOccurred())
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+		respBytes, err := ioutil.ReadAll(conn)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(respBytes).To(BeEmpty())
+	})
+
+	It("does not send messages to health manager", func() {
+		err := handler.SendToHealthManager("heartbeat", nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
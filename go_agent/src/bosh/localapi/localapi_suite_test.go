@@ -0,0 +1,13 @@
+package localapi_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLocalapi(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Local API Suite")
+}
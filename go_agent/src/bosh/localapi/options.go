@@ -0,0 +1,63 @@
+package localapi
+
+import (
+	"path/filepath"
+	"time"
+
+	boshdir "bosh/settings/directories"
+)
+
+const (
+	socketFileName = "agent.sock"
+
+	defaultIOTimeout = 10 * time.Second
+)
+
+// Actions that do not change state of the VM
+var DefaultAllowedActions = []string{
+	"ping",
+	"get_state",
+	"get_task",
+	"list_tasks",
+	"get_vitals_history",
+	"list_disk",
+}
+
+type Options struct {
+	// Local API is enabled unless set to true
+	Disabled bool
+
+	// Defaults to agent.sock in bosh directory
+	SocketPath string
+
+	// Defaults to DefaultAllowedActions
+	AllowedActions []string
+
+	// Time allowed for reading request and for writing response;
+	// defaults to 10 seconds
+	IOTimeoutSeconds int
+}
+
+func (o Options) SocketPathOrDefault(dirProvider boshdir.DirectoriesProvider) string {
+	if o.SocketPath != "" {
+		return o.SocketPath
+	}
+
+	return filepath.Join(dirProvider.BoshDir(), socketFileName)
+}
+
+func (o Options) AllowedActionsOrDefault() []string {
+	if o.AllowedActions != nil {
+		return o.AllowedActions
+	}
+
+	return DefaultAllowedActions
+}
+
+func (o Options) IOTimeoutOrDefault() time.Duration {
+	if o.IOTimeoutSeconds > 0 {
+		return time.Duration(o.IOTimeoutSeconds) * time.Second
+	}
+
+	return defaultIOTimeout
+}
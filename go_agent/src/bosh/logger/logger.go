@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"runtime/debug"
+	"sync"
 )
 
 type LogLevel int
@@ -21,6 +22,13 @@ type Logger struct {
 	level LogLevel
 	out   *log.Logger
 	err   *log.Logger
+
+	// Shared by copies of the logger since tag and correlation ID
+	// are set as prefix of shared log.Logger right before printing
+	printLock *sync.Mutex
+
+	// Included in every line to relate lines logged while serving a request
+	correlationID string
 }
 
 func NewLogger(level LogLevel) Logger {
@@ -32,16 +40,25 @@ func NewWriterLogger(level LogLevel, out, err io.Writer) Logger {
 		level: level,
 		out:   log.New(out, "", log.LstdFlags),
 		err:   log.New(err, "", log.LstdFlags),
+
+		printLock: &sync.Mutex{},
 	}
 }
 
+// WithCorrelationID returns a logger that includes
+// correlation ID after the tag of each line
+func (l Logger) WithCorrelationID(correlationID string) Logger {
+	l.correlationID = correlationID
+	return l
+}
+
 func (l Logger) Debug(tag, msg string, args ...interface{}) {
 	if l.level > LevelDebug {
 		return
 	}
 
 	msg = fmt.Sprintf("DEBUG - %s", msg)
-	l.getOutLogger(tag).Printf(msg, args...)
+	l.printf(l.out, tag, msg, args...)
 }
 
 // DebugWithDetails will automatically change the format of the message
@@ -57,7 +74,7 @@ func (l Logger) Info(tag, msg string, args ...interface{}) {
 	}
 
 	msg = fmt.Sprintf("INFO - %s", msg)
-	l.getOutLogger(tag).Printf(msg, args...)
+	l.printf(l.out, tag, msg, args...)
 }
 
 func (l Logger) Error(tag, msg string, args ...interface{}) {
@@ -66,7 +83,7 @@ func (l Logger) Error(tag, msg string, args ...interface{}) {
 	}
 
 	msg = fmt.Sprintf("ERROR - %s", msg)
-	l.getErrLogger(tag).Printf(msg, args...)
+	l.printf(l.err, tag, msg, args...)
 }
 
 // ErrorWithDetails will automatically change the format of the message
@@ -98,16 +115,15 @@ func (l Logger) HandlePanic(tag string) {
 	}
 }
 
-func (l Logger) getOutLogger(tag string) (logger *log.Logger) {
-	return l.updateLogger(l.out, tag)
-}
+func (l Logger) printf(logger *log.Logger, tag, msg string, args ...interface{}) {
+	prefix := fmt.Sprintf("[%s] ", tag)
+	if l.correlationID != "" {
+		prefix = fmt.Sprintf("[%s] [%s] ", tag, l.correlationID)
+	}
 
-func (l Logger) getErrLogger(tag string) (logger *log.Logger) {
-	return l.updateLogger(l.err, tag)
-}
+	l.printLock.Lock()
+	defer l.printLock.Unlock()
 
-func (l Logger) updateLogger(logger *log.Logger, tag string) *log.Logger {
-	prefix := fmt.Sprintf("[%s] ", tag)
 	logger.SetPrefix(prefix)
-	return logger
+	logger.Printf(msg, args...)
 }
@@ -1,10 +1,13 @@
 package logger_test
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"regexp"
+	"strings"
+	"sync"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -69,6 +72,54 @@ var _ = Describe("Logger", func() {
 		Expect(matcher.Match(stdout)).To(BeTrue())
 	})
 
+	It("info with correlation id", func() {
+		stdout, _ := captureOutputs(func() {
+			logger := NewLogger(LevelInfo).WithCorrelationID("fake-correlation-id")
+			logger.Info("TAG", "some %s info to log", "awesome")
+		})
+
+		matcher, _ := regexp.Compile(expectedLogFormat("TAG\\] \\[fake-correlation-id", "INFO - some awesome info to log"))
+		Expect(matcher.Match(stdout)).To(BeTrue())
+	})
+
+	It("keeps correlation id of each line when copies log concurrently", func() {
+		out := &bytes.Buffer{}
+		logger := NewWriterLogger(LevelInfo, out, ioutil.Discard)
+
+		var wg sync.WaitGroup
+
+		startCh := make(chan struct{})
+
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+
+			go func(id string) {
+				defer wg.Done()
+
+				<-startCh
+
+				requestLogger := logger.WithCorrelationID(id)
+				for j := 0; j < 100; j++ {
+					requestLogger.Info("TAG", "request %s", id)
+				}
+			}(fmt.Sprintf("fake-id-%d", i))
+		}
+
+		close(startCh)
+		wg.Wait()
+
+		lineRegexp := regexp.MustCompile(`^\[TAG\] \[(fake-id-\d+)\] .* INFO - request (fake-id-\d+)$`)
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		Expect(lines).To(HaveLen(2000))
+
+		for _, line := range lines {
+			matches := lineRegexp.FindStringSubmatch(line)
+			Expect(matches).To(HaveLen(3), line)
+			Expect(matches[1]).To(Equal(matches[2]))
+		}
+	})
+
 	It("debug", func() {
 		stdout, _ := captureOutputs(func() {
 			logger := NewLogger(LevelDebug)
@@ -0,0 +1,27 @@
+package mbus
+
+import (
+	"math/rand"
+	"time"
+)
+
+// backoffDelay returns exponentially growing delay for given attempt
+// (starting at 0) capped at max. Half of the delay is randomized
+// so that agents do not reconnect to restarted server all at once.
+func backoffDelay(attempt int, min, max time.Duration, random *rand.Rand) time.Duration {
+	delay := min
+	for i := 0; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+
+	return half + time.Duration(random.Int63n(int64(half)+1))
+}
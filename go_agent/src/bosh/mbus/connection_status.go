@@ -0,0 +1,27 @@
+package mbus
+
+import (
+	"errors"
+)
+
+// ErrNotConnected is returned when message could not be sent
+// because connection to message bus is being re-established
+var ErrNotConnected = errors.New("Not connected to message bus")
+
+const (
+	ConnectionStateConnecting   = "connecting"
+	ConnectionStateConnected    = "connected"
+	ConnectionStateReconnecting = "reconnecting"
+	ConnectionStateDisconnected = "disconnected"
+)
+
+type ConnectionStatus struct {
+	State string `json:"state"`
+
+	// Number of successful reconnects since start
+	Reconnects uint64 `json:"reconnects"`
+}
+
+type ConnectionStatusProvider interface {
+	GetConnectionStatus() ConnectionStatus
+}
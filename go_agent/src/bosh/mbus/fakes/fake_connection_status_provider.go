@@ -0,0 +1,13 @@
+package fakes
+
+import (
+	boshmbus "bosh/mbus"
+)
+
+type FakeConnectionStatusProvider struct {
+	Status boshmbus.ConnectionStatus
+}
+
+func (p *FakeConnectionStatusProvider) GetConnectionStatus() boshmbus.ConnectionStatus {
+	return p.Status
+}
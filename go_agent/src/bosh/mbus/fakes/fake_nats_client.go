@@ -0,0 +1,145 @@
+package fakes
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/cloudfoundry/yagnats"
+)
+
+// FakeNATSClient simulates connection to NATS server
+// which can be dropped and refuse new connections
+type FakeNATSClient struct {
+	connected      bool
+	connectErrs    map[string]error
+	connectedAddrs []string
+	subscriptions  map[string][]yagnats.Callback
+	published      map[string][][]byte
+	lock           sync.Mutex
+}
+
+func NewFakeNATSClient() *FakeNATSClient {
+	return &FakeNATSClient{
+		connectErrs:   map[string]error{},
+		subscriptions: map[string][]yagnats.Callback{},
+		published:     map[string][][]byte{},
+	}
+}
+
+// Drop simulates server closing the connection
+func (c *FakeNATSClient) Drop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.connected = false
+	c.subscriptions = map[string][]yagnats.Callback{}
+}
+
+func (c *FakeNATSClient) SetConnectErr(addr string, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err == nil {
+		delete(c.connectErrs, addr)
+	} else {
+		c.connectErrs[addr] = err
+	}
+}
+
+func (c *FakeNATSClient) ConnectedAddrs() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]string{}, c.connectedAddrs...)
+}
+
+func (c *FakeNATSClient) SubscriptionsCount(subject string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.subscriptions[subject])
+}
+
+func (c *FakeNATSClient) PublishedMessages(subject string) [][]byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([][]byte{}, c.published[subject]...)
+}
+
+func (c *FakeNATSClient) Ping() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.connected
+}
+
+func (c *FakeNATSClient) Connect(connectionProvider yagnats.ConnectionProvider) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	connInfo, ok := connectionProvider.(*yagnats.ConnectionInfo)
+	if !ok {
+		return errors.New("fake-unsupported-connection-provider")
+	}
+
+	if err, found := c.connectErrs[connInfo.Addr]; found {
+		return err
+	}
+
+	c.connected = true
+	c.connectedAddrs = append(c.connectedAddrs, connInfo.Addr)
+
+	return nil
+}
+
+func (c *FakeNATSClient) Disconnect() {
+	c.Drop()
+}
+
+func (c *FakeNATSClient) Publish(subject string, payload []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.connected {
+		return errors.New("fake-not-connected")
+	}
+
+	c.published[subject] = append(c.published[subject], payload)
+
+	return nil
+}
+
+func (c *FakeNATSClient) PublishWithReplyTo(subject, reply string, payload []byte) error {
+	return c.Publish(subject, payload)
+}
+
+func (c *FakeNATSClient) Subscribe(subject string, callback yagnats.Callback) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.connected {
+		return 0, errors.New("fake-not-connected")
+	}
+
+	c.subscriptions[subject] = append(c.subscriptions[subject], callback)
+
+	return len(c.subscriptions[subject]), nil
+}
+
+func (c *FakeNATSClient) SubscribeWithQueue(subject, queue string, callback yagnats.Callback) (int, error) {
+	return c.Subscribe(subject, callback)
+}
+
+func (c *FakeNATSClient) Unsubscribe(subscription int) error {
+	return nil
+}
+
+func (c *FakeNATSClient) UnsubscribeAll(subject string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.subscriptions, subject)
+}
+
+func (c *FakeNATSClient) BeforeConnectCallback(callback func()) {}
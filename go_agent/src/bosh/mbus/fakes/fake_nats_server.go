@@ -0,0 +1,202 @@
+package fakes
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FakeNATSServer understands just enough of NATS protocol over TCP
+// to accept connections, subscriptions and published messages.
+// It can be stopped and started again on the same address.
+type FakeNATSServer struct {
+	addr      string
+	tlsConfig *tls.Config
+
+	listener      net.Listener
+	conns         map[net.Conn]struct{}
+	subscriptions map[string]int
+	published     map[string][][]byte
+	lock          sync.Mutex
+}
+
+func NewFakeNATSServer() (*FakeNATSServer, error) {
+	return NewFakeTLSNATSServer(nil)
+}
+
+// NewFakeTLSNATSServer only accepts TLS connections when tlsConfig is set
+func NewFakeTLSNATSServer(tlsConfig *tls.Config) (*FakeNATSServer, error) {
+	s := &FakeNATSServer{
+		addr:          "127.0.0.1:0",
+		tlsConfig:     tlsConfig,
+		conns:         map[net.Conn]struct{}{},
+		subscriptions: map[string]int{},
+		published:     map[string][][]byte{},
+	}
+
+	err := s.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *FakeNATSServer) Addr() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.addr
+}
+
+func (s *FakeNATSServer) Start() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	s.addr = listener.Addr().String()
+
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+
+	s.listener = listener
+
+	go s.accept(listener)
+
+	return nil
+}
+
+// Stop closes all client connections as if server went down
+func (s *FakeNATSServer) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+	}
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+
+	s.conns = map[net.Conn]struct{}{}
+	s.subscriptions = map[string]int{}
+}
+
+func (s *FakeNATSServer) SubscriptionsCount(subject string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.subscriptions[subject]
+}
+
+func (s *FakeNATSServer) PublishedMessages(subject string) [][]byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return append([][]byte{}, s.published[subject]...)
+}
+
+func (s *FakeNATSServer) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.lock.Lock()
+		s.conns[conn] = struct{}{}
+		s.lock.Unlock()
+
+		go s.serve(conn)
+	}
+}
+
+func (s *FakeNATSServer) serve(conn net.Conn) {
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, conn)
+		s.lock.Unlock()
+
+		conn.Close()
+	}()
+
+	_, err := io.WriteString(conn, `INFO {"server_id":"fake-nats-server","max_payload":1048576}`+"\r\n")
+	if err != nil {
+		return
+	}
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			_, err = io.WriteString(conn, "PONG\r\n")
+
+		case "SUB":
+			// SUB <subject> [queue] <sid>
+			s.lock.Lock()
+			s.subscriptions[fields[1]]++
+			s.lock.Unlock()
+
+		case "PUB":
+			// PUB <subject> [reply-to] <size>
+			err = s.readPublished(reader, fields)
+
+		case "CONNECT", "UNSUB", "PONG":
+
+		default:
+			_, err = fmt.Fprintf(conn, "-ERR 'Unknown Protocol Operation'\r\n")
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *FakeNATSServer) readPublished(reader *bufio.Reader, fields []string) error {
+	if len(fields) < 3 {
+		return fmt.Errorf("Invalid PUB arguments %v", fields)
+	}
+
+	size, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return err
+	}
+
+	// Payload is followed by CRLF
+	payload := make([]byte, size+2)
+
+	_, err = io.ReadFull(reader, payload)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.published[fields[1]] = append(s.published[fields[1]], payload[:size])
+	s.lock.Unlock()
+
+	return nil
+}
@@ -7,24 +7,29 @@ import (
 
 	bosherr "bosh/errors"
 	boshhandler "bosh/handler"
+	boshdispatcher "bosh/httpsdispatcher"
 	boshlog "bosh/logger"
 	"bosh/micro"
 	boshplatform "bosh/platform"
 	boshsettings "bosh/settings"
 	boshdir "bosh/settings/directories"
+	boshtime "bosh/time"
 )
 
 type MbusHandlerProvider struct {
 	settingsService boshsettings.Service
+	microOptions    micro.Options
 	logger          boshlog.Logger
 	handler         boshhandler.Handler
 }
 
 func NewHandlerProvider(
 	settingsService boshsettings.Service,
+	microOptions micro.Options,
 	logger boshlog.Logger,
 ) (p MbusHandlerProvider) {
 	p.settingsService = settingsService
+	p.microOptions = microOptions
 	p.logger = logger
 	return
 }
@@ -38,17 +43,66 @@ func (p MbusHandlerProvider) Get(
 		return
 	}
 
-	mbusURL, err := url.Parse(p.settingsService.GetSettings().Mbus)
+	mbusURLs := p.settingsService.GetSettings().GetMbusURLs()
+	if len(mbusURLs) == 0 {
+		err = bosherr.New("Message Bus URL is not set")
+		return
+	}
+
+	mbusURL, err := url.Parse(mbusURLs[0])
 	if err != nil {
 		err = bosherr.WrapError(err, "Parsing handler URL")
 		return
 	}
 
+	// Mixing schemes would e.g. silently fall back from nats+tls to nats
+	for _, otherURL := range mbusURLs[1:] {
+		var parsedURL *url.URL
+
+		parsedURL, err = url.Parse(otherURL)
+		if err != nil {
+			err = bosherr.WrapError(err, "Parsing handler URL")
+			return
+		}
+
+		if parsedURL.Scheme != mbusURL.Scheme {
+			err = bosherr.New("Message Bus URLs must use the same scheme, got %s and %s", mbusURL.Scheme, parsedURL.Scheme)
+			return
+		}
+	}
+
+	verifier, err := boshhandler.NewHMACRequestVerifier(
+		p.settingsService.GetSettings().RequestSigning,
+		boshtime.NewConcreteService(),
+	)
+	if err != nil {
+		err = bosherr.WrapError(err, "Preparing request verification")
+		return
+	}
+
 	switch mbusURL.Scheme {
 	case "nats":
-		handler = NewNatsHandler(p.settingsService, yagnats.NewClient(), p.logger)
+		handler = NewNatsHandler(p.settingsService, yagnats.NewClient(), NatsOptions{RequestVerifier: verifier}, p.logger)
+	case "nats+tls":
+		var natsTLS NatsTLS
+
+		natsTLS, err = NewNatsTLS(platform.GetFs(), dirProvider, p.settingsService.GetSettings().MbusTLS)
+		if err != nil {
+			err = bosherr.WrapError(err, "Preparing NATS TLS configuration")
+			return
+		}
+
+		handler = NewNatsHandler(p.settingsService, yagnats.NewClient(), NatsOptions{TLS: &natsTLS, RequestVerifier: verifier}, p.logger)
 	case "https":
-		handler = micro.NewHTTPSHandler(mbusURL, p.logger, platform.GetFs(), dirProvider)
+		var tlsOptions boshdispatcher.TLSOptions
+
+		tlsOptions, err = micro.NewTLSOptions(platform.GetFs(), p.settingsService.GetSettings().MbusTLS, p.microOptions)
+		if err != nil {
+			err = bosherr.WrapError(err, "Preparing HTTPS TLS configuration")
+			return
+		}
+
+		handler = micro.NewHTTPSHandler(mbusURL, tlsOptions, verifier, p.logger, platform.GetFs(), dirProvider, p.microOptions)
 	default:
 		err = bosherr.New("Message Bus Handler with scheme %s could not be found", mbusURL.Scheme)
 	}
@@ -8,10 +8,13 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	testcrypto "bosh/crypto/testing"
+	boshdispatcher "bosh/httpsdispatcher"
 	boshlog "bosh/logger"
 	. "bosh/mbus"
 	"bosh/micro"
 	fakeplatform "bosh/platform/fakes"
+	boshsettings "bosh/settings"
 	boshdir "bosh/settings/directories"
 	fakesettings "bosh/settings/fakes"
 )
@@ -30,7 +33,7 @@ var _ = Describe("MbusHandlerProvider", func() {
 		logger = boshlog.NewLogger(boshlog.LevelNone)
 		platform = fakeplatform.NewFakePlatform()
 		dirProvider = boshdir.NewDirectoriesProvider("/var/vcap")
-		provider = NewHandlerProvider(settingsService, logger)
+		provider = NewHandlerProvider(settingsService, micro.Options{}, logger)
 	})
 
 	Describe("Get", func() {
@@ -40,7 +43,7 @@ var _ = Describe("MbusHandlerProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			// yagnats.NewClient returns new object every time
-			expectedHandler := NewNatsHandler(settingsService, yagnats.NewClient(), logger)
+			expectedHandler := NewNatsHandler(settingsService, yagnats.NewClient(), NatsOptions{}, logger)
 			Expect(reflect.TypeOf(handler)).To(Equal(reflect.TypeOf(expectedHandler)))
 		})
 
@@ -51,7 +54,75 @@ var _ = Describe("MbusHandlerProvider", func() {
 			settingsService.Settings.Mbus = "https://lol"
 			handler, err := provider.Get(platform, dirProvider)
 			Expect(err).ToNot(HaveOccurred())
-			Expect(handler).To(Equal(micro.NewHTTPSHandler(url, logger, platform.GetFs(), dirProvider)))
+
+			// https handler has its own health message queue
+			expectedHandler := micro.NewHTTPSHandler(url, boshdispatcher.TLSOptions{}, nil, logger, platform.GetFs(), dirProvider, micro.Options{})
+			Expect(reflect.TypeOf(handler)).To(Equal(reflect.TypeOf(expectedHandler)))
+		})
+
+		It("returns nats handler when multiple urls are given", func() {
+			settingsService.Settings.Mbus = "nats://lol,nats://lol2"
+			handler, err := provider.Get(platform, dirProvider)
+			Expect(err).ToNot(HaveOccurred())
+
+			expectedHandler := NewNatsHandler(settingsService, yagnats.NewClient(), NatsOptions{}, logger)
+			Expect(reflect.TypeOf(handler)).To(Equal(reflect.TypeOf(expectedHandler)))
+		})
+
+		It("returns an error when urls use different schemes", func() {
+			settingsService.Settings.Mbus = "nats+tls://lol,nats://lol2"
+			settingsService.Settings.MbusTLS = boshsettings.MbusTLS{CA: testcrypto.GenerateCertificate("fake-ca", nil).CertPEM}
+
+			_, err := provider.Get(platform, dirProvider)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Message Bus URLs must use the same scheme, got nats+tls and nats"))
+		})
+
+		It("returns nats handler for tls scheme", func() {
+			ca := testcrypto.GenerateCertificate("fake-ca", nil)
+			settingsService.Settings.Mbus = "nats+tls://lol"
+			settingsService.Settings.MbusTLS = boshsettings.MbusTLS{CA: ca.CertPEM}
+
+			handler, err := provider.Get(platform, dirProvider)
+			Expect(err).ToNot(HaveOccurred())
+
+			expectedHandler := NewNatsHandler(settingsService, yagnats.NewClient(), NatsOptions{}, logger)
+			Expect(reflect.TypeOf(handler)).To(Equal(reflect.TypeOf(expectedHandler)))
+			Expect(platform.GetFs().FileExists("/var/vcap/bosh/etc/mbus/ca.pem")).To(BeTrue())
+		})
+
+		It("returns an error if tls configuration is invalid", func() {
+			settingsService.Settings.Mbus = "nats+tls://lol"
+			settingsService.Settings.MbusTLS = boshsettings.MbusTLS{CA: "fake-invalid-ca"}
+
+			_, err := provider.Get(platform, dirProvider)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Preparing NATS TLS configuration"))
+		})
+
+		It("returns an error if https certificate is given without private key", func() {
+			settingsService.Settings.Mbus = "https://lol"
+			settingsService.Settings.MbusTLS = boshsettings.MbusTLS{Certificate: "fake-cert"}
+
+			_, err := provider.Get(platform, dirProvider)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Preparing HTTPS TLS configuration"))
+		})
+
+		It("returns an error if request signing key is invalid", func() {
+			settingsService.Settings.Mbus = "nats://lol"
+			settingsService.Settings.RequestSigning = boshsettings.RequestSigning{
+				Keys: map[string]string{"fake-key-id": "fake-invalid-base64!"},
+			}
+
+			_, err := provider.Get(platform, dirProvider)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Preparing request verification"))
+		})
+
+		It("returns an error if mbus url is not set", func() {
+			_, err := provider.Get(platform, dirProvider)
+			Expect(err).To(HaveOccurred())
 		})
 
 		It("returns an error if not supported", func() {
@@ -1,6 +1,7 @@
 package mbus
 
 import (
+	boshjobmetrics "bosh/jobmetrics"
 	boshvitals "bosh/platform/vitals"
 )
 
@@ -9,6 +10,8 @@ type Heartbeat struct {
 	Index    *int              `json:"index"`
 	JobState string            `json:"job_state"`
 	Vitals   boshvitals.Vitals `json:"vitals"`
+
+	Metrics []boshjobmetrics.Metric `json:"metrics,omitempty"`
 }
 
 //Heartbeat payload example:
@@ -29,5 +32,8 @@ type Heartbeat struct {
 //  "ntp": {
 //      "offset": "-0.06423",
 //      "timestamp": "14 Oct 11:13:19"
-//  }
+//  },
+//  "metrics": [
+//    {"name": "cloud_controller.requests", "type": "counter", "value": 1024, "timestamp": 1413285199}
+//  ]
 //}
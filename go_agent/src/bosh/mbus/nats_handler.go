@@ -1,14 +1,16 @@
 package mbus
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/cloudfoundry/yagnats"
+	"math/rand"
+	"net"
 	"net/url"
-	"os"
-	"os/signal"
-	"syscall"
+	"sync"
+	"time"
 
 	bosherr "bosh/errors"
 	boshhandler "bosh/handler"
@@ -19,24 +21,81 @@ import (
 const (
 	natsHandlerLogTag = "NATS Handler"
 	responseMaxLength = 1024 * 1024
+
+	// Chunk data is base64 encoded so that chunk messages stay under responseMaxLength
+	responseChunkSize = 512 * 1024
+
+	defaultNatsPingInterval = 5 * time.Second
+	defaultNatsMinBackoff   = 1 * time.Second
+	defaultNatsMaxBackoff   = 1 * time.Minute
+
+	natsDialTimeout = 10 * time.Second
 )
 
+type NatsOptions struct {
+	// How often connection is checked; defaults to 5s
+	PingInterval time.Duration
+
+	// Reconnect delay bounds; default to 1s and 1m
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// When set all connections are made over TLS
+	TLS *NatsTLS
+
+	// When set only verified requests are dispatched
+	RequestVerifier boshhandler.RequestVerifier
+}
+
 type natsHandler struct {
 	settingsService boshsettings.Service
 	client          yagnats.NATSClient
 	logger          boshlog.Logger
+	options         NatsOptions
 	handlerFuncs    []boshhandler.HandlerFunc
+
+	// Index of the URL from settings used for the next connection attempt
+	urlIndex int
+
+	status     ConnectionStatus
+	statusLock sync.Mutex
+
+	// Triggers reconnect when publishing fails
+	checkCh chan struct{}
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+
+	random *rand.Rand
 }
 
 func NewNatsHandler(
 	settingsService boshsettings.Service,
 	client yagnats.NATSClient,
+	options NatsOptions,
 	logger boshlog.Logger,
 ) *natsHandler {
+	if options.PingInterval <= 0 {
+		options.PingInterval = defaultNatsPingInterval
+	}
+
+	if options.MinBackoff <= 0 {
+		options.MinBackoff = defaultNatsMinBackoff
+	}
+
+	if options.MaxBackoff < options.MinBackoff {
+		options.MaxBackoff = defaultNatsMaxBackoff
+	}
+
 	return &natsHandler{
 		settingsService: settingsService,
 		client:          client,
 		logger:          logger,
+		options:         options,
+		status:          ConnectionStatus{State: ConnectionStateDisconnected},
+		checkCh:         make(chan struct{}, 1),
+		stopCh:          make(chan struct{}),
+		random:          rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
@@ -48,7 +107,9 @@ func (h *natsHandler) Run(handlerFunc boshhandler.HandlerFunc) error {
 
 	defer h.Stop()
 
-	h.runUntilInterrupted()
+	// Agent coordinates shutdown and stops handler
+	// once pending responses and alerts are sent
+	<-h.stopCh
 
 	return nil
 }
@@ -56,27 +117,28 @@ func (h *natsHandler) Run(handlerFunc boshhandler.HandlerFunc) error {
 func (h *natsHandler) Start(handlerFunc boshhandler.HandlerFunc) error {
 	h.RegisterAdditionalHandlerFunc(handlerFunc)
 
-	connProvider, err := h.getConnectionInfo()
-	if err != nil {
-		return bosherr.WrapError(err, "Getting connection info")
+	h.setState(ConnectionStateConnecting)
+
+	// Each URL is tried once before giving up on initial connection
+	var err error
+
+	for i := 0; i < len(h.settingsService.GetSettings().GetMbusURLs()); i++ {
+		err = h.connect()
+		if err == nil {
+			break
+		}
+
+		h.logger.Error(natsHandlerLogTag, "Connecting: %s", err.Error())
 	}
 
-	err = h.client.Connect(connProvider)
 	if err != nil {
+		h.setState(ConnectionStateDisconnected)
 		return bosherr.WrapError(err, "Connecting")
 	}
 
-	settings := h.settingsService.GetSettings()
-
-	subject := fmt.Sprintf("agent.%s", settings.AgentID)
+	h.setState(ConnectionStateConnected)
 
-	h.logger.Error(natsHandlerLogTag, "Subscribing to %s", subject)
-
-	_, err = h.client.Subscribe(subject, func(natsMsg *yagnats.Message) {
-		for _, handlerFunc := range h.handlerFuncs {
-			h.handleNatsMsg(natsMsg, handlerFunc)
-		}
-	})
+	go h.superviseConnection()
 
 	return nil
 }
@@ -87,7 +149,17 @@ func (h *natsHandler) RegisterAdditionalHandlerFunc(handlerFunc boshhandler.Hand
 	h.handlerFuncs = append(h.handlerFuncs, handlerFunc)
 }
 
-func (h natsHandler) SendToHealthManager(topic string, payload interface{}) error {
+func (h *natsHandler) GetConnectionStatus() ConnectionStatus {
+	h.statusLock.Lock()
+	defer h.statusLock.Unlock()
+
+	return h.status
+}
+
+// SendToHealthManager returns ErrNotConnected when message is dropped
+// because connection is being re-established; callers decide
+// whether temporary NATS outage is tolerable
+func (h *natsHandler) SendToHealthManager(topic string, payload interface{}) error {
 	msgBytes := []byte("")
 
 	if payload != nil {
@@ -104,17 +176,164 @@ func (h natsHandler) SendToHealthManager(topic string, payload interface{}) erro
 	settings := h.settingsService.GetSettings()
 
 	subject := fmt.Sprintf("hm.agent.%s.%s", topic, settings.AgentID)
-	return h.client.Publish(subject, msgBytes)
+
+	if h.GetConnectionStatus().State != ConnectionStateConnected {
+		return ErrNotConnected
+	}
+
+	err := h.client.Publish(subject, msgBytes)
+	if err != nil {
+		h.logger.Error(natsHandlerLogTag, "Publishing HM message '%s': %s", topic, err.Error())
+		h.requestConnectionCheck()
+		return bosherr.WrapError(ErrNotConnected, "Publishing HM message '%s'", topic)
+	}
+
+	return nil
 }
 
-func (h natsHandler) Stop() {
+func (h *natsHandler) Stop() {
+	h.stopOnce.Do(func() { close(h.stopCh) })
 	h.client.Disconnect()
+	h.setState(ConnectionStateDisconnected)
 }
 
-func (h natsHandler) handleNatsMsg(natsMsg *yagnats.Message, handlerFunc boshhandler.HandlerFunc) {
+func (h *natsHandler) connect() error {
+	urls := h.settingsService.GetSettings().GetMbusURLs()
+	if len(urls) == 0 {
+		return errors.New("No NATS URLs configured")
+	}
+
+	natsURL := urls[h.urlIndex%len(urls)]
+	h.urlIndex++
+
+	connProvider, err := h.getConnectionInfo(natsURL)
+	if err != nil {
+		return bosherr.WrapError(err, "Getting connection info")
+	}
+
+	if h.options.TLS != nil {
+		connProvider.Dial, err = h.tlsDialFunc(connProvider.Addr)
+		if err != nil {
+			return bosherr.WrapError(err, "Preparing TLS connection to %s", connProvider.Addr)
+		}
+	}
+
+	err = h.client.Connect(connProvider)
+	if err != nil {
+		return bosherr.WrapError(err, "Connecting to %s", connProvider.Addr)
+	}
+
+	settings := h.settingsService.GetSettings()
+
+	subject := fmt.Sprintf("agent.%s", settings.AgentID)
+
+	h.logger.Info(natsHandlerLogTag, "Subscribing to %s", subject)
+
+	_, err = h.client.Subscribe(subject, func(natsMsg *yagnats.Message) {
+		for _, handlerFunc := range h.handlerFuncs {
+			h.handleNatsMsg(natsMsg, handlerFunc)
+		}
+	})
+	if err != nil {
+		h.client.Disconnect()
+		return bosherr.WrapError(err, "Subscribing to %s", subject)
+	}
+
+	return nil
+}
+
+// tlsDialFunc returns function used by NATS client to open connections;
+// connection is only returned after server certificate is verified
+// so there is no way to fall back to plaintext
+func (h *natsHandler) tlsDialFunc(addr string) (func(network, address string) (net.Conn, error), error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing address")
+	}
+
+	tlsConfig := h.options.TLS.ConfigFor(host)
+
+	return func(network, address string) (net.Conn, error) {
+		dialer := &net.Dialer{Timeout: natsDialTimeout}
+		return tls.DialWithDialer(dialer, network, address, tlsConfig)
+	}, nil
+}
+
+func (h *natsHandler) superviseConnection() {
+	defer h.logger.HandlePanic("NATS Connection Supervisor")
+
+	ticker := time.NewTicker(h.options.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-h.checkCh:
+		case <-h.stopCh:
+			return
+		}
+
+		if h.client.Ping() {
+			continue
+		}
+
+		h.logger.Error(natsHandlerLogTag, "Lost connection")
+
+		if !h.reconnect() {
+			return
+		}
+	}
+}
+
+// reconnect returns false if handler was stopped before connection was re-established
+func (h *natsHandler) reconnect() bool {
+	h.setState(ConnectionStateReconnecting)
+
+	for attempt := 0; ; attempt++ {
+		h.client.Disconnect()
+
+		err := h.connect()
+		if err == nil {
+			h.statusLock.Lock()
+			h.status.State = ConnectionStateConnected
+			h.status.Reconnects++
+			h.statusLock.Unlock()
+
+			h.logger.Info(natsHandlerLogTag, "Reconnected after %d attempt(s)", attempt+1)
+			return true
+		}
+
+		delay := backoffDelay(attempt, h.options.MinBackoff, h.options.MaxBackoff, h.random)
+
+		h.logger.Error(natsHandlerLogTag, "Reconnecting: %s; retrying in %s", err.Error(), delay)
+
+		select {
+		case <-time.After(delay):
+		case <-h.stopCh:
+			return false
+		}
+	}
+}
+
+func (h *natsHandler) requestConnectionCheck() {
+	select {
+	case h.checkCh <- struct{}{}:
+	default:
+	}
+}
+
+func (h *natsHandler) setState(state string) {
+	h.statusLock.Lock()
+	defer h.statusLock.Unlock()
+
+	h.status.State = state
+}
+
+func (h *natsHandler) handleNatsMsg(natsMsg *yagnats.Message, handlerFunc boshhandler.HandlerFunc) {
 	respBytes, req, err := boshhandler.PerformHandlerWithJSON(
 		natsMsg.Payload,
 		handlerFunc,
+		h.options.RequestVerifier,
 		responseMaxLength,
 		h.logger,
 	)
@@ -123,31 +342,34 @@ func (h natsHandler) handleNatsMsg(natsMsg *yagnats.Message, handlerFunc boshhan
 		return
 	}
 
-	if len(respBytes) > 0 {
-		h.client.Publish(req.ReplyTo, respBytes)
+	if len(respBytes) == 0 {
+		return
 	}
-}
 
-func (h natsHandler) runUntilInterrupted() {
-	defer h.client.Disconnect()
+	if !req.ChunkedReplies || len(respBytes) <= responseMaxLength {
+		h.client.Publish(req.ReplyTo, respBytes)
+		return
+	}
 
-	keepRunning := true
+	chunks, err := boshhandler.SplitIntoChunks(respBytes, responseChunkSize)
+	if err != nil {
+		h.logger.Error(natsHandlerLogTag, "Splitting response into chunks: %s", err)
+		return
+	}
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	h.logger.Info(natsHandlerLogTag, "Responding with %d chunks", len(chunks))
 
-	for keepRunning {
-		select {
-		case <-c:
-			keepRunning = false
+	for _, chunk := range chunks {
+		err = h.client.Publish(req.ReplyTo, chunk)
+		if err != nil {
+			h.logger.Error(natsHandlerLogTag, "Publishing response chunk: %s", err)
+			return
 		}
 	}
 }
 
-func (h natsHandler) getConnectionInfo() (*yagnats.ConnectionInfo, error) {
-	settings := h.settingsService.GetSettings()
-
-	natsURL, err := url.Parse(settings.Mbus)
+func (h *natsHandler) getConnectionInfo(mbusURL string) (*yagnats.ConnectionInfo, error) {
+	natsURL, err := url.Parse(mbusURL)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Parsing Nats URL")
 	}
@@ -1,7 +1,11 @@
 package mbus_test
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -9,11 +13,17 @@ import (
 	"github.com/cloudfoundry/yagnats"
 	"github.com/cloudfoundry/yagnats/fakeyagnats"
 
+	testcrypto "bosh/crypto/testing"
+	bosherr "bosh/errors"
 	boshhandler "bosh/handler"
+	fakehandler "bosh/handler/fakes"
 	boshlog "bosh/logger"
 	. "bosh/mbus"
+	fakembus "bosh/mbus/fakes"
 	boshsettings "bosh/settings"
+	boshdir "bosh/settings/directories"
 	fakesettings "bosh/settings/fakes"
+	fakesys "bosh/system/fakes"
 )
 
 func init() {
@@ -34,7 +44,7 @@ func init() {
 			}
 			logger = boshlog.NewLogger(boshlog.LevelNone)
 			client = fakeyagnats.New()
-			handler = NewNatsHandler(settingsService, client, logger)
+			handler = NewNatsHandler(settingsService, client, NatsOptions{}, logger)
 		})
 
 		Describe("Start", func() {
@@ -53,7 +63,7 @@ func init() {
 				subscriptions := client.Subscriptions["agent.my-agent-id"]
 				Expect(len(subscriptions)).To(Equal(1))
 
-				expectedPayload := []byte(`{"method":"ping","arguments":["foo","bar"], "reply_to": "reply to me!"}`)
+				expectedPayload := []byte(`{"method":"ping","arguments":["foo","bar"], "reply_to": "reply to me!", "request_id": "fake-request-id"}`)
 				subscription := client.Subscriptions["agent.my-agent-id"][0]
 				subscription.Callback(&yagnats.Message{
 					Subject: "agent.my-agent-id",
@@ -61,9 +71,11 @@ func init() {
 				})
 
 				Expect(receivedRequest).To(Equal(boshhandler.Request{
-					ReplyTo: "reply to me!",
-					Method:  "ping",
-					Payload: expectedPayload,
+					ReplyTo:       "reply to me!",
+					Method:        "ping",
+					Payload:       expectedPayload,
+					RequestID:     "fake-request-id",
+					CorrelationID: "fake-request-id",
 				}))
 
 				Expect(len(client.PublishedMessages)).To(Equal(1))
@@ -72,6 +84,61 @@ func init() {
 				Expect(messages[0].Payload).To(Equal([]byte(`{"value":"expected value"}`)))
 			})
 
+			Context("when request verifier is configured", func() {
+				var verifier *fakehandler.FakeRequestVerifier
+
+				BeforeEach(func() {
+					verifier = &fakehandler.FakeRequestVerifier{}
+					handler = NewNatsHandler(settingsService, client, NatsOptions{RequestVerifier: verifier}, logger)
+				})
+
+				It("dispatches verified requests", func() {
+					err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
+						return boshhandler.NewValueResponse("expected value")
+					})
+					Expect(err).ToNot(HaveOccurred())
+					defer handler.Stop()
+
+					subscription := client.Subscriptions["agent.my-agent-id"][0]
+					subscription.Callback(&yagnats.Message{
+						Subject: "agent.my-agent-id",
+						Payload: []byte(`{"method":"ping","arguments":[],"reply_to":"fake-reply-to","key_id":"fake-key-id"}`),
+					})
+
+					Expect(len(verifier.VerifyRequests)).To(Equal(1))
+					Expect(verifier.VerifyRequests[0].KeyID).To(Equal("fake-key-id"))
+
+					messages := client.PublishedMessages["fake-reply-to"]
+					Expect(len(messages)).To(Equal(1))
+					Expect(messages[0].Payload).To(Equal([]byte(`{"value":"expected value"}`)))
+				})
+
+				It("responds with unauthorized request exception without dispatching rejected requests", func() {
+					verifier.VerifyErr = errors.New("fake-verify-err")
+
+					dispatched := false
+					err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
+						dispatched = true
+						return boshhandler.NewValueResponse("expected value")
+					})
+					Expect(err).ToNot(HaveOccurred())
+					defer handler.Stop()
+
+					subscription := client.Subscriptions["agent.my-agent-id"][0]
+					subscription.Callback(&yagnats.Message{
+						Subject: "agent.my-agent-id",
+						Payload: []byte(`{"method":"ssh","arguments":[],"reply_to":"fake-reply-to","request_id":"fake-request-id"}`),
+					})
+
+					Expect(dispatched).To(BeFalse())
+
+					messages := client.PublishedMessages["fake-reply-to"]
+					Expect(len(messages)).To(Equal(1))
+					Expect(messages[0].Payload).To(Equal([]byte(
+						`{"exception":{"message":"Verifying request: fake-verify-err","type":"unauthorized_request","correlation_id":"fake-request-id"}}`)))
+				})
+			})
+
 			It("does not respond if the response is nil", func() {
 				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
 					return nil
@@ -130,6 +197,64 @@ func init() {
 					`{"exception":{"message":"Response exceeded maximum allowed length"}}`)))
 			})
 
+			It("responds with chunks if the response is bigger than 1MB and chunked replies are accepted", func() {
+				chars := make([]byte, 2*1024*1024)
+				for i := range chars {
+					chars[i] = 'A'
+				}
+
+				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
+					return boshhandler.NewValueResponse(string(chars))
+				})
+				Expect(err).ToNot(HaveOccurred())
+				defer handler.Stop()
+
+				subscription := client.Subscriptions["agent.my-agent-id"][0]
+				subscription.Callback(&yagnats.Message{
+					Subject: "agent.my-agent-id",
+					Payload: []byte(`{"method":"big","arguments":[],"reply_to":"fake-reply-to","chunked_replies":true}`),
+				})
+
+				messages := client.PublishedMessages["fake-reply-to"]
+				Expect(len(messages)).To(Equal(5))
+
+				reassembled := []byte{}
+
+				for i, message := range messages {
+					Expect(len(message.Payload)).To(BeNumerically("<=", 1024*1024))
+
+					var chunk boshhandler.ResponseChunk
+					err := json.Unmarshal(message.Payload, &chunk)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(chunk.Chunk.Index).To(Equal(i))
+					Expect(chunk.Chunk.Count).To(Equal(5))
+
+					reassembled = append(reassembled, chunk.Data...)
+				}
+
+				expectedJSON, err := json.Marshal(boshhandler.NewValueResponse(string(chars)))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(reassembled).To(Equal(expectedJSON))
+			})
+
+			It("responds without chunks if the response fits even though chunked replies are accepted", func() {
+				err := handler.Start(func(req boshhandler.Request) (resp boshhandler.Response) {
+					return boshhandler.NewValueResponse("fake-value")
+				})
+				Expect(err).ToNot(HaveOccurred())
+				defer handler.Stop()
+
+				subscription := client.Subscriptions["agent.my-agent-id"][0]
+				subscription.Callback(&yagnats.Message{
+					Subject: "agent.my-agent-id",
+					Payload: []byte(`{"method":"ping","arguments":[],"reply_to":"fake-reply-to","chunked_replies":true}`),
+				})
+
+				messages := client.PublishedMessages["fake-reply-to"]
+				Expect(len(messages)).To(Equal(1))
+				Expect(messages[0].Payload).To(Equal([]byte(`{"value":"fake-value"}`)))
+			})
+
 			It("can add additional handler funcs to receive requests", func() {
 				var firstHandlerReq, secondHandlerRequest boshhandler.Request
 
@@ -144,7 +269,7 @@ func init() {
 					return boshhandler.NewValueResponse("second-handler-resp")
 				})
 
-				expectedPayload := []byte(`{"method":"ping","arguments":["foo","bar"], "reply_to": "fake-reply-to"}`)
+				expectedPayload := []byte(`{"method":"ping","arguments":["foo","bar"], "reply_to": "fake-reply-to", "request_id": "fake-request-id"}`)
 
 				subscription := client.Subscriptions["agent.my-agent-id"][0]
 				subscription.Callback(&yagnats.Message{
@@ -154,15 +279,19 @@ func init() {
 
 				// Expected requests received by both handlers
 				Expect(firstHandlerReq).To(Equal(boshhandler.Request{
-					ReplyTo: "fake-reply-to",
-					Method:  "ping",
-					Payload: expectedPayload,
+					ReplyTo:       "fake-reply-to",
+					Method:        "ping",
+					Payload:       expectedPayload,
+					RequestID:     "fake-request-id",
+					CorrelationID: "fake-request-id",
 				}))
 
 				Expect(secondHandlerRequest).To(Equal(boshhandler.Request{
-					ReplyTo: "fake-reply-to",
-					Method:  "ping",
-					Payload: expectedPayload,
+					ReplyTo:       "fake-reply-to",
+					Method:        "ping",
+					Payload:       expectedPayload,
+					RequestID:     "fake-request-id",
+					CorrelationID: "fake-request-id",
 				}))
 
 				// Bosh handler responses were sent
@@ -187,7 +316,7 @@ func init() {
 
 			It("does not err when no username and password", func() {
 				settingsService.Settings.Mbus = "nats://127.0.0.1:1234"
-				handler = NewNatsHandler(settingsService, client, logger)
+				handler = NewNatsHandler(settingsService, client, NatsOptions{}, logger)
 
 				err := handler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
 				Expect(err).ToNot(HaveOccurred())
@@ -196,7 +325,7 @@ func init() {
 
 			It("errs when has username without password", func() {
 				settingsService.Settings.Mbus = "nats://foo@127.0.0.1:1234"
-				handler = NewNatsHandler(settingsService, client, logger)
+				handler = NewNatsHandler(settingsService, client, NatsOptions{}, logger)
 
 				err := handler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
 				Expect(err).To(HaveOccurred())
@@ -206,6 +335,10 @@ func init() {
 
 		Describe("SendToHealthManager", func() {
 			It("sends periodic heartbeats", func() {
+				err := handler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
+				Expect(err).ToNot(HaveOccurred())
+				defer handler.Stop()
+
 				errCh := make(chan error, 1)
 
 				jobName := "foo"
@@ -216,7 +349,6 @@ func init() {
 					errCh <- handler.SendToHealthManager("heartbeat", expectedHeartbeat)
 				}()
 
-				var err error
 				select {
 				case err = <-errCh:
 				}
@@ -231,6 +363,260 @@ func init() {
 				expectedJSON, _ := json.Marshal(expectedHeartbeat)
 				Expect(string(expectedJSON)).To(Equal(string(message.Payload)))
 			})
+
+			It("returns not connected error when not connected", func() {
+				err := handler.SendToHealthManager("heartbeat", nil)
+				Expect(err).To(Equal(ErrNotConnected))
+
+				Expect(len(client.PublishedMessages)).To(Equal(0))
+			})
+		})
+
+		Describe("TLS", func() {
+			var (
+				ca     testcrypto.Certificate
+				server *fakembus.FakeNATSServer
+			)
+
+			BeforeEach(func() {
+				ca = testcrypto.GenerateCertificate("fake-ca", nil)
+			})
+
+			AfterEach(func() {
+				if server != nil {
+					server.Stop()
+				}
+				handler.Stop()
+			})
+
+			// startTLSServer starts NATS server which requires client certificate signed by CA
+			startTLSServer := func(serverCert testcrypto.Certificate) {
+				clientCAs := x509.NewCertPool()
+				clientCAs.AppendCertsFromPEM([]byte(ca.CertPEM))
+
+				var err error
+				server, err = fakembus.NewFakeTLSNATSServer(&tls.Config{
+					Certificates: []tls.Certificate{serverCert.TLSCertificate()},
+					ClientAuth:   tls.RequireAndVerifyClientCert,
+					ClientCAs:    clientCAs,
+				})
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			startTLSHandler := func() error {
+				client := testcrypto.GenerateCertificate("fake-client", &ca)
+
+				natsTLS, err := NewNatsTLS(fakesys.NewFakeFileSystem(), boshdir.NewDirectoriesProvider("/var/vcap"), boshsettings.MbusTLS{
+					CA:          ca.CertPEM,
+					Certificate: client.CertPEM,
+					PrivateKey:  client.KeyPEM,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				settingsService.Settings.Mbus = "nats+tls://" + server.Addr()
+				handler = NewNatsHandler(settingsService, yagnats.NewClient(), NatsOptions{TLS: &natsTLS}, logger)
+
+				return handler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
+			}
+
+			It("connects over mutually authenticated TLS", func() {
+				startTLSServer(testcrypto.GenerateCertificate("fake-server", &ca))
+
+				err := startTLSHandler()
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(func() int {
+					return server.SubscriptionsCount("agent.my-agent-id")
+				}).Should(Equal(1))
+			})
+
+			It("fails to connect instead of falling back to plaintext when server certificate is not trusted", func() {
+				otherCA := testcrypto.GenerateCertificate("fake-other-ca", nil)
+				startTLSServer(testcrypto.GenerateCertificate("fake-server", &otherCA))
+
+				err := startTLSHandler()
+				Expect(err).To(HaveOccurred())
+				Expect(server.SubscriptionsCount("agent.my-agent-id")).To(Equal(0))
+			})
+
+			It("fails to connect when server does not speak TLS", func() {
+				var err error
+				server, err = fakembus.NewFakeNATSServer()
+				Expect(err).ToNot(HaveOccurred())
+
+				err = startTLSHandler()
+				Expect(err).To(HaveOccurred())
+				Expect(server.SubscriptionsCount("agent.my-agent-id")).To(Equal(0))
+			})
+		})
+
+		Describe("reconnecting", func() {
+			var (
+				natsClient  *fakembus.FakeNATSClient
+				natsHandler interface {
+					boshhandler.Handler
+					ConnectionStatusProvider
+				}
+			)
+
+			BeforeEach(func() {
+				settingsService.Settings.Mbus = "nats://127.0.0.1:1234, nats://127.0.0.2:1234"
+				natsClient = fakembus.NewFakeNATSClient()
+			})
+
+			startHandler := func(options NatsOptions) {
+				natsHandler = NewNatsHandler(settingsService, natsClient, options, logger)
+
+				err := natsHandler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			reconnects := func() uint64 {
+				return natsHandler.GetConnectionStatus().Reconnects
+			}
+
+			fastOptions := NatsOptions{
+				PingInterval: 10 * time.Millisecond,
+				MinBackoff:   time.Millisecond,
+				MaxBackoff:   5 * time.Millisecond,
+			}
+
+			AfterEach(func() {
+				natsHandler.Stop()
+			})
+
+			It("reports connected state after start", func() {
+				startHandler(fastOptions)
+
+				Expect(natsHandler.GetConnectionStatus()).To(Equal(ConnectionStatus{
+					State:      ConnectionStateConnected,
+					Reconnects: 0,
+				}))
+			})
+
+			It("reconnects to next url and resubscribes when connection is dropped", func() {
+				startHandler(fastOptions)
+
+				natsClient.Drop()
+
+				Eventually(reconnects).Should(Equal(uint64(1)))
+				Expect(natsHandler.GetConnectionStatus().State).To(Equal(ConnectionStateConnected))
+				Expect(natsClient.ConnectedAddrs()).To(Equal([]string{"127.0.0.1:1234", "127.0.0.2:1234"}))
+				Expect(natsClient.SubscriptionsCount("agent.my-agent-id")).To(Equal(1))
+			})
+
+			It("keeps retrying while servers refuse connections", func() {
+				startHandler(fastOptions)
+
+				natsClient.SetConnectErr("127.0.0.1:1234", errors.New("fake-connect-err"))
+				natsClient.SetConnectErr("127.0.0.2:1234", errors.New("fake-connect-err"))
+				natsClient.Drop()
+
+				Eventually(func() string {
+					return natsHandler.GetConnectionStatus().State
+				}).Should(Equal(ConnectionStateReconnecting))
+
+				Consistently(reconnects, 50*time.Millisecond).Should(Equal(uint64(0)))
+
+				natsClient.SetConnectErr("127.0.0.2:1234", nil)
+
+				Eventually(reconnects).Should(Equal(uint64(1)))
+				Expect(natsClient.SubscriptionsCount("agent.my-agent-id")).To(Equal(1))
+			})
+
+			It("returns not connected error for heartbeats while reconnecting", func() {
+				startHandler(fastOptions)
+
+				natsClient.SetConnectErr("127.0.0.1:1234", errors.New("fake-connect-err"))
+				natsClient.SetConnectErr("127.0.0.2:1234", errors.New("fake-connect-err"))
+				natsClient.Drop()
+
+				Eventually(func() string {
+					return natsHandler.GetConnectionStatus().State
+				}).Should(Equal(ConnectionStateReconnecting))
+
+				err := natsHandler.SendToHealthManager("heartbeat", nil)
+				Expect(err).To(Equal(ErrNotConnected))
+			})
+
+			It("reconnects right away when publishing fails", func() {
+				startHandler(NatsOptions{
+					PingInterval: time.Hour,
+					MinBackoff:   time.Millisecond,
+					MaxBackoff:   5 * time.Millisecond,
+				})
+
+				natsClient.Drop()
+
+				err := natsHandler.SendToHealthManager("heartbeat", nil)
+				Expect(err).To(HaveOccurred())
+				Expect(bosherr.Cause(err)).To(Equal(ErrNotConnected))
+
+				Eventually(reconnects).Should(Equal(uint64(1)))
+			})
+
+			It("returns error from start when all urls refuse connections", func() {
+				natsClient.SetConnectErr("127.0.0.1:1234", errors.New("fake-connect-err-1"))
+				natsClient.SetConnectErr("127.0.0.2:1234", errors.New("fake-connect-err-2"))
+
+				natsHandler = NewNatsHandler(settingsService, natsClient, fastOptions, logger)
+
+				err := natsHandler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-connect-err-2"))
+				Expect(natsHandler.GetConnectionStatus().State).To(Equal(ConnectionStateDisconnected))
+			})
+
+			Context("when connected to NATS server over TCP", func() {
+				var server *fakembus.FakeNATSServer
+
+				BeforeEach(func() {
+					var err error
+					server, err = fakembus.NewFakeNATSServer()
+					Expect(err).ToNot(HaveOccurred())
+
+					settingsService.Settings.Mbus = "nats://" + server.Addr()
+				})
+
+				AfterEach(func() {
+					server.Stop()
+				})
+
+				It("reconnects and resubscribes after server restarts", func() {
+					natsHandler = NewNatsHandler(settingsService, yagnats.NewClient(), fastOptions, logger)
+
+					err := natsHandler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(func() int {
+						return server.SubscriptionsCount("agent.my-agent-id")
+					}).Should(Equal(1))
+
+					server.Stop()
+
+					Eventually(func() string {
+						return natsHandler.GetConnectionStatus().State
+					}).Should(Equal(ConnectionStateReconnecting))
+
+					err = natsHandler.SendToHealthManager("heartbeat", nil)
+					Expect(bosherr.Cause(err)).To(Equal(ErrNotConnected))
+
+					err = server.Start()
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(reconnects).Should(Equal(uint64(1)))
+					Eventually(func() int {
+						return server.SubscriptionsCount("agent.my-agent-id")
+					}).Should(Equal(1))
+
+					err = natsHandler.SendToHealthManager("heartbeat", nil)
+					Expect(err).ToNot(HaveOccurred())
+
+					Eventually(func() [][]byte {
+						return server.PublishedMessages("hm.agent.heartbeat.my-agent-id")
+					}).Should(HaveLen(1))
+				})
+			})
 		})
 	})
 }
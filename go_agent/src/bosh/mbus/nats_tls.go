@@ -0,0 +1,121 @@
+package mbus
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+
+	bosherr "bosh/errors"
+	boshsettings "bosh/settings"
+	boshdir "bosh/settings/directories"
+	boshsys "bosh/system"
+)
+
+const (
+	natsTLSCAFileName          = "ca.pem"
+	natsTLSCertificateFileName = "certificate.pem"
+	natsTLSPrivateKeyFileName  = "private_key.pem"
+)
+
+// NatsTLS holds certificates used to establish TLS connections to NATS
+type NatsTLS struct {
+	// Nil means system CAs are used
+	RootCAs *x509.CertPool
+
+	ClientCertificates []tls.Certificate
+}
+
+// ConfigFor returns configuration verifying that server certificate is valid for host
+func (t NatsTLS) ConfigFor(host string) *tls.Config {
+	return &tls.Config{
+		RootCAs:      t.RootCAs,
+		Certificates: t.ClientCertificates,
+		ServerName:   host,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// NewNatsTLS writes certificate material from settings into
+// bosh/etc/mbus readable only by the agent and loads it back.
+func NewNatsTLS(
+	fs boshsys.FileSystem,
+	dirProvider boshdir.DirectoriesProvider,
+	mbusTLS boshsettings.MbusTLS,
+) (NatsTLS, error) {
+	var natsTLS NatsTLS
+
+	certsDir := filepath.Join(dirProvider.EtcDir(), "mbus")
+
+	err := fs.MkdirAll(certsDir, os.FileMode(0700))
+	if err != nil {
+		return natsTLS, bosherr.WrapError(err, "Creating certificates directory")
+	}
+
+	// Directory might have existed with broader permissions
+	err = fs.Chmod(certsDir, os.FileMode(0700))
+	if err != nil {
+		return natsTLS, bosherr.WrapError(err, "Restricting certificates directory permissions")
+	}
+
+	if mbusTLS.CA != "" {
+		caPath := filepath.Join(certsDir, natsTLSCAFileName)
+
+		caPEM, err := writeRestrictedFile(fs, caPath, mbusTLS.CA)
+		if err != nil {
+			return natsTLS, bosherr.WrapError(err, "Writing CA certificate")
+		}
+
+		natsTLS.RootCAs = x509.NewCertPool()
+
+		if !natsTLS.RootCAs.AppendCertsFromPEM(caPEM) {
+			return natsTLS, bosherr.New("Parsing CA certificate")
+		}
+	}
+
+	if mbusTLS.Certificate == "" && mbusTLS.PrivateKey == "" {
+		return natsTLS, nil
+	}
+
+	if mbusTLS.Certificate == "" || mbusTLS.PrivateKey == "" {
+		return natsTLS, bosherr.New("Both client certificate and private key must be provided")
+	}
+
+	certPEM, err := writeRestrictedFile(fs, filepath.Join(certsDir, natsTLSCertificateFileName), mbusTLS.Certificate)
+	if err != nil {
+		return natsTLS, bosherr.WrapError(err, "Writing client certificate")
+	}
+
+	keyPEM, err := writeRestrictedFile(fs, filepath.Join(certsDir, natsTLSPrivateKeyFileName), mbusTLS.PrivateKey)
+	if err != nil {
+		return natsTLS, bosherr.WrapError(err, "Writing client private key")
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return natsTLS, bosherr.WrapError(err, "Parsing client certificate and private key")
+	}
+
+	natsTLS.ClientCertificates = []tls.Certificate{cert}
+
+	return natsTLS, nil
+}
+
+func writeRestrictedFile(fs boshsys.FileSystem, path, content string) ([]byte, error) {
+	err := fs.WriteFileString(path, content)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Writing %s", path)
+	}
+
+	err = fs.Chmod(path, os.FileMode(0600))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Restricting %s permissions", path)
+	}
+
+	bytes, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Reading %s", path)
+	}
+
+	return bytes, nil
+}
@@ -0,0 +1,110 @@
+package mbus_test
+
+import (
+	"crypto/tls"
+	"errors"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	testcrypto "bosh/crypto/testing"
+	. "bosh/mbus"
+	boshsettings "bosh/settings"
+	boshdir "bosh/settings/directories"
+	fakesys "bosh/system/fakes"
+)
+
+var _ = Describe("NewNatsTLS", func() {
+	var (
+		fs          *fakesys.FakeFileSystem
+		dirProvider boshdir.DirectoriesProvider
+		ca          testcrypto.Certificate
+		client      testcrypto.Certificate
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		dirProvider = boshdir.NewDirectoriesProvider("/var/vcap")
+		ca = testcrypto.GenerateCertificate("fake-ca", nil)
+		client = testcrypto.GenerateCertificate("fake-client", &ca)
+	})
+
+	It("writes certificates readable only by owner and loads them", func() {
+		natsTLS, err := NewNatsTLS(fs, dirProvider, boshsettings.MbusTLS{
+			CA:          ca.CertPEM,
+			Certificate: client.CertPEM,
+			PrivateKey:  client.KeyPEM,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fs.GetFileTestStat("/var/vcap/bosh/etc/mbus").FileMode).To(Equal(os.FileMode(0700)))
+
+		caStats := fs.GetFileTestStat("/var/vcap/bosh/etc/mbus/ca.pem")
+		Expect(caStats.StringContents()).To(Equal(ca.CertPEM))
+		Expect(caStats.FileMode).To(Equal(os.FileMode(0600)))
+
+		certStats := fs.GetFileTestStat("/var/vcap/bosh/etc/mbus/certificate.pem")
+		Expect(certStats.StringContents()).To(Equal(client.CertPEM))
+		Expect(certStats.FileMode).To(Equal(os.FileMode(0600)))
+
+		keyStats := fs.GetFileTestStat("/var/vcap/bosh/etc/mbus/private_key.pem")
+		Expect(keyStats.StringContents()).To(Equal(client.KeyPEM))
+		Expect(keyStats.FileMode).To(Equal(os.FileMode(0600)))
+
+		Expect(natsTLS.RootCAs).ToNot(BeNil())
+		Expect(natsTLS.ClientCertificates).To(HaveLen(1))
+	})
+
+	It("uses system CAs and no client certificate when none are given", func() {
+		natsTLS, err := NewNatsTLS(fs, dirProvider, boshsettings.MbusTLS{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(natsTLS.RootCAs).To(BeNil())
+		Expect(natsTLS.ClientCertificates).To(BeEmpty())
+	})
+
+	It("returns error when CA certificate cannot be parsed", func() {
+		_, err := NewNatsTLS(fs, dirProvider, boshsettings.MbusTLS{CA: "fake-invalid-ca"})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing CA certificate"))
+	})
+
+	It("returns error when only client certificate is given", func() {
+		_, err := NewNatsTLS(fs, dirProvider, boshsettings.MbusTLS{Certificate: client.CertPEM})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Both client certificate and private key must be provided"))
+	})
+
+	It("returns error when client private key does not match certificate", func() {
+		otherClient := testcrypto.GenerateCertificate("fake-other-client", &ca)
+
+		_, err := NewNatsTLS(fs, dirProvider, boshsettings.MbusTLS{
+			Certificate: client.CertPEM,
+			PrivateKey:  otherClient.KeyPEM,
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing client certificate and private key"))
+	})
+
+	It("returns error when certificate cannot be written", func() {
+		fs.WriteToFileError = errors.New("fake-write-err")
+
+		_, err := NewNatsTLS(fs, dirProvider, boshsettings.MbusTLS{CA: ca.CertPEM})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+	})
+
+	Describe("ConfigFor", func() {
+		It("requires at least TLS 1.2 and verifies server host", func() {
+			natsTLS, err := NewNatsTLS(fs, dirProvider, boshsettings.MbusTLS{CA: ca.CertPEM})
+			Expect(err).ToNot(HaveOccurred())
+
+			config := natsTLS.ConfigFor("fake-host")
+			Expect(config.ServerName).To(Equal("fake-host"))
+			Expect(config.MinVersion).To(Equal(uint16(tls.VersionTLS12)))
+			Expect(config.InsecureSkipVerify).To(BeFalse())
+			Expect(config.RootCAs).To(Equal(natsTLS.RootCAs))
+		})
+	})
+})
@@ -0,0 +1,286 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+
+	boshagent "bosh/agent"
+	boshtask "bosh/agent/task"
+	boshblob "bosh/blobstore"
+	boshjobsuper "bosh/jobsupervisor"
+	boshlog "bosh/logger"
+	boshntp "bosh/platform/ntp"
+	boshvitals "bosh/platform/vitals"
+)
+
+const agentCollectorLogTag = "agentCollector"
+
+type BlobstoreTransferStatsProvider interface {
+	GetTransferStats() boshblob.TransferStats
+}
+
+// agentCollector gathers metrics from agent services on every scrape
+type agentCollector struct {
+	vitalsService  boshvitals.Service
+	jobSupervisor  boshjobsuper.JobSupervisor
+	taskService    boshtask.Service
+	blobstoreStats BlobstoreTransferStatsProvider
+	alertSender    boshagent.AlertSender
+	ntpService     boshntp.Service
+	logger         boshlog.Logger
+}
+
+func NewAgentCollector(
+	vitalsService boshvitals.Service,
+	jobSupervisor boshjobsuper.JobSupervisor,
+	taskService boshtask.Service,
+	blobstoreStats BlobstoreTransferStatsProvider,
+	alertSender boshagent.AlertSender,
+	ntpService boshntp.Service,
+	logger boshlog.Logger,
+) agentCollector {
+	return agentCollector{
+		vitalsService:  vitalsService,
+		jobSupervisor:  jobSupervisor,
+		taskService:    taskService,
+		blobstoreStats: blobstoreStats,
+		alertSender:    alertSender,
+		ntpService:     ntpService,
+		logger:         logger,
+	}
+}
+
+func (c agentCollector) Collect() []Family {
+	families := []Family{}
+
+	vitals, err := c.vitalsService.GetExtended("metrics")
+	if err != nil {
+		c.logger.Error(agentCollectorLogTag, "Failed getting vitals: %s", err.Error())
+	} else {
+		families = append(families, vitalsFamilies(vitals)...)
+	}
+
+	families = append(families, c.jobFamilies()...)
+	families = append(families, c.taskFamilies()...)
+	families = append(families, c.blobstoreFamilies()...)
+	families = append(families, c.alertFamilies()...)
+	families = append(families, c.ntpFamilies()...)
+
+	return families
+}
+
+func (c agentCollector) jobFamilies() []Family {
+	jobState := NewGauge("bosh_agent_job_state", "Aggregated state of all job processes (1 for current state)").
+		With(1, "state", c.jobSupervisor.Status())
+
+	processes, err := c.jobSupervisor.Processes()
+	if err != nil {
+		c.logger.Error(agentCollectorLogTag, "Failed getting job processes: %s", err.Error())
+		return []Family{jobState}
+	}
+
+	processStatus := NewGauge("bosh_agent_job_process_status", "Status of a job process (1 for current status)")
+	processMonitored := NewGauge("bosh_agent_job_process_monitored", "Whether job process is monitored")
+
+	for _, process := range processes {
+		processStatus = processStatus.With(1, "process", process.Name, "status", process.Status)
+		processMonitored = processMonitored.With(boolValue(process.Monitored), "process", process.Name)
+	}
+
+	return []Family{jobState, processStatus, processMonitored}
+}
+
+func (c agentCollector) taskFamilies() []Family {
+	counts := c.taskService.GetCounts()
+
+	return []Family{
+		NewGauge("bosh_agent_tasks", "Number of queued and running tasks").
+			With(float64(counts.Queued), "state", "queued").
+			With(float64(counts.Running), "state", "running"),
+
+		NewCounter("bosh_agent_tasks_finished_total", "Number of finished tasks").
+			With(float64(counts.Done), "result", "done").
+			With(float64(counts.Failed), "result", "failed"),
+	}
+}
+
+func (c agentCollector) blobstoreFamilies() []Family {
+	stats := c.blobstoreStats.GetTransferStats()
+
+	return []Family{
+		NewCounter("bosh_agent_blobstore_downloads_total", "Number of blob downloads").
+			With(float64(stats.Downloads), "result", "success").
+			With(float64(stats.DownloadFailures), "result", "failure"),
+
+		NewCounter("bosh_agent_blobstore_downloaded_bytes_total", "Number of downloaded bytes").
+			With(float64(stats.DownloadedBytes)),
+
+		NewCounter("bosh_agent_blobstore_uploads_total", "Number of blob uploads").
+			With(float64(stats.Uploads), "result", "success").
+			With(float64(stats.UploadFailures), "result", "failure"),
+
+		NewCounter("bosh_agent_blobstore_uploaded_bytes_total", "Number of uploaded bytes").
+			With(float64(stats.UploadedBytes)),
+	}
+}
+
+func (c agentCollector) alertFamilies() []Family {
+	counts := c.alertSender.GetCounts()
+
+	return []Family{
+		NewCounter("bosh_agent_alerts_sent_total", "Number of alerts sent to health manager").
+			With(float64(counts.MonitSent), "type", "monit").
+			With(float64(counts.SSHSent), "type", "ssh").
+			With(float64(counts.NTPSent), "type", "ntp"),
+
+		NewCounter("bosh_agent_alerts_failed_total", "Number of alerts that failed to be sent to health manager").
+			With(float64(counts.MonitFailed), "type", "monit").
+			With(float64(counts.SSHFailed), "type", "ssh").
+			With(float64(counts.NTPFailed), "type", "ntp"),
+	}
+}
+
+func (c agentCollector) ntpFamilies() []Family {
+	offset := NewGauge("bosh_agent_ntp_offset_seconds", "Clock offset reported by last NTP synchronization")
+	offset = withParsedValue(offset, c.ntpService.GetInfo().Offset)
+	return []Family{offset}
+}
+
+func vitalsFamilies(vitals boshvitals.Vitals) []Family {
+	load := NewGauge("bosh_agent_load_average", "System load average")
+	for i, period := range []string{"1m", "5m", "15m"} {
+		if i < len(vitals.Load) {
+			load = withParsedValue(load, vitals.Load[i], "period", period)
+		}
+	}
+
+	cpu := NewGauge("bosh_agent_cpu_percent", "CPU usage percentage by mode")
+	cpu = withParsedValue(cpu, vitals.CPU.User, "mode", "user")
+	cpu = withParsedValue(cpu, vitals.CPU.Sys, "mode", "sys")
+	cpu = withParsedValue(cpu, vitals.CPU.Wait, "mode", "wait")
+
+	families := []Family{
+		load,
+		cpu,
+		withParsedValue(NewGauge("bosh_agent_memory_used_percent", "Memory usage percentage"), vitals.Mem.Percent),
+		withParsedValue(NewGauge("bosh_agent_memory_used_kilobytes", "Memory usage in kilobytes"), vitals.Mem.Kb),
+		withParsedValue(NewGauge("bosh_agent_swap_used_percent", "Swap usage percentage"), vitals.Swap.Percent),
+		withParsedValue(NewGauge("bosh_agent_swap_used_kilobytes", "Swap usage in kilobytes"), vitals.Swap.Kb),
+	}
+
+	diskPercent := NewGauge("bosh_agent_disk_used_percent", "Disk usage percentage")
+	diskInodePercent := NewGauge("bosh_agent_disk_inode_used_percent", "Disk inode usage percentage")
+
+	diskNames := make([]string, 0, len(vitals.Disk))
+	for name := range vitals.Disk {
+		diskNames = append(diskNames, name)
+	}
+	sort.Strings(diskNames)
+
+	for _, name := range diskNames {
+		diskPercent = withParsedValue(diskPercent, vitals.Disk[name].Percent, "disk", name)
+		diskInodePercent = withParsedValue(diskInodePercent, vitals.Disk[name].InodePercent, "disk", name)
+	}
+
+	families = append(families, diskPercent, diskInodePercent)
+	families = append(families, networkFamilies(vitals.Network)...)
+	families = append(families, diskIOFamilies(vitals.DiskIO)...)
+
+	if vitals.Processes != nil {
+		processes := NewGauge("bosh_agent_processes", "Number of processes by state")
+		processes = withParsedValue(processes, vitals.Processes.Total, "state", "all")
+		processes = withParsedValue(processes, vitals.Processes.Running, "state", "running")
+		processes = withParsedValue(processes, vitals.Processes.Zombies, "state", "zombie")
+		families = append(families, processes)
+	}
+
+	if vitals.FileDescriptors != nil {
+		families = append(families,
+			withParsedValue(NewGauge("bosh_agent_file_descriptors_open", "Number of allocated file descriptors"), vitals.FileDescriptors.Open),
+			withParsedValue(NewGauge("bosh_agent_file_descriptors_max", "Maximum number of file descriptors"), vitals.FileDescriptors.Max),
+		)
+	}
+
+	if vitals.Uptime != nil {
+		families = append(families,
+			withParsedValue(NewGauge("bosh_agent_uptime_seconds", "System uptime in seconds"), vitals.Uptime.Secs),
+		)
+	}
+
+	return families
+}
+
+func networkFamilies(network boshvitals.NetworkVitals) []Family {
+	type networkRate struct {
+		family Family
+		value  func(boshvitals.SpecificNetworkVitals) string
+	}
+
+	rates := []networkRate{
+		{NewGauge("bosh_agent_network_receive_bytes_per_second", "Received bytes per second"), func(v boshvitals.SpecificNetworkVitals) string { return v.RxBytes }},
+		{NewGauge("bosh_agent_network_receive_packets_per_second", "Received packets per second"), func(v boshvitals.SpecificNetworkVitals) string { return v.RxPackets }},
+		{NewGauge("bosh_agent_network_receive_errors_per_second", "Receive errors per second"), func(v boshvitals.SpecificNetworkVitals) string { return v.RxErrors }},
+		{NewGauge("bosh_agent_network_receive_dropped_per_second", "Dropped received packets per second"), func(v boshvitals.SpecificNetworkVitals) string { return v.RxDropped }},
+		{NewGauge("bosh_agent_network_transmit_bytes_per_second", "Transmitted bytes per second"), func(v boshvitals.SpecificNetworkVitals) string { return v.TxBytes }},
+		{NewGauge("bosh_agent_network_transmit_packets_per_second", "Transmitted packets per second"), func(v boshvitals.SpecificNetworkVitals) string { return v.TxPackets }},
+		{NewGauge("bosh_agent_network_transmit_errors_per_second", "Transmit errors per second"), func(v boshvitals.SpecificNetworkVitals) string { return v.TxErrors }},
+		{NewGauge("bosh_agent_network_transmit_dropped_per_second", "Dropped transmitted packets per second"), func(v boshvitals.SpecificNetworkVitals) string { return v.TxDropped }},
+	}
+
+	names := make([]string, 0, len(network))
+	for name := range network {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	families := []Family{}
+
+	for _, rate := range rates {
+		family := rate.family
+		for _, name := range names {
+			family = withParsedValue(family, rate.value(network[name]), "interface", name)
+		}
+		families = append(families, family)
+	}
+
+	return families
+}
+
+func diskIOFamilies(diskIO boshvitals.DiskIOVitals) []Family {
+	readIOPS := NewGauge("bosh_agent_disk_io_read_iops", "Completed reads per second")
+	writeIOPS := NewGauge("bosh_agent_disk_io_write_iops", "Completed writes per second")
+	readLatency := NewGauge("bosh_agent_disk_io_read_latency_milliseconds", "Average time spent per read")
+	writeLatency := NewGauge("bosh_agent_disk_io_write_latency_milliseconds", "Average time spent per write")
+
+	names := make([]string, 0, len(diskIO))
+	for name := range diskIO {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		device := diskIO[name]
+		readIOPS = withParsedValue(readIOPS, device.ReadIOPS, "device", name)
+		writeIOPS = withParsedValue(writeIOPS, device.WriteIOPS, "device", name)
+		readLatency = withParsedValue(readLatency, device.ReadLatencyMs, "device", name)
+		writeLatency = withParsedValue(writeLatency, device.WriteLatencyMs, "device", name)
+	}
+
+	return []Family{readIOPS, writeIOPS, readLatency, writeLatency}
+}
+
+// withParsedValue adds a sample only if vitals value is a valid number
+func withParsedValue(family Family, value string, labelPairs ...string) Family {
+	parsedValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return family
+	}
+	return family.With(parsedValue, labelPairs...)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,202 @@
+package metrics_test
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshagent "bosh/agent"
+	fakeagent "bosh/agent/fakes"
+	boshtask "bosh/agent/task"
+	faketask "bosh/agent/task/fakes"
+	boshblob "bosh/blobstore"
+	boshjobsuper "bosh/jobsupervisor"
+	fakejobsuper "bosh/jobsupervisor/fakes"
+	boshlog "bosh/logger"
+	. "bosh/metrics"
+	fakemetrics "bosh/metrics/fakes"
+	boshntp "bosh/platform/ntp"
+	fakentp "bosh/platform/ntp/fakes"
+	boshvitals "bosh/platform/vitals"
+	fakevitals "bosh/platform/vitals/fakes"
+)
+
+var _ = Describe("agentCollector", func() {
+	var (
+		vitalsService  *fakevitals.FakeService
+		jobSupervisor  *fakejobsuper.FakeJobSupervisor
+		taskService    *faketask.FakeService
+		blobstoreStats *fakemetrics.FakeBlobstoreTransferStatsProvider
+		alertSender    *fakeagent.FakeAlertSender
+		ntpService     *fakentp.FakeService
+		collector      Collector
+	)
+
+	BeforeEach(func() {
+		vitalsService = fakevitals.NewFakeService()
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		taskService = faketask.NewFakeService()
+		blobstoreStats = &fakemetrics.FakeBlobstoreTransferStatsProvider{}
+		alertSender = &fakeagent.FakeAlertSender{}
+		ntpService = &fakentp.FakeService{}
+
+		collector = NewAgentCollector(
+			vitalsService,
+			jobSupervisor,
+			taskService,
+			blobstoreStats,
+			alertSender,
+			ntpService,
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+	})
+
+	collectText := func() string {
+		buf := bytes.NewBuffer([]byte{})
+		err := WriteText(buf, collector.Collect())
+		Expect(err).ToNot(HaveOccurred())
+		return buf.String()
+	}
+
+	It("includes extended vitals", func() {
+		vitalsService.GetExtendedVitals = boshvitals.Vitals{
+			Load: []string{"0.20", "4.55", "1.12"},
+			CPU:  boshvitals.CPUVitals{User: "56.0", Sys: "10.0", Wait: "1.0"},
+			Mem:  boshvitals.MemoryVitals{Kb: "700", Percent: "70"},
+			Swap: boshvitals.MemoryVitals{Kb: "600", Percent: "60"},
+			Disk: boshvitals.DiskVitals{
+				"system": boshvitals.SpecificDiskVitals{Percent: "50", InodePercent: "10"},
+			},
+			Network: boshvitals.NetworkVitals{
+				"eth0": boshvitals.SpecificNetworkVitals{RxBytes: "100.00", TxBytes: "200.00"},
+			},
+			DiskIO: boshvitals.DiskIOVitals{
+				"sda": boshvitals.SpecificDiskIOVitals{ReadIOPS: "5.00", ReadLatencyMs: "2.00"},
+			},
+			Processes:       &boshvitals.ProcessVitals{Total: "120", Running: "3", Zombies: "2"},
+			FileDescriptors: &boshvitals.FileDescriptorVitals{Open: "512", Max: "2048"},
+			Uptime:          &boshvitals.UptimeVitals{Secs: "10"},
+		}
+
+		text := collectText()
+		Expect(text).To(ContainSubstring(`bosh_agent_load_average{period="1m"} 0.2` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_load_average{period="15m"} 1.12` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_cpu_percent{mode="user"} 56` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_memory_used_percent 70` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_swap_used_kilobytes 600` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_disk_used_percent{disk="system"} 50` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_disk_inode_used_percent{disk="system"} 10` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_network_receive_bytes_per_second{interface="eth0"} 100` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_network_transmit_bytes_per_second{interface="eth0"} 200` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_disk_io_read_iops{device="sda"} 5` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_disk_io_read_latency_milliseconds{device="sda"} 2` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_processes{state="zombie"} 2` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_file_descriptors_open 512` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_file_descriptors_max 2048` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_uptime_seconds 10` + "\n"))
+
+		// Scrapes must not reset rates reported in heartbeats
+		Expect(vitalsService.GetExtendedConsumer).To(Equal("metrics"))
+	})
+
+	It("skips vitals that are not set", func() {
+		text := collectText()
+		Expect(text).ToNot(ContainSubstring("bosh_agent_load_average"))
+		Expect(text).ToNot(ContainSubstring("bosh_agent_uptime_seconds"))
+	})
+
+	It("skips vitals but includes other metrics when vitals cannot be retrieved", func() {
+		vitalsService.GetExtendedVitals = boshvitals.Vitals{Load: []string{"1", "2", "3"}}
+		vitalsService.GetExtendedErr = errors.New("fake-vitals-err")
+
+		text := collectText()
+		Expect(text).ToNot(ContainSubstring("bosh_agent_load_average"))
+		Expect(text).To(ContainSubstring("bosh_agent_tasks"))
+	})
+
+	It("includes job state and status of each process", func() {
+		jobSupervisor.StatusStatus = "failing"
+		jobSupervisor.ProcessesProcesses = []boshjobsuper.Process{
+			boshjobsuper.Process{Name: "fake-process-1", Monitored: true, Status: "running"},
+			boshjobsuper.Process{Name: "fake-process-2", Monitored: false, Status: "unknown"},
+		}
+
+		text := collectText()
+		Expect(text).To(ContainSubstring(`bosh_agent_job_state{state="failing"} 1` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_job_process_status{process="fake-process-1",status="running"} 1` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_job_process_status{process="fake-process-2",status="unknown"} 1` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_job_process_monitored{process="fake-process-1"} 1` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_job_process_monitored{process="fake-process-2"} 0` + "\n"))
+	})
+
+	It("includes job state when processes cannot be retrieved", func() {
+		jobSupervisor.StatusStatus = "unknown"
+		jobSupervisor.ProcessesErr = errors.New("fake-processes-err")
+
+		text := collectText()
+		Expect(text).To(ContainSubstring(`bosh_agent_job_state{state="unknown"} 1` + "\n"))
+		Expect(text).ToNot(ContainSubstring("bosh_agent_job_process_status"))
+	})
+
+	It("includes task counts", func() {
+		taskService.Counts = boshtask.Counts{Queued: 1, Running: 2, Done: 3, Failed: 4}
+
+		text := collectText()
+		Expect(text).To(ContainSubstring(`bosh_agent_tasks{state="queued"} 1` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_tasks{state="running"} 2` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_tasks_finished_total{result="done"} 3` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_tasks_finished_total{result="failed"} 4` + "\n"))
+	})
+
+	It("includes blobstore transfer stats", func() {
+		blobstoreStats.TransferStats = boshblob.TransferStats{
+			Downloads:        1,
+			DownloadFailures: 2,
+			DownloadedBytes:  3,
+			Uploads:          4,
+			UploadFailures:   5,
+			UploadedBytes:    6,
+		}
+
+		text := collectText()
+		Expect(text).To(ContainSubstring(`bosh_agent_blobstore_downloads_total{result="success"} 1` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_blobstore_downloads_total{result="failure"} 2` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_blobstore_downloaded_bytes_total 3` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_blobstore_uploads_total{result="success"} 4` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_blobstore_uploads_total{result="failure"} 5` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_blobstore_uploaded_bytes_total 6` + "\n"))
+	})
+
+	It("includes alert counts", func() {
+		alertSender.Counts = boshagent.AlertCounts{
+			MonitSent:   1,
+			MonitFailed: 2,
+			SSHSent:     3,
+			SSHFailed:   4,
+			NTPSent:     5,
+			NTPFailed:   6,
+		}
+
+		text := collectText()
+		Expect(text).To(ContainSubstring(`bosh_agent_alerts_sent_total{type="monit"} 1` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_alerts_failed_total{type="monit"} 2` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_alerts_sent_total{type="ssh"} 3` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_alerts_failed_total{type="ssh"} 4` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_alerts_sent_total{type="ntp"} 5` + "\n"))
+		Expect(text).To(ContainSubstring(`bosh_agent_alerts_failed_total{type="ntp"} 6` + "\n"))
+	})
+
+	It("includes ntp offset when available", func() {
+		ntpService.GetOffsetNTPOffset = boshntp.NTPInfo{Offset: "-0.06423"}
+
+		Expect(collectText()).To(ContainSubstring("bosh_agent_ntp_offset_seconds -0.06423\n"))
+	})
+
+	It("skips ntp offset when not available", func() {
+		ntpService.GetOffsetNTPOffset = boshntp.NTPInfo{Message: "file missing"}
+
+		Expect(collectText()).ToNot(ContainSubstring("bosh_agent_ntp_offset_seconds"))
+	})
+})
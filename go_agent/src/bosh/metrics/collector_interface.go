@@ -0,0 +1,5 @@
+package metrics
+
+type Collector interface {
+	Collect() []Family
+}
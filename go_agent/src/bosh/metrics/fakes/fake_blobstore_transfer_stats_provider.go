@@ -0,0 +1,13 @@
+package fakes
+
+import (
+	boshblob "bosh/blobstore"
+)
+
+type FakeBlobstoreTransferStatsProvider struct {
+	TransferStats boshblob.TransferStats
+}
+
+func (p *FakeBlobstoreTransferStatsProvider) GetTransferStats() boshblob.TransferStats {
+	return p.TransferStats
+}
@@ -0,0 +1,13 @@
+package fakes
+
+import (
+	boshmetrics "bosh/metrics"
+)
+
+type FakeCollector struct {
+	CollectFamilies []boshmetrics.Family
+}
+
+func (c *FakeCollector) Collect() []boshmetrics.Family {
+	return c.CollectFamilies
+}
@@ -0,0 +1,45 @@
+package metrics
+
+const (
+	TypeGauge   = "gauge"
+	TypeCounter = "counter"
+)
+
+// Family is a group of samples sharing name, help and type
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+func NewGauge(name, help string) Family {
+	return Family{Name: name, Help: help, Type: TypeGauge}
+}
+
+func NewCounter(name, help string) Family {
+	return Family{Name: name, Help: help, Type: TypeCounter}
+}
+
+// With returns a copy of the family with an additional sample.
+// Labels are given as name/value pairs, e.g. With(1, "state", "running").
+func (f Family) With(value float64, labelPairs ...string) Family {
+	sample := Sample{Value: value}
+
+	for i := 0; i+1 < len(labelPairs); i += 2 {
+		sample.Labels = append(sample.Labels, Label{Name: labelPairs[i], Value: labelPairs[i+1]})
+	}
+
+	f.Samples = append(f.Samples, sample)
+	return f
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"sync"
+
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+)
+
+const concreteServerLogTag = "metricsServer"
+
+type concreteServer struct {
+	options   Options
+	collector Collector
+	logger    boshlog.Logger
+
+	l  net.Listener
+	ll sync.Mutex
+}
+
+func NewServer(options Options, collector Collector, logger boshlog.Logger) *concreteServer {
+	return &concreteServer{
+		options:   options,
+		collector: collector,
+		logger:    logger,
+	}
+}
+
+func (s *concreteServer) Start() error {
+	var err error
+
+	s.ll.Lock()
+
+	s.l, err = net.Listen("tcp", s.options.ListenAddress)
+	if err != nil {
+		s.ll.Unlock()
+		return bosherr.WrapError(err, "Listening on %s", s.options.ListenAddress)
+	}
+
+	// Should not defer unlock since Serve is a long-running loop
+	s.ll.Unlock()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.metricsHandler)
+
+	return http.Serve(s.l, mux)
+}
+
+func (s *concreteServer) Stop() error {
+	s.ll.Lock()
+	defer s.ll.Unlock()
+
+	if s.l != nil {
+		return s.l.Close()
+	}
+
+	return nil
+}
+
+func (s *concreteServer) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.requestNotAuthorized(r) {
+		w.Header().Add("WWW-Authenticate", `Basic realm="bosh-agent metrics"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", TextContentType)
+
+	err := WriteText(w, s.collector.Collect())
+	if err != nil {
+		s.logger.Error(concreteServerLogTag, "Failed writing metrics: %s", err.Error())
+	}
+}
+
+func (s *concreteServer) requestNotAuthorized(r *http.Request) bool {
+	if s.options.Username == "" {
+		return false
+	}
+
+	auth := s.options.Username + ":" + s.options.Password
+	expectedAuthorizationHeader := "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+
+	actualAuthorizationHeader := r.Header.Get("Authorization")
+
+	return subtle.ConstantTimeCompare([]byte(expectedAuthorizationHeader), []byte(actualAuthorizationHeader)) != 1
+}
@@ -0,0 +1,16 @@
+package metrics
+
+type Options struct {
+	// Address (e.g. ":9100") metrics are served on;
+	// when empty metrics endpoint is not started
+	ListenAddress string
+
+	// When set, scrapes must provide matching basic auth credentials
+	Username string
+	Password string
+}
+
+type Server interface {
+	Start() error
+	Stop() error
+}
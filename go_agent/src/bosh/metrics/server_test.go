@@ -0,0 +1,138 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "bosh/logger"
+	. "bosh/metrics"
+	fakemetrics "bosh/metrics/fakes"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		listenAddress string
+		collector     *fakemetrics.FakeCollector
+		server        Server
+	)
+
+	grabEphemeralAddress := func() string {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		defer l.Close()
+
+		return l.Addr().String()
+	}
+
+	startServer := func(options Options) {
+		server = NewServer(options, collector, boshlog.NewLogger(boshlog.LevelNone))
+
+		go server.Start()
+
+		Eventually(func() error {
+			conn, err := net.Dial("tcp", listenAddress)
+			if err == nil {
+				conn.Close()
+			}
+			return err
+		}, 2*time.Second).ShouldNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		listenAddress = grabEphemeralAddress()
+		collector = &fakemetrics.FakeCollector{
+			CollectFamilies: []Family{
+				NewGauge("fake_gauge", "Fake gauge help").With(1),
+			},
+		}
+	})
+
+	AfterEach(func() {
+		server.Stop()
+	})
+
+	Context("when basic auth is not configured", func() {
+		BeforeEach(func() {
+			startServer(Options{ListenAddress: listenAddress})
+		})
+
+		It("serves metrics in text format", func() {
+			resp, err := http.Get("http://" + listenAddress + "/metrics")
+			Expect(err).ToNot(HaveOccurred())
+
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(resp.StatusCode).To(Equal(200))
+			Expect(resp.Header.Get("Content-Type")).To(Equal(TextContentType))
+			Expect(string(body)).To(ContainSubstring("fake_gauge 1\n"))
+		})
+
+		It("returns 404 for other paths", func() {
+			resp, err := http.Get("http://" + listenAddress + "/other")
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(404))
+		})
+
+		It("returns 405 for non GET requests", func() {
+			resp, err := http.Post("http://"+listenAddress+"/metrics", "text/plain", nil)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(405))
+		})
+	})
+
+	Context("when basic auth is configured", func() {
+		BeforeEach(func() {
+			startServer(Options{
+				ListenAddress: listenAddress,
+				Username:      "fake-user",
+				Password:      "fake-pass",
+			})
+		})
+
+		It("serves metrics when credentials match", func() {
+			req, err := http.NewRequest("GET", "http://"+listenAddress+"/metrics", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth("fake-user", "fake-pass")
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(200))
+		})
+
+		It("returns 401 when credentials do not match", func() {
+			req, err := http.NewRequest("GET", "http://"+listenAddress+"/metrics", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.SetBasicAuth("fake-user", "wrong-pass")
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(401))
+			Expect(resp.Header.Get("WWW-Authenticate")).To(ContainSubstring("Basic"))
+		})
+
+		It("returns 401 when credentials are missing", func() {
+			resp, err := http.Get("http://" + listenAddress + "/metrics")
+			Expect(err).ToNot(HaveOccurred())
+			resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(401))
+		})
+	})
+})
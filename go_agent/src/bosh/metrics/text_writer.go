@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	bosherr "bosh/errors"
+)
+
+const TextContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// WriteText writes families in Prometheus text exposition format.
+// Families without samples are skipped.
+func WriteText(w io.Writer, families []Family) error {
+	buf := bufio.NewWriter(w)
+
+	for _, family := range families {
+		if len(family.Samples) == 0 {
+			continue
+		}
+
+		buf.WriteString("# HELP " + family.Name + " " + helpEscaper.Replace(family.Help) + "\n")
+		buf.WriteString("# TYPE " + family.Name + " " + family.Type + "\n")
+
+		for _, sample := range family.Samples {
+			buf.WriteString(family.Name)
+
+			if len(sample.Labels) > 0 {
+				buf.WriteString("{")
+				for i, label := range sample.Labels {
+					if i > 0 {
+						buf.WriteString(",")
+					}
+					buf.WriteString(label.Name + `="` + labelValueEscaper.Replace(label.Value) + `"`)
+				}
+				buf.WriteString("}")
+			}
+
+			buf.WriteString(" " + formatValue(sample.Value) + "\n")
+		}
+	}
+
+	err := buf.Flush()
+	if err != nil {
+		return bosherr.WrapError(err, "Writing metrics")
+	}
+
+	return nil
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case value == math.Trunc(value) && math.Abs(value) < 1e15:
+		// Avoid exponent notation for counters
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
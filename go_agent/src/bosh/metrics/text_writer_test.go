@@ -0,0 +1,75 @@
+package metrics_test
+
+import (
+	"bytes"
+	"math"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/metrics"
+)
+
+var _ = Describe("WriteText", func() {
+	It("writes families with help, type and samples", func() {
+		families := []Family{
+			NewGauge("fake_gauge", "Fake gauge help").
+				With(1.5, "label1", "value1", "label2", "value2").
+				With(2),
+			NewCounter("fake_counter_total", "Fake counter help").
+				With(123456789),
+		}
+
+		buf := bytes.NewBuffer([]byte{})
+
+		err := WriteText(buf, families)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf.String()).To(Equal(`# HELP fake_gauge Fake gauge help
+# TYPE fake_gauge gauge
+fake_gauge{label1="value1",label2="value2"} 1.5
+fake_gauge 2
+# HELP fake_counter_total Fake counter help
+# TYPE fake_counter_total counter
+fake_counter_total 123456789
+`))
+	})
+
+	It("skips families without samples", func() {
+		buf := bytes.NewBuffer([]byte{})
+
+		err := WriteText(buf, []Family{NewGauge("fake_gauge", "Fake gauge help")})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf.String()).To(Equal(""))
+	})
+
+	It("escapes help text and label values", func() {
+		families := []Family{
+			NewGauge("fake_gauge", "Fake \\ help\nnext line").
+				With(1, "label", "fake \"quoted\" \\ value\n"),
+		}
+
+		buf := bytes.NewBuffer([]byte{})
+
+		err := WriteText(buf, families)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf.String()).To(Equal(`# HELP fake_gauge Fake \\ help\nnext line
+# TYPE fake_gauge gauge
+fake_gauge{label="fake \"quoted\" \\ value\n"} 1
+`))
+	})
+
+	It("writes special float values", func() {
+		families := []Family{
+			NewGauge("fake_gauge", "Fake gauge help").
+				With(math.NaN()).
+				With(math.Inf(1)).
+				With(math.Inf(-1)),
+		}
+
+		buf := bytes.NewBuffer([]byte{})
+
+		err := WriteText(buf, families)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf.String()).To(ContainSubstring("fake_gauge NaN\nfake_gauge +Inf\nfake_gauge -Inf\n"))
+	})
+})
@@ -0,0 +1,37 @@
+package micro
+
+import (
+	"sync"
+)
+
+// blobUploads tracks blobs being uploaded so that concurrent
+// uploads of the same blob do not write into the same partial file
+type blobUploads struct {
+	blobIDs map[string]struct{}
+	lock    sync.Mutex
+}
+
+func newBlobUploads() *blobUploads {
+	return &blobUploads{blobIDs: map[string]struct{}{}}
+}
+
+// Start returns false when upload of the blob is already in progress
+func (u *blobUploads) Start(blobID string) bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if _, found := u.blobIDs[blobID]; found {
+		return false
+	}
+
+	u.blobIDs[blobID] = struct{}{}
+
+	return true
+}
+
+func (u *blobUploads) Finish(blobID string) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	delete(u.blobIDs, blobID)
+}
@@ -0,0 +1,91 @@
+package micro
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+)
+
+const (
+	healthPusherLogTag = "HealthPusher"
+	healthPushBatch    = 100
+	healthPushWait     = 30 * time.Second
+	healthPushTimeout  = 10 * time.Second
+)
+
+// healthPusher delivers queued health messages to health manager
+type healthPusher struct {
+	queue      *healthQueue
+	url        string
+	retryDelay time.Duration
+	client     *http.Client
+	logger     boshlog.Logger
+}
+
+func newHealthPusher(queue *healthQueue, url string, retryDelay time.Duration, logger boshlog.Logger) healthPusher {
+	return healthPusher{
+		queue:      queue,
+		url:        url,
+		retryDelay: retryDelay,
+		client:     &http.Client{Timeout: healthPushTimeout},
+		logger:     logger,
+	}
+}
+
+// Run pushes messages until queue is closed
+func (p healthPusher) Run() {
+	defer p.logger.HandlePanic("Health Pusher")
+
+	for {
+		msgs := p.queue.Poll(healthPushBatch, healthPushWait)
+
+		if p.queue.IsClosed() {
+			return
+		}
+
+		if len(msgs) == 0 {
+			continue
+		}
+
+		err := p.push(msgs)
+		if err == nil {
+			continue
+		}
+
+		p.logger.Error(healthPusherLogTag, "Pushing %d health message(s): %s", len(msgs), err.Error())
+		p.queue.Requeue(msgs)
+
+		time.Sleep(p.retryDelay)
+	}
+}
+
+func (p healthPusher) push(msgs []HealthMessage) error {
+	msgsJSON, err := json.Marshal(msgs)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling health messages")
+	}
+
+	request, err := http.NewRequest("POST", p.url, bytes.NewReader(msgsJSON))
+	if err != nil {
+		return bosherr.WrapError(err, "Building request")
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return bosherr.WrapError(err, "Performing request")
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return bosherr.New("Health manager responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package micro
+
+import (
+	"sync"
+	"time"
+)
+
+type HealthMessage struct {
+	Topic   string      `json:"topic"`
+	Payload interface{} `json:"payload"`
+}
+
+// healthQueue keeps most recent health messages until they are delivered
+type healthQueue struct {
+	size     int
+	messages []HealthMessage
+	closed   bool
+	lock     sync.Mutex
+
+	// Receives a value when messages become available or queue is closed
+	notifyCh chan struct{}
+}
+
+func newHealthQueue(size int) *healthQueue {
+	return &healthQueue{
+		size:     size,
+		notifyCh: make(chan struct{}, 1),
+	}
+}
+
+// Push returns number of oldest messages dropped to stay within size
+func (q *healthQueue) Push(msg HealthMessage) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.messages = append(q.messages, msg)
+
+	dropped := q.trim()
+
+	q.notify()
+
+	return dropped
+}
+
+// Requeue puts undelivered messages back in front of the queue
+func (q *healthQueue) Requeue(msgs []HealthMessage) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.messages = append(append([]HealthMessage{}, msgs...), q.messages...)
+	q.trim()
+
+	q.notify()
+}
+
+// Poll waits up to timeout for messages and removes at most max of them.
+// Empty list is returned on timeout or when queue is closed.
+func (q *healthQueue) Poll(max int, timeout time.Duration) []HealthMessage {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		msgs, closed := q.take(max)
+		if len(msgs) > 0 || closed {
+			return msgs
+		}
+
+		select {
+		case <-q.notifyCh:
+		case <-timer.C:
+			return []HealthMessage{}
+		}
+	}
+}
+
+// Close wakes up all pollers; it can be called more than once
+func (q *healthQueue) Close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	q.closed = true
+	close(q.notifyCh)
+}
+
+func (q *healthQueue) IsClosed() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.closed
+}
+
+func (q *healthQueue) take(max int) ([]HealthMessage, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.closed {
+		return []HealthMessage{}, true
+	}
+
+	count := len(q.messages)
+	if count > max {
+		count = max
+	}
+
+	msgs := append([]HealthMessage{}, q.messages[:count]...)
+	q.messages = q.messages[count:]
+
+	// Let other pollers pick up remaining messages
+	if len(q.messages) > 0 {
+		q.notify()
+	}
+
+	return msgs, false
+}
+
+// trim must be called with lock held
+func (q *healthQueue) trim() int {
+	dropped := len(q.messages) - q.size
+	if dropped <= 0 {
+		return 0
+	}
+
+	q.messages = q.messages[dropped:]
+
+	return dropped
+}
+
+// notify must be called with lock held
+func (q *healthQueue) notify() {
+	if q.closed {
+		return
+	}
+
+	select {
+	case q.notifyCh <- struct{}{}:
+	default:
+	}
+}
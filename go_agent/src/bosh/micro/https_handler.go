@@ -1,11 +1,19 @@
 package micro
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"bosh/blobstore"
 	bosherr "bosh/errors"
@@ -16,25 +24,67 @@ import (
 	boshsys "bosh/system"
 )
 
+const (
+	httpsHandlerLogTag = "HTTPS Handler"
+
+	defaultHealthQueueSize = 1000
+	healthPushRetryDelay   = 5 * time.Second
+
+	defaultHealthPollTimeout = 30 * time.Second
+	maxHealthPollTimeout     = 60 * time.Second
+	healthPollBatch          = 100
+
+	blobSHA1Header = "X-Checksum-Sha1"
+)
+
+type Options struct {
+	// Number of undelivered health messages kept; defaults to 1000
+	HealthQueueSize int
+
+	// When set health messages are POSTed to this URL;
+	// otherwise they are served via GET /health
+	HealthManagerURL string
+
+	// Paths to PEM files used unless certificates are given in settings
+	CertificatePath string
+	PrivateKeyPath  string
+	ClientCAPath    string
+}
+
 type HTTPSHandler struct {
 	parsedURL   *url.URL
 	logger      boshlog.Logger
 	dispatcher  boshdispatcher.HTTPSDispatcher
 	fs          boshsys.FileSystem
 	dirProvider boshdir.DirectoriesProvider
+	options     Options
+	healthQueue *healthQueue
+	verifier    boshhandler.RequestVerifier
+	uploads     *blobUploads
 }
 
 func NewHTTPSHandler(
 	parsedURL *url.URL,
+	tlsOptions boshdispatcher.TLSOptions,
+	verifier boshhandler.RequestVerifier,
 	logger boshlog.Logger,
 	fs boshsys.FileSystem,
 	dirProvider boshdir.DirectoriesProvider,
+	options Options,
 ) (handler HTTPSHandler) {
+	if options.HealthQueueSize <= 0 {
+		options.HealthQueueSize = defaultHealthQueueSize
+	}
+
 	handler.parsedURL = parsedURL
 	handler.logger = logger
 	handler.fs = fs
 	handler.dirProvider = dirProvider
-	handler.dispatcher = boshdispatcher.NewHTTPSDispatcher(parsedURL, logger)
+	handler.options = options
+	handler.verifier = verifier
+	handler.healthQueue = newHealthQueue(options.HealthQueueSize)
+	handler.uploads = newBlobUploads()
+	handler.dispatcher = boshdispatcher.NewHTTPSDispatcher(parsedURL, tlsOptions, logger)
 	return
 }
 
@@ -47,13 +97,27 @@ func (h HTTPSHandler) Run(handlerFunc boshhandler.HandlerFunc) error {
 }
 
 func (h HTTPSHandler) Start(handlerFunc boshhandler.HandlerFunc) error {
+	// Health messages are not pushed when endpoint cannot be served
+	err := h.dispatcher.Validate()
+	if err != nil {
+		return err
+	}
+
 	h.dispatcher.AddRoute("/agent", h.agentHandler(handlerFunc))
 	h.dispatcher.AddRoute("/blobs/", h.blobsHandler())
-	h.dispatcher.Start()
-	return nil
+
+	if h.options.HealthManagerURL != "" {
+		pusher := newHealthPusher(h.healthQueue, h.options.HealthManagerURL, healthPushRetryDelay, h.logger)
+		go pusher.Run()
+	} else {
+		h.dispatcher.AddRoute("/health", h.healthHandler())
+	}
+
+	return h.dispatcher.Start()
 }
 
 func (h HTTPSHandler) Stop() {
+	h.healthQueue.Close()
 	h.dispatcher.Stop()
 }
 
@@ -61,7 +125,16 @@ func (h HTTPSHandler) RegisterAdditionalHandlerFunc(handlerFunc boshhandler.Hand
 	panic("HTTPSHandler does not support registering additional handler funcs")
 }
 
+// SendToHealthManager queues message until health manager picks it up;
+// oldest messages are dropped when queue is full
 func (h HTTPSHandler) SendToHealthManager(topic string, payload interface{}) error {
+	h.logger.Info(httpsHandlerLogTag, "Queueing HM message '%s'", topic)
+
+	dropped := h.healthQueue.Push(HealthMessage{Topic: topic, Payload: payload})
+	if dropped > 0 {
+		h.logger.Error(httpsHandlerLogTag, "Dropped %d undelivered HM message(s)", dropped)
+	}
+
 	return nil
 }
 
@@ -71,7 +144,11 @@ func (h HTTPSHandler) requestNotAuthorized(request *http.Request) bool {
 	auth := username + ":" + password
 	expectedAuthorizationHeader := "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
 
-	return expectedAuthorizationHeader != request.Header.Get("Authorization")
+	// Digests are compared so that neither contents nor length leak through timing
+	expectedDigest := sha256.Sum256([]byte(expectedAuthorizationHeader))
+	actualDigest := sha256.Sum256([]byte(request.Header.Get("Authorization")))
+
+	return subtle.ConstantTimeCompare(expectedDigest[:], actualDigest[:]) != 1
 }
 
 func (h HTTPSHandler) agentHandler(handlerFunc boshhandler.HandlerFunc) (agentHandler func(http.ResponseWriter, *http.Request)) {
@@ -96,6 +173,7 @@ func (h HTTPSHandler) agentHandler(handlerFunc boshhandler.HandlerFunc) (agentHa
 		respBytes, _, err := boshhandler.PerformHandlerWithJSON(
 			rawJSONPayload,
 			handlerFunc,
+			h.verifier,
 			boshhandler.UnlimitedResponseLength,
 			h.logger,
 		)
@@ -109,10 +187,77 @@ func (h HTTPSHandler) agentHandler(handlerFunc boshhandler.HandlerFunc) (agentHa
 	return
 }
 
+// healthHandler long-polls for queued health messages;
+// optional timeout query parameter is in seconds
+func (h HTTPSHandler) healthHandler() (healthHandler func(http.ResponseWriter, *http.Request)) {
+	healthHandler = func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			w.WriteHeader(404)
+			return
+		}
+
+		if h.requestNotAuthorized(r) {
+			w.Header().Add("WWW-Authenticate", `Basic realm=""`)
+			w.WriteHeader(401)
+			return
+		}
+
+		timeout := defaultHealthPollTimeout
+
+		timeoutParam := r.URL.Query().Get("timeout")
+		if timeoutParam != "" {
+			seconds, err := strconv.Atoi(timeoutParam)
+			if err != nil || seconds < 0 {
+				w.WriteHeader(400)
+				w.Write([]byte("Invalid timeout"))
+				return
+			}
+
+			timeout = time.Duration(seconds) * time.Second
+			if timeout > maxHealthPollTimeout {
+				timeout = maxHealthPollTimeout
+			}
+		}
+
+		var closedCh <-chan bool
+
+		if closeNotifier, ok := w.(http.CloseNotifier); ok {
+			closedCh = closeNotifier.CloseNotify()
+		}
+
+		msgs := h.healthQueue.Poll(healthPollBatch, timeout)
+
+		// Client might have given up waiting by the time messages arrived
+		select {
+		case <-closedCh:
+			h.healthQueue.Requeue(msgs)
+			return
+		default:
+		}
+
+		msgsJSON, err := json.Marshal(msgs)
+		if err != nil {
+			h.healthQueue.Requeue(msgs)
+			w.WriteHeader(500)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		_, err = w.Write(msgsJSON)
+		if err != nil {
+			h.logger.Error(httpsHandlerLogTag, "Writing health messages: %s", err.Error())
+			h.healthQueue.Requeue(msgs)
+		}
+	}
+	return
+}
+
 func (h HTTPSHandler) blobsHandler() (blobsHandler func(http.ResponseWriter, *http.Request)) {
 	blobsHandler = func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
-		case "GET":
+		case "GET", "HEAD":
 			h.getBlob(w, r)
 		case "PUT":
 			h.putBlob(w, r)
@@ -124,18 +269,99 @@ func (h HTTPSHandler) blobsHandler() (blobsHandler func(http.ResponseWriter, *ht
 	return
 }
 
+// putBlob streams request body to disk. Uploads can be resumed by sending
+// remaining bytes with Content-Range header; 202 with Range header describing
+// received bytes is returned until all bytes are received. Optional SHA1 header
+// is verified against the whole blob. Only one upload of a blob can be
+// in progress at a time; others get 409.
 func (h HTTPSHandler) putBlob(w http.ResponseWriter, r *http.Request) {
 	_, blobID := path.Split(r.URL.Path)
+	if !blobstore.IsValidBlobID(blobID) {
+		w.WriteHeader(400)
+		w.Write([]byte("Invalid blob ID"))
+		return
+	}
+
+	if !h.uploads.Start(blobID) {
+		w.WriteHeader(409)
+		w.Write([]byte("Upload of blob is already in progress"))
+		return
+	}
+
+	defer h.uploads.Finish(blobID)
+
 	blobManager := blobstore.NewBlobManager(h.fs, h.dirProvider)
 
-	payload, err := ioutil.ReadAll(r.Body)
+	var start, end, total int64
+
+	body := io.Reader(r.Body)
+
+	contentRange := r.Header.Get("Content-Range")
+	if contentRange != "" {
+		var err error
+
+		start, end, total, err = parseContentRange(contentRange)
+		if err != nil {
+			w.WriteHeader(400)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		size, err := blobManager.PartialSize(blobID)
+		if err != nil {
+			w.WriteHeader(500)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if start != 0 && start != size {
+			writeReceivedRange(w, size)
+			w.WriteHeader(409)
+			return
+		}
+
+		// Bytes past the end of range are never written
+		body = io.LimitReader(r.Body, end-start+1)
+	}
+
+	size, err := blobManager.WritePartial(blobID, start, body)
 	if err != nil {
 		w.WriteHeader(500)
 		w.Write([]byte(err.Error()))
 		return
 	}
 
-	err = blobManager.Write(blobID, payload)
+	if contentRange != "" && (size != end+1 || hasMoreBytes(r.Body)) {
+		blobManager.DiscardPartial(blobID)
+		w.WriteHeader(400)
+		w.Write([]byte(fmt.Sprintf("Request body does not match Content-Range '%s'", contentRange)))
+		return
+	}
+
+	if contentRange != "" && size < total {
+		writeReceivedRange(w, size)
+		w.WriteHeader(202)
+		return
+	}
+
+	expectedSHA1 := r.Header.Get(blobSHA1Header)
+	if expectedSHA1 != "" {
+		actualSHA1, err := blobManager.PartialSHA1(blobID)
+		if err != nil {
+			w.WriteHeader(500)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if !strings.EqualFold(actualSHA1, expectedSHA1) {
+			blobManager.DiscardPartial(blobID)
+			w.WriteHeader(400)
+			w.Write([]byte(fmt.Sprintf("SHA1 mismatch: expected %s, got %s", expectedSHA1, actualSHA1)))
+			return
+		}
+	}
+
+	err = blobManager.CompletePartial(blobID)
 	if err != nil {
 		w.WriteHeader(500)
 		w.Write([]byte(err.Error()))
@@ -145,16 +371,56 @@ func (h HTTPSHandler) putBlob(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(201)
 }
 
+// getBlob streams blob from disk and supports Range requests
 func (h HTTPSHandler) getBlob(w http.ResponseWriter, r *http.Request) {
 	_, blobID := path.Split(r.URL.Path)
+	if !blobstore.IsValidBlobID(blobID) {
+		w.WriteHeader(400)
+		w.Write([]byte("Invalid blob ID"))
+		return
+	}
+
 	blobManager := blobstore.NewBlobManager(h.fs, h.dirProvider)
 
-	blobBytes, err := blobManager.Fetch(blobID)
+	if !blobManager.Exists(blobID) {
+		w.WriteHeader(404)
+		return
+	}
 
+	file, err := blobManager.Open(blobID)
 	if err != nil {
-		w.WriteHeader(404)
-	} else {
-		w.Write(blobBytes)
+		w.WriteHeader(500)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+
+	http.ServeContent(w, r, blobID, time.Time{}, file)
+}
+
+// parseContentRange parses 'bytes <start>-<end>/<total>'
+func parseContentRange(contentRange string) (int64, int64, int64, error) {
+	var start, end, total int64
+
+	_, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &total)
+	if err != nil || start < 0 || end < start || total <= end {
+		return 0, 0, 0, bosherr.New("Invalid Content-Range '%s'", contentRange)
+	}
+
+	return start, end, total, nil
+}
+
+func hasMoreBytes(reader io.Reader) bool {
+	_, err := io.ReadFull(reader, make([]byte, 1))
+	return err == nil
+}
+
+func writeReceivedRange(w http.ResponseWriter, size int64) {
+	if size > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", size-1))
 	}
 }
 
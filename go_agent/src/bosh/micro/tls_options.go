@@ -0,0 +1,57 @@
+package micro
+
+import (
+	bosherr "bosh/errors"
+	boshdispatcher "bosh/httpsdispatcher"
+	boshsettings "bosh/settings"
+	boshsys "bosh/system"
+)
+
+// NewTLSOptions prefers certificates given in settings
+// over files configured in options
+func NewTLSOptions(
+	fs boshsys.FileSystem,
+	mbusTLS boshsettings.MbusTLS,
+	options Options,
+) (boshdispatcher.TLSOptions, error) {
+	tlsOptions := boshdispatcher.TLSOptions{
+		Certificate: mbusTLS.Certificate,
+		PrivateKey:  mbusTLS.PrivateKey,
+		ClientCA:    mbusTLS.ClientCA,
+	}
+
+	var err error
+
+	if tlsOptions.Certificate == "" && tlsOptions.PrivateKey == "" {
+		tlsOptions.Certificate, err = readOptionalFile(fs, options.CertificatePath)
+		if err != nil {
+			return tlsOptions, bosherr.WrapError(err, "Reading certificate")
+		}
+
+		tlsOptions.PrivateKey, err = readOptionalFile(fs, options.PrivateKeyPath)
+		if err != nil {
+			return tlsOptions, bosherr.WrapError(err, "Reading private key")
+		}
+	}
+
+	if (tlsOptions.Certificate == "") != (tlsOptions.PrivateKey == "") {
+		return tlsOptions, bosherr.New("Both certificate and private key must be provided")
+	}
+
+	if tlsOptions.ClientCA == "" {
+		tlsOptions.ClientCA, err = readOptionalFile(fs, options.ClientCAPath)
+		if err != nil {
+			return tlsOptions, bosherr.WrapError(err, "Reading client CA")
+		}
+	}
+
+	return tlsOptions, nil
+}
+
+func readOptionalFile(fs boshsys.FileSystem, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	return fs.ReadFileString(path)
+}
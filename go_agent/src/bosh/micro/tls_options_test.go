@@ -0,0 +1,90 @@
+package micro_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshdispatcher "bosh/httpsdispatcher"
+	. "bosh/micro"
+	boshsettings "bosh/settings"
+	fakesys "bosh/system/fakes"
+)
+
+var _ = Describe("NewTLSOptions", func() {
+	var (
+		fs *fakesys.FakeFileSystem
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		fs.WriteFileString("/fake-cert.pem", "fake-file-cert")
+		fs.WriteFileString("/fake-key.pem", "fake-file-key")
+		fs.WriteFileString("/fake-ca.pem", "fake-file-ca")
+	})
+
+	options := Options{
+		CertificatePath: "/fake-cert.pem",
+		PrivateKeyPath:  "/fake-key.pem",
+		ClientCAPath:    "/fake-ca.pem",
+	}
+
+	It("uses certificates from settings", func() {
+		mbusTLS := boshsettings.MbusTLS{
+			Certificate: "fake-settings-cert",
+			PrivateKey:  "fake-settings-key",
+			ClientCA:    "fake-settings-ca",
+		}
+
+		tlsOptions, err := NewTLSOptions(fs, mbusTLS, options)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tlsOptions).To(Equal(boshdispatcher.TLSOptions{
+			Certificate: "fake-settings-cert",
+			PrivateKey:  "fake-settings-key",
+			ClientCA:    "fake-settings-ca",
+		}))
+	})
+
+	It("does not use CA of NATS server to verify client certificates", func() {
+		mbusTLS := boshsettings.MbusTLS{
+			Certificate: "fake-settings-cert",
+			PrivateKey:  "fake-settings-key",
+			CA:          "fake-settings-server-ca",
+		}
+
+		tlsOptions, err := NewTLSOptions(fs, mbusTLS, Options{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tlsOptions.ClientCA).To(BeEmpty())
+	})
+
+	It("reads certificates from configured files when settings do not include them", func() {
+		tlsOptions, err := NewTLSOptions(fs, boshsettings.MbusTLS{}, options)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tlsOptions).To(Equal(boshdispatcher.TLSOptions{
+			Certificate: "fake-file-cert",
+			PrivateKey:  "fake-file-key",
+			ClientCA:    "fake-file-ca",
+		}))
+	})
+
+	It("returns empty options when nothing is configured so that default certificate is used", func() {
+		tlsOptions, err := NewTLSOptions(fs, boshsettings.MbusTLS{}, Options{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tlsOptions).To(Equal(boshdispatcher.TLSOptions{}))
+	})
+
+	It("returns error when certificate is given without private key", func() {
+		_, err := NewTLSOptions(fs, boshsettings.MbusTLS{Certificate: "fake-cert"}, Options{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Both certificate and private key must be provided"))
+	})
+
+	It("returns error when configured file cannot be read", func() {
+		fs.ReadFileError = errors.New("fake-read-error")
+
+		_, err := NewTLSOptions(fs, boshsettings.MbusTLS{}, options)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-read-error"))
+	})
+})
@@ -14,6 +14,7 @@ import (
 	boshdir "bosh/settings/directories"
 	boshdirs "bosh/settings/directories"
 	boshsys "bosh/system"
+	boshtime "bosh/time"
 )
 
 type dummyPlatform struct {
@@ -42,7 +43,7 @@ func NewDummyPlatform(
 		compressor:    boshcmd.NewTarballCompressor(cmdRunner, fs),
 		copier:        boshcmd.NewCpCopier(cmdRunner, fs, logger),
 		dirProvider:   dirProvider,
-		vitalsService: boshvitals.NewService(collector, dirProvider),
+		vitalsService: boshvitals.NewService(collector, dirProvider, boshtime.NewConcreteService()),
 	}
 }
 
@@ -22,6 +22,7 @@ import (
 	boshsettings "bosh/settings"
 	boshdirs "bosh/settings/directories"
 	fakesys "bosh/system/fakes"
+	faketime "bosh/time/fakes"
 )
 
 var _ = Describe("LinuxPlatform", func() {
@@ -52,7 +53,7 @@ var _ = Describe("LinuxPlatform", func() {
 		cdutil = fakecd.NewFakeCdUtil()
 		compressor = boshcmd.NewTarballCompressor(cmdRunner, fs)
 		copier = boshcmd.NewCpCopier(cmdRunner, fs, logger)
-		vitalsService = boshvitals.NewService(collector, dirProvider)
+		vitalsService = boshvitals.NewService(collector, dirProvider, &faketime.FakeService{})
 		netManager = &fakenet.FakeNetManager{}
 		devicePathResolver = fakedpresolv.NewFakeDevicePathResolver()
 		options = LinuxOptions{}
@@ -0,0 +1,33 @@
+package fakes
+
+import (
+	"errors"
+	"sync"
+
+	boshntp "bosh/platform/ntp"
+)
+
+type FakeSNTPClient struct {
+	Measurements map[string]boshntp.Measurement
+	Errs         map[string]error
+
+	QueriedServers []string
+	queriedLock    sync.Mutex
+}
+
+func (c *FakeSNTPClient) Query(server string) (boshntp.Measurement, error) {
+	c.queriedLock.Lock()
+	c.QueriedServers = append(c.QueriedServers, server)
+	c.queriedLock.Unlock()
+
+	if err, found := c.Errs[server]; found {
+		return boshntp.Measurement{Server: server}, err
+	}
+
+	measurement, found := c.Measurements[server]
+	if !found {
+		return boshntp.Measurement{Server: server}, errors.New("fake-unknown-server")
+	}
+
+	return measurement, nil
+}
@@ -18,6 +18,19 @@ type NTPInfo struct {
 	Offset    string `json:"offset,omitempty"`
 	Timestamp string `json:"timestamp,omitempty"`
 	Message   string `json:"message,omitempty"`
+
+	// Only reported by SNTP service
+	Server  string          `json:"server,omitempty"`
+	Delay   string          `json:"delay,omitempty"`
+	Servers []NTPServerInfo `json:"servers,omitempty"`
+}
+
+type NTPServerInfo struct {
+	Server  string `json:"server"`
+	Stratum int    `json:"stratum,omitempty"`
+	Offset  string `json:"offset,omitempty"`
+	Delay   string `json:"delay,omitempty"`
+	Error   string `json:"error,omitempty"`
 }
 
 type Service interface {
@@ -0,0 +1,135 @@
+package ntp
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	bosherr "bosh/errors"
+)
+
+const (
+	sntpDefaultPort    = "123"
+	sntpDefaultTimeout = 5 * time.Second
+	sntpPacketSize     = 48
+
+	// Seconds between NTP era 0 (1900) and unix epoch
+	ntpEpochOffset = 2208988800
+)
+
+type Measurement struct {
+	Server  string
+	Stratum int
+
+	// Offset of the local clock relative to server;
+	// positive when local clock is behind
+	Offset time.Duration
+
+	// Round trip delay excluding server processing time
+	Delay time.Duration
+}
+
+type SNTPClient interface {
+	Query(server string) (Measurement, error)
+}
+
+type udpSNTPClient struct {
+	timeout time.Duration
+}
+
+func NewUDPSNTPClient(timeout time.Duration) udpSNTPClient {
+	if timeout <= 0 {
+		timeout = sntpDefaultTimeout
+	}
+
+	return udpSNTPClient{timeout: timeout}
+}
+
+func (c udpSNTPClient) Query(server string) (Measurement, error) {
+	measurement := Measurement{Server: server}
+
+	address := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		address = net.JoinHostPort(server, sntpDefaultPort)
+	}
+
+	conn, err := net.DialTimeout("udp", address, c.timeout)
+	if err != nil {
+		return measurement, bosherr.WrapError(err, "Connecting to %s", address)
+	}
+
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(c.timeout))
+	if err != nil {
+		return measurement, bosherr.WrapError(err, "Setting deadline")
+	}
+
+	request := make([]byte, sntpPacketSize)
+
+	// Leap indicator 0, version 4, mode 3 (client)
+	request[0] = 0x23
+
+	originTime := time.Now()
+	origin := toNTPTimestamp(originTime)
+	binary.BigEndian.PutUint64(request[40:], origin)
+
+	_, err = conn.Write(request)
+	if err != nil {
+		return measurement, bosherr.WrapError(err, "Sending request")
+	}
+
+	response := make([]byte, sntpPacketSize)
+
+	n, err := conn.Read(response)
+	if err != nil {
+		return measurement, bosherr.WrapError(err, "Reading response")
+	}
+
+	destinationTime := time.Now()
+
+	if n < sntpPacketSize {
+		return measurement, bosherr.New("Response too short: %d bytes", n)
+	}
+
+	leapIndicator := response[0] >> 6
+	mode := response[0] & 0x07
+	stratum := int(response[1])
+
+	if mode != 4 {
+		return measurement, bosherr.New("Unexpected response mode %d", mode)
+	}
+
+	if leapIndicator == 3 {
+		return measurement, bosherr.New("Server clock is not synchronized")
+	}
+
+	if stratum == 0 || stratum > 15 {
+		return measurement, bosherr.New("Invalid stratum %d", stratum)
+	}
+
+	if binary.BigEndian.Uint64(response[24:]) != origin {
+		return measurement, bosherr.New("Response does not match request")
+	}
+
+	receiveTime := fromNTPTimestamp(binary.BigEndian.Uint64(response[32:]))
+	transmitTime := fromNTPTimestamp(binary.BigEndian.Uint64(response[40:]))
+
+	measurement.Stratum = stratum
+	measurement.Offset = (receiveTime.Sub(originTime) + transmitTime.Sub(destinationTime)) / 2
+	measurement.Delay = destinationTime.Sub(originTime) - transmitTime.Sub(receiveTime)
+
+	return measurement, nil
+}
+
+func toNTPTimestamp(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := (uint64(t.Nanosecond()) << 32) / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+func fromNTPTimestamp(timestamp uint64) time.Time {
+	secs := int64(timestamp>>32) - ntpEpochOffset
+	nanos := ((timestamp & 0xffffffff) * uint64(time.Second)) >> 32
+	return time.Unix(secs, int64(nanos))
+}
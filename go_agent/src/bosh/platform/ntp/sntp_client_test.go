@@ -0,0 +1,150 @@
+package ntp_test
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/platform/ntp"
+)
+
+type standInServerResponse struct {
+	mode          byte
+	stratum       byte
+	offset        time.Duration
+	mismatchOrig  bool
+	doNotRespond  bool
+	processingFor time.Duration
+}
+
+// startStandInServer responds to SNTP requests as if its clock
+// was ahead of local clock by configured offset
+func startStandInServer(response standInServerResponse) (string, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	toNTP := func(t time.Time) uint64 {
+		secs := uint64(t.Unix() + 2208988800)
+		frac := (uint64(t.Nanosecond()) << 32) / uint64(time.Second)
+		return secs<<32 | frac
+	}
+
+	go func() {
+		request := make([]byte, 48)
+
+		for {
+			_, addr, err := conn.ReadFrom(request)
+			if err != nil {
+				return
+			}
+
+			receiveTime := time.Now().Add(response.offset)
+
+			if response.doNotRespond {
+				continue
+			}
+
+			time.Sleep(response.processingFor)
+
+			reply := make([]byte, 48)
+			reply[0] = 0x20 | response.mode
+			reply[1] = response.stratum
+
+			origin := binary.BigEndian.Uint64(request[40:])
+			if response.mismatchOrig {
+				origin++
+			}
+
+			binary.BigEndian.PutUint64(reply[24:], origin)
+			binary.BigEndian.PutUint64(reply[32:], toNTP(receiveTime))
+			binary.BigEndian.PutUint64(reply[40:], toNTP(time.Now().Add(response.offset)))
+
+			conn.WriteTo(reply, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { conn.Close() }
+}
+
+var _ = Describe("udpSNTPClient", func() {
+	var (
+		client SNTPClient
+	)
+
+	BeforeEach(func() {
+		client = NewUDPSNTPClient(500 * time.Millisecond)
+	})
+
+	It("returns offset and delay reported by server", func() {
+		address, stop := startStandInServer(standInServerResponse{
+			mode:          4,
+			stratum:       2,
+			offset:        3 * time.Second,
+			processingFor: 50 * time.Millisecond,
+		})
+		defer stop()
+
+		measurement, err := client.Query(address)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(measurement.Server).To(Equal(address))
+		Expect(measurement.Stratum).To(Equal(2))
+		Expect(measurement.Offset).To(BeNumerically("~", 3*time.Second, 20*time.Millisecond))
+
+		// Server processing time is excluded from delay
+		Expect(measurement.Delay).To(BeNumerically(">=", 0))
+		Expect(measurement.Delay).To(BeNumerically("<", 40*time.Millisecond))
+	})
+
+	It("returns negative offset when local clock is ahead", func() {
+		address, stop := startStandInServer(standInServerResponse{
+			mode:    4,
+			stratum: 1,
+			offset:  -2 * time.Second,
+		})
+		defer stop()
+
+		measurement, err := client.Query(address)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(measurement.Offset).To(BeNumerically("~", -2*time.Second, 20*time.Millisecond))
+	})
+
+	It("returns error when server responds with invalid stratum", func() {
+		address, stop := startStandInServer(standInServerResponse{mode: 4, stratum: 0})
+		defer stop()
+
+		_, err := client.Query(address)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Invalid stratum 0"))
+	})
+
+	It("returns error when server responds with unexpected mode", func() {
+		address, stop := startStandInServer(standInServerResponse{mode: 3, stratum: 2})
+		defer stop()
+
+		_, err := client.Query(address)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unexpected response mode 3"))
+	})
+
+	It("returns error when response does not match request", func() {
+		address, stop := startStandInServer(standInServerResponse{mode: 4, stratum: 2, mismatchOrig: true})
+		defer stop()
+
+		_, err := client.Query(address)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Response does not match request"))
+	})
+
+	It("returns error when server does not respond in time", func() {
+		address, stop := startStandInServer(standInServerResponse{doNotRespond: true})
+		defer stop()
+
+		_, err := client.Query(address)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Reading response"))
+	})
+})
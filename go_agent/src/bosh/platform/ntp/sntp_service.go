@@ -0,0 +1,193 @@
+package ntp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	boshlog "bosh/logger"
+	boshsettings "bosh/settings"
+	boshtime "bosh/time"
+)
+
+const (
+	sntpServiceLogTag              = "sntpService"
+	defaultDriftThresholdMillisecs = 1000
+	ntpTimestampFormat             = "02 Jan 15:04:05"
+)
+
+type SNTPOptions struct {
+	// How often NTP servers are queried; when zero
+	// offset is read from ntpdate output instead
+	IntervalSeconds int
+
+	// Timeout for a single query; defaults to 5
+	TimeoutSeconds int
+
+	// Offset above which clock drift is reported; defaults to 1000
+	DriftThresholdMilliseconds int
+}
+
+type SNTPService interface {
+	Service
+
+	// Start queries NTP servers periodically until Stop is called
+	Start(DriftHandler)
+	Stop()
+}
+
+// DriftHandler is called once each time
+// clock offset starts exceeding drift threshold
+type DriftHandler func(NTPInfo)
+
+type sntpService struct {
+	client          SNTPClient
+	settingsService boshsettings.Service
+	timeService     boshtime.Service
+	options         SNTPOptions
+	logger          boshlog.Logger
+
+	info     NTPInfo
+	drifting bool
+	infoLock sync.Mutex
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func NewSNTPService(
+	client SNTPClient,
+	settingsService boshsettings.Service,
+	timeService boshtime.Service,
+	options SNTPOptions,
+	logger boshlog.Logger,
+) *sntpService {
+	if options.DriftThresholdMilliseconds <= 0 {
+		options.DriftThresholdMilliseconds = defaultDriftThresholdMillisecs
+	}
+
+	return &sntpService{
+		client:          client,
+		settingsService: settingsService,
+		timeService:     timeService,
+		options:         options,
+		logger:          logger,
+		info:            NTPInfo{Message: "not yet queried"},
+		stopCh:          make(chan struct{}),
+	}
+}
+
+func (s *sntpService) GetInfo() NTPInfo {
+	s.infoLock.Lock()
+	defer s.infoLock.Unlock()
+
+	return s.info
+}
+
+func (s *sntpService) Start(driftHandler DriftHandler) {
+	if s.options.IntervalSeconds <= 0 {
+		return
+	}
+
+	s.update(driftHandler)
+
+	ticker := time.NewTicker(time.Duration(s.options.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.update(driftHandler)
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *sntpService) Stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+}
+
+func (s *sntpService) update(driftHandler DriftHandler) {
+	info, selected := s.query()
+
+	s.infoLock.Lock()
+
+	s.info = info
+
+	drifting := false
+	if selected != nil {
+		drifting = s.exceedsThreshold(selected.Offset)
+	}
+
+	startedDrifting := drifting && !s.drifting
+	s.drifting = drifting
+
+	s.infoLock.Unlock()
+
+	if startedDrifting {
+		s.logger.Error(sntpServiceLogTag, "Clock offset %s from %s exceeds threshold", info.Offset, info.Server)
+		driftHandler(info)
+	}
+}
+
+func (s *sntpService) query() (NTPInfo, *Measurement) {
+	info := NTPInfo{
+		Timestamp: s.timeService.Now().Format(ntpTimestampFormat),
+		Servers:   []NTPServerInfo{},
+	}
+
+	servers := s.settingsService.GetSettings().Ntp
+	if len(servers) == 0 {
+		info.Message = "no ntp servers"
+		return info, nil
+	}
+
+	var selected *Measurement
+
+	for _, server := range servers {
+		measurement, err := s.client.Query(server)
+		if err != nil {
+			s.logger.Debug(sntpServiceLogTag, "Querying %s failed: %s", server, err.Error())
+			info.Servers = append(info.Servers, NTPServerInfo{Server: server, Error: err.Error()})
+			continue
+		}
+
+		info.Servers = append(info.Servers, NTPServerInfo{
+			Server:  server,
+			Stratum: measurement.Stratum,
+			Offset:  formatSeconds(measurement.Offset),
+			Delay:   formatSeconds(measurement.Delay),
+		})
+
+		// Prefer server with the lowest round trip delay
+		// since its offset has the smallest error bound
+		if selected == nil || measurement.Delay < selected.Delay {
+			m := measurement
+			selected = &m
+		}
+	}
+
+	if selected == nil {
+		info.Message = "bad ntp server"
+		return info, nil
+	}
+
+	info.Server = selected.Server
+	info.Offset = formatSeconds(selected.Offset)
+	info.Delay = formatSeconds(selected.Delay)
+
+	return info, selected
+}
+
+func (s *sntpService) exceedsThreshold(offset time.Duration) bool {
+	if offset < 0 {
+		offset = -offset
+	}
+
+	return offset > time.Duration(s.options.DriftThresholdMilliseconds)*time.Millisecond
+}
+
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.6f", d.Seconds())
+}
@@ -0,0 +1,162 @@
+package ntp_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "bosh/logger"
+	. "bosh/platform/ntp"
+	fakentp "bosh/platform/ntp/fakes"
+	fakesettings "bosh/settings/fakes"
+	faketime "bosh/time/fakes"
+)
+
+var _ = Describe("sntpService", func() {
+	var (
+		client          *fakentp.FakeSNTPClient
+		settingsService *fakesettings.FakeSettingsService
+		timeService     *faketime.FakeService
+		options         SNTPOptions
+		driftedInfos    chan NTPInfo
+		service         SNTPService
+	)
+
+	BeforeEach(func() {
+		client = &fakentp.FakeSNTPClient{
+			Measurements: map[string]Measurement{},
+			Errs:         map[string]error{},
+		}
+		settingsService = &fakesettings.FakeSettingsService{}
+		timeService = &faketime.FakeService{NowTime: time.Date(2014, time.October, 12, 17, 37, 58, 0, time.UTC)}
+		options = SNTPOptions{IntervalSeconds: 3600}
+		driftedInfos = make(chan NTPInfo, 10)
+	})
+
+	JustBeforeEach(func() {
+		service = NewSNTPService(client, settingsService, timeService, options, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	startService := func() {
+		go service.Start(func(info NTPInfo) { driftedInfos <- info })
+
+		Eventually(func() string { return service.GetInfo().Timestamp }).ShouldNot(BeEmpty())
+	}
+
+	AfterEach(func() {
+		service.Stop()
+	})
+
+	It("reports not yet queried before first query", func() {
+		Expect(service.GetInfo()).To(Equal(NTPInfo{Message: "not yet queried"}))
+	})
+
+	It("selects server with the lowest delay and reports all servers", func() {
+		settingsService.Settings.Ntp = []string{"fake-server-1", "fake-server-2", "fake-server-3"}
+		client.Measurements["fake-server-1"] = Measurement{
+			Server:  "fake-server-1",
+			Stratum: 2,
+			Offset:  -81236 * time.Microsecond,
+			Delay:   42910 * time.Microsecond,
+		}
+		client.Measurements["fake-server-2"] = Measurement{
+			Server:  "fake-server-2",
+			Stratum: 3,
+			Offset:  -80000 * time.Microsecond,
+			Delay:   10000 * time.Microsecond,
+		}
+		client.Errs["fake-server-3"] = errors.New("fake-query-err")
+
+		startService()
+
+		Expect(service.GetInfo()).To(Equal(NTPInfo{
+			Timestamp: "12 Oct 17:37:58",
+			Server:    "fake-server-2",
+			Offset:    "-0.080000",
+			Delay:     "0.010000",
+			Servers: []NTPServerInfo{
+				NTPServerInfo{Server: "fake-server-1", Stratum: 2, Offset: "-0.081236", Delay: "0.042910"},
+				NTPServerInfo{Server: "fake-server-2", Stratum: 3, Offset: "-0.080000", Delay: "0.010000"},
+				NTPServerInfo{Server: "fake-server-3", Error: "fake-query-err"},
+			},
+		}))
+	})
+
+	It("reports bad ntp server when no server responds", func() {
+		settingsService.Settings.Ntp = []string{"fake-server"}
+		client.Errs["fake-server"] = errors.New("fake-query-err")
+
+		startService()
+
+		info := service.GetInfo()
+		Expect(info.Message).To(Equal("bad ntp server"))
+		Expect(info.Servers).To(Equal([]NTPServerInfo{
+			NTPServerInfo{Server: "fake-server", Error: "fake-query-err"},
+		}))
+	})
+
+	It("reports no ntp servers when none are configured", func() {
+		startService()
+
+		Expect(service.GetInfo().Message).To(Equal("no ntp servers"))
+	})
+
+	Context("when offset exceeds drift threshold", func() {
+		BeforeEach(func() {
+			options.DriftThresholdMilliseconds = 500
+			settingsService.Settings.Ntp = []string{"fake-server"}
+			client.Measurements["fake-server"] = Measurement{
+				Server:  "fake-server",
+				Stratum: 2,
+				Offset:  -501 * time.Millisecond,
+			}
+		})
+
+		It("calls drift handler with ntp info", func() {
+			startService()
+
+			var info NTPInfo
+			Eventually(driftedInfos).Should(Receive(&info))
+			Expect(info.Server).To(Equal("fake-server"))
+			Expect(info.Offset).To(Equal("-0.501000"))
+		})
+	})
+
+	Context("when offset is within drift threshold", func() {
+		BeforeEach(func() {
+			settingsService.Settings.Ntp = []string{"fake-server"}
+			client.Measurements["fake-server"] = Measurement{
+				Server:  "fake-server",
+				Stratum: 2,
+				Offset:  999 * time.Millisecond,
+			}
+		})
+
+		It("does not call drift handler", func() {
+			startService()
+
+			Consistently(driftedInfos).ShouldNot(Receive())
+		})
+	})
+
+	It("can be stopped more than once", func() {
+		startService()
+
+		service.Stop()
+		service.Stop()
+	})
+
+	Context("when interval is not set", func() {
+		BeforeEach(func() {
+			options.IntervalSeconds = 0
+		})
+
+		It("does not query servers", func() {
+			service.Start(func(NTPInfo) {})
+
+			Expect(client.QueriedServers).To(BeEmpty())
+		})
+	})
+})
@@ -15,6 +15,7 @@ import (
 	boshvitals "bosh/platform/vitals"
 	boshdirs "bosh/settings/directories"
 	boshsys "bosh/system"
+	boshtime "bosh/time"
 )
 
 type provider struct {
@@ -39,7 +40,7 @@ func NewProvider(logger boshlog.Logger, dirProvider boshdirs.DirectoriesProvider
 	copier := boshcmd.NewCpCopier(runner, fs, logger)
 
 	sigarCollector := boshstats.NewSigarStatsCollector()
-	vitalsService := boshvitals.NewService(sigarCollector, dirProvider)
+	vitalsService := boshvitals.NewService(sigarCollector, dirProvider, boshtime.NewConcreteService())
 
 	routesSearcher := boshnet.NewCmdRoutesSearcher(runner)
 	defaultNetworkResolver := boshnet.NewDefaultNetworkResolver(
@@ -31,3 +31,26 @@ func (p dummyStatsCollector) GetDiskStats(devicePath string) (stats DiskStats, e
 	stats.InodeUsage.Total = 1
 	return
 }
+
+func (p dummyStatsCollector) GetNetworkStats() (stats map[string]NetworkStats, err error) {
+	stats = map[string]NetworkStats{}
+	return
+}
+
+func (p dummyStatsCollector) GetDiskIOStats() (stats map[string]DiskIOStats, err error) {
+	stats = map[string]DiskIOStats{}
+	return
+}
+
+func (p dummyStatsCollector) GetProcessStats() (stats ProcessStats, err error) {
+	return
+}
+
+func (p dummyStatsCollector) GetFileDescriptorStats() (usage Usage, err error) {
+	usage.Total = 1
+	return
+}
+
+func (p dummyStatsCollector) GetUptimeStats() (stats UptimeStats, err error) {
+	return
+}
@@ -11,6 +11,14 @@ type FakeStatsCollector struct {
 	MemStats  boshstats.Usage
 	SwapStats boshstats.Usage
 	DiskStats map[string]boshstats.DiskStats
+
+	NetworkStats        map[string]boshstats.NetworkStats
+	NetworkStatsErr     error
+	DiskIOStats         map[string]boshstats.DiskIOStats
+	DiskIOStatsErr      error
+	ProcessStats        boshstats.ProcessStats
+	FileDescriptorStats boshstats.Usage
+	UptimeStats         boshstats.UptimeStats
 }
 
 func (c *FakeStatsCollector) GetCPULoad() (load boshstats.CPULoad, err error) {
@@ -40,3 +48,30 @@ func (c *FakeStatsCollector) GetDiskStats(devicePath string) (stats boshstats.Di
 	}
 	return
 }
+
+func (c *FakeStatsCollector) GetNetworkStats() (stats map[string]boshstats.NetworkStats, err error) {
+	stats = c.NetworkStats
+	err = c.NetworkStatsErr
+	return
+}
+
+func (c *FakeStatsCollector) GetDiskIOStats() (stats map[string]boshstats.DiskIOStats, err error) {
+	stats = c.DiskIOStats
+	err = c.DiskIOStatsErr
+	return
+}
+
+func (c *FakeStatsCollector) GetProcessStats() (stats boshstats.ProcessStats, err error) {
+	stats = c.ProcessStats
+	return
+}
+
+func (c *FakeStatsCollector) GetFileDescriptorStats() (usage boshstats.Usage, err error) {
+	usage = c.FileDescriptorStats
+	return
+}
+
+func (c *FakeStatsCollector) GetUptimeStats() (stats boshstats.UptimeStats, err error) {
+	stats = c.UptimeStats
+	return
+}
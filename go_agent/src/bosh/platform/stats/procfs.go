@@ -0,0 +1,134 @@
+package stats
+
+import (
+	"strconv"
+	"strings"
+
+	bosherr "bosh/errors"
+)
+
+const (
+	procNetDevPath    = "/proc/net/dev"
+	procDiskStatsPath = "/proc/diskstats"
+	procFileNrPath    = "/proc/sys/fs/file-nr"
+)
+
+// Virtual block devices that never represent real disk activity
+var ignoredDiskIODevicePrefixes = []string{"loop", "ram"}
+
+// parseProcNetDev parses /proc/net/dev where each interface line
+// has 8 receive counters followed by 8 transmit counters, e.g.
+// "eth0: 1640215 12027 0 0 0 0 0 0 974235 8251 0 0 0 0 0 0"
+func parseProcNetDev(content string) (map[string]NetworkStats, error) {
+	stats := map[string]NetworkStats{}
+
+	for _, line := range strings.Split(content, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		name := strings.TrimSpace(parts[0])
+
+		values, err := parseUints(strings.Fields(parts[1]))
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing counters for interface %s", name)
+		}
+
+		if len(values) < 16 {
+			return nil, bosherr.New("Expected 16 counters for interface %s, got %d", name, len(values))
+		}
+
+		stats[name] = NetworkStats{
+			RxBytes:   values[0],
+			RxPackets: values[1],
+			RxErrors:  values[2],
+			RxDropped: values[3],
+			TxBytes:   values[8],
+			TxPackets: values[9],
+			TxErrors:  values[10],
+			TxDropped: values[11],
+		}
+	}
+
+	return stats, nil
+}
+
+// parseProcDiskStats parses /proc/diskstats where each line has major, minor,
+// name, reads completed, reads merged, sectors read, ms reading, writes completed,
+// writes merged, sectors written, ms writing, etc.
+func parseProcDiskStats(content string) (map[string]DiskIOStats, error) {
+	stats := map[string]DiskIOStats{}
+
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) < 11 {
+			return nil, bosherr.New("Expected at least 11 fields in diskstats line '%s'", line)
+		}
+
+		name := fields[2]
+		if isIgnoredDiskIODevice(name) {
+			continue
+		}
+
+		values, err := parseUints(fields[3:11])
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing counters for device %s", name)
+		}
+
+		stats[name] = DiskIOStats{
+			ReadsCompleted:  values[0],
+			ReadTimeMs:      values[3],
+			WritesCompleted: values[4],
+			WriteTimeMs:     values[7],
+		}
+	}
+
+	return stats, nil
+}
+
+// parseProcFileNr parses /proc/sys/fs/file-nr which contains
+// number of allocated, free allocated and maximum file handles
+func parseProcFileNr(content string) (usage Usage, err error) {
+	values, err := parseUints(strings.Fields(content))
+	if err != nil {
+		return
+	}
+
+	if len(values) != 3 {
+		err = bosherr.New("Expected 3 values in file-nr, got %d", len(values))
+		return
+	}
+
+	usage.Used = values[0] - values[1]
+	usage.Total = values[2]
+
+	return
+}
+
+func isIgnoredDiskIODevice(name string) bool {
+	for _, prefix := range ignoredDiskIODevicePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseUints(fields []string) ([]uint64, error) {
+	values := make([]uint64, len(fields))
+
+	for i, field := range fields {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing '%s'", field)
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
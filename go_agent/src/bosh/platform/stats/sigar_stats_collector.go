@@ -1,6 +1,8 @@
 package stats
 
 import (
+	"io/ioutil"
+
 	sigar "github.com/cloudfoundry/gosigar"
 
 	bosherr "bosh/errors"
@@ -89,3 +91,90 @@ func (s sigarStatsCollector) GetDiskStats(mountedPath string) (stats DiskStats,
 
 	return
 }
+
+func (s sigarStatsCollector) GetNetworkStats() (stats map[string]NetworkStats, err error) {
+	content, err := ioutil.ReadFile(procNetDevPath)
+	if err != nil {
+		err = bosherr.WrapError(err, "Reading %s", procNetDevPath)
+		return
+	}
+
+	stats, err = parseProcNetDev(string(content))
+	if err != nil {
+		err = bosherr.WrapError(err, "Parsing %s", procNetDevPath)
+	}
+
+	return
+}
+
+func (s sigarStatsCollector) GetDiskIOStats() (stats map[string]DiskIOStats, err error) {
+	content, err := ioutil.ReadFile(procDiskStatsPath)
+	if err != nil {
+		err = bosherr.WrapError(err, "Reading %s", procDiskStatsPath)
+		return
+	}
+
+	stats, err = parseProcDiskStats(string(content))
+	if err != nil {
+		err = bosherr.WrapError(err, "Parsing %s", procDiskStatsPath)
+	}
+
+	return
+}
+
+func (s sigarStatsCollector) GetProcessStats() (stats ProcessStats, err error) {
+	procList := sigar.ProcList{}
+	err = procList.Get()
+	if err != nil {
+		err = bosherr.WrapError(err, "Getting Sigar Proc List")
+		return
+	}
+
+	for _, pid := range procList.List {
+		procState := sigar.ProcState{}
+
+		// Process might have exited since the list was taken
+		if procState.Get(pid) != nil {
+			continue
+		}
+
+		stats.Total++
+
+		switch procState.State {
+		case sigar.RunStateRun:
+			stats.Running++
+		case sigar.RunStateZombie:
+			stats.Zombies++
+		}
+	}
+
+	return
+}
+
+func (s sigarStatsCollector) GetFileDescriptorStats() (usage Usage, err error) {
+	content, err := ioutil.ReadFile(procFileNrPath)
+	if err != nil {
+		err = bosherr.WrapError(err, "Reading %s", procFileNrPath)
+		return
+	}
+
+	usage, err = parseProcFileNr(string(content))
+	if err != nil {
+		err = bosherr.WrapError(err, "Parsing %s", procFileNrPath)
+	}
+
+	return
+}
+
+func (s sigarStatsCollector) GetUptimeStats() (stats UptimeStats, err error) {
+	uptime := sigar.Uptime{}
+	err = uptime.Get()
+	if err != nil {
+		err = bosherr.WrapError(err, "Getting Sigar Uptime")
+		return
+	}
+
+	stats.Secs = uint64(uptime.Length)
+
+	return
+}
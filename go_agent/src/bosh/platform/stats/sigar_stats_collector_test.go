@@ -64,4 +64,49 @@ var _ = Describe("sigarStatsCollector", func() {
 			Expect(stats.InodeUsage.Used > 0).To(BeTrue())
 		})
 	})
+	Describe("GetNetworkStats", func() {
+		It("returns stats for loopback interface", func() {
+			stats, err := collector.GetNetworkStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(HaveKey("lo"))
+		})
+	})
+
+	Describe("GetDiskIOStats", func() {
+		It("returns disk io stats without loop devices", func() {
+			stats, err := collector.GetDiskIOStats()
+			Expect(err).ToNot(HaveOccurred())
+
+			for name := range stats {
+				Expect(name).ToNot(HavePrefix("loop"))
+				Expect(name).ToNot(HavePrefix("ram"))
+			}
+		})
+	})
+
+	Describe("GetProcessStats", func() {
+		It("returns process stats", func() {
+			stats, err := collector.GetProcessStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.Total > 0).To(BeTrue())
+			Expect(stats.Running > 0).To(BeTrue())
+		})
+	})
+
+	Describe("GetFileDescriptorStats", func() {
+		It("returns file descriptor stats", func() {
+			usage, err := collector.GetFileDescriptorStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(usage.Total > 0).To(BeTrue())
+			Expect(usage.Used > 0).To(BeTrue())
+		})
+	})
+
+	Describe("GetUptimeStats", func() {
+		It("returns uptime stats", func() {
+			stats, err := collector.GetUptimeStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.Secs > 0).To(BeTrue())
+		})
+	})
 })
@@ -23,12 +23,52 @@ type DiskStats struct {
 	InodeUsage Usage
 }
 
+// NetworkStats are cumulative counters for a single network interface
+type NetworkStats struct {
+	RxBytes   uint64
+	RxPackets uint64
+	RxErrors  uint64
+	RxDropped uint64
+	TxBytes   uint64
+	TxPackets uint64
+	TxErrors  uint64
+	TxDropped uint64
+}
+
+// DiskIOStats are cumulative counters for a single block device
+type DiskIOStats struct {
+	ReadsCompleted  uint64
+	ReadTimeMs      uint64
+	WritesCompleted uint64
+	WriteTimeMs     uint64
+}
+
+type ProcessStats struct {
+	Total   uint64
+	Running uint64
+	Zombies uint64
+}
+
+type UptimeStats struct {
+	Secs uint64
+}
+
 type StatsCollector interface {
 	GetCPULoad() (load CPULoad, err error)
 	GetCPUStats() (stats CPUStats, err error)
 	GetMemStats() (usage Usage, err error)
 	GetSwapStats() (usage Usage, err error)
 	GetDiskStats(mountedPath string) (stats DiskStats, err error)
+
+	// Network and disk I/O stats are keyed by interface and device name
+	GetNetworkStats() (stats map[string]NetworkStats, err error)
+	GetDiskIOStats() (stats map[string]DiskIOStats, err error)
+	GetProcessStats() (stats ProcessStats, err error)
+
+	// Used is the number of allocated file descriptors
+	// and Total is the system-wide maximum
+	GetFileDescriptorStats() (usage Usage, err error)
+	GetUptimeStats() (stats UptimeStats, err error)
 }
 
 func (cpuStats CPUStats) UserPercent() Percentage {
@@ -0,0 +1,40 @@
+package fakes
+
+import (
+	"time"
+
+	boshvitals "bosh/platform/vitals"
+)
+
+type FakeHistory struct {
+	Started bool
+	Stopped bool
+
+	RecordCalled bool
+	RecordErr    error
+
+	SamplesFrom       time.Time
+	SamplesTo         time.Time
+	SamplesResolution time.Duration
+	SamplesSamples    []boshvitals.Sample
+}
+
+func (h *FakeHistory) Start() {
+	h.Started = true
+}
+
+func (h *FakeHistory) Stop() {
+	h.Stopped = true
+}
+
+func (h *FakeHistory) Record() error {
+	h.RecordCalled = true
+	return h.RecordErr
+}
+
+func (h *FakeHistory) Samples(from, to time.Time, resolution time.Duration) []boshvitals.Sample {
+	h.SamplesFrom = from
+	h.SamplesTo = to
+	h.SamplesResolution = resolution
+	return h.SamplesSamples
+}
@@ -5,6 +5,10 @@ import boshvitals "bosh/platform/vitals"
 type FakeService struct {
 	GetVitals boshvitals.Vitals
 	GetErr    error
+
+	GetExtendedConsumer string
+	GetExtendedVitals   boshvitals.Vitals
+	GetExtendedErr      error
 }
 
 func NewFakeService() (fakeService *FakeService) {
@@ -17,3 +21,10 @@ func (s *FakeService) Get() (vitals boshvitals.Vitals, err error) {
 	err = s.GetErr
 	return
 }
+
+func (s *FakeService) GetExtended(consumer string) (vitals boshvitals.Vitals, err error) {
+	s.GetExtendedConsumer = consumer
+	vitals = s.GetExtendedVitals
+	err = s.GetExtendedErr
+	return
+}
@@ -0,0 +1,235 @@
+package vitals
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+	boshtime "bosh/time"
+)
+
+const (
+	historyLogTag          = "vitalsHistory"
+	defaultHistoryCapacity = 1440
+)
+
+type HistoryOptions struct {
+	// How often vitals are sampled; sampling is disabled when zero
+	IntervalSeconds int
+
+	// Number of samples kept in memory; defaults to 1440
+	Capacity int
+
+	// When set, samples are saved to and restored from this file
+	Path string
+}
+
+type Sample struct {
+	Time   time.Time `json:"time"`
+	Vitals Vitals    `json:"vitals"`
+}
+
+type History interface {
+	// Start samples vitals until Stop is called
+	Start()
+	Stop()
+
+	// Record takes a single sample
+	Record() error
+
+	// Samples returns samples taken within [from, to] in chronological order.
+	// When resolution is non-zero only the first sample of each
+	// resolution-wide window starting at from is returned.
+	Samples(from, to time.Time, resolution time.Duration) []Sample
+}
+
+type concreteHistory struct {
+	vitalsService Service
+	fs            boshsys.FileSystem
+	timeService   boshtime.Service
+	options       HistoryOptions
+	logger        boshlog.Logger
+
+	// Ring buffer; next is the index the next sample is written to
+	samples     []Sample
+	next        int
+	count       int
+	samplesLock sync.Mutex
+
+	// Serializes writes so that older snapshots do not overwrite newer ones
+	saveLock sync.Mutex
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func NewHistory(
+	vitalsService Service,
+	fs boshsys.FileSystem,
+	timeService boshtime.Service,
+	options HistoryOptions,
+	logger boshlog.Logger,
+) *concreteHistory {
+	capacity := options.Capacity
+	if capacity <= 0 {
+		capacity = defaultHistoryCapacity
+	}
+
+	return &concreteHistory{
+		vitalsService: vitalsService,
+		fs:            fs,
+		timeService:   timeService,
+		options:       options,
+		logger:        logger,
+		samples:       make([]Sample, capacity),
+		stopCh:        make(chan struct{}),
+	}
+}
+
+func (h *concreteHistory) Start() {
+	if h.options.IntervalSeconds <= 0 {
+		return
+	}
+
+	err := h.load()
+	if err != nil {
+		h.logger.Error(historyLogTag, "Failed to load vitals history: %s", err.Error())
+	}
+
+	ticker := time.NewTicker(time.Duration(h.options.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := h.Record()
+			if err != nil {
+				h.logger.Error(historyLogTag, "Failed to record vitals: %s", err.Error())
+			}
+		case <-h.stopCh:
+			return
+		}
+	}
+}
+
+func (h *concreteHistory) Stop() {
+	h.stopOnce.Do(func() { close(h.stopCh) })
+}
+
+func (h *concreteHistory) Record() error {
+	vitals, err := h.vitalsService.GetExtended("history")
+	if err != nil {
+		return bosherr.WrapError(err, "Getting vitals")
+	}
+
+	h.samplesLock.Lock()
+	h.add(Sample{Time: h.timeService.Now(), Vitals: vitals})
+	h.samplesLock.Unlock()
+
+	if h.options.Path == "" {
+		return nil
+	}
+
+	err = h.save()
+	if err != nil {
+		return bosherr.WrapError(err, "Saving vitals history")
+	}
+
+	return nil
+}
+
+func (h *concreteHistory) Samples(from, to time.Time, resolution time.Duration) []Sample {
+	h.samplesLock.Lock()
+	defer h.samplesLock.Unlock()
+
+	samples := []Sample{}
+	lastWindow := int64(-1)
+
+	for _, sample := range h.ordered() {
+		if sample.Time.Before(from) || sample.Time.After(to) {
+			continue
+		}
+
+		if resolution > 0 {
+			window := int64(sample.Time.Sub(from) / resolution)
+			if window == lastWindow {
+				continue
+			}
+			lastWindow = window
+		}
+
+		samples = append(samples, sample)
+	}
+
+	return samples
+}
+
+// add must be called with samplesLock held
+func (h *concreteHistory) add(sample Sample) {
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % len(h.samples)
+
+	if h.count < len(h.samples) {
+		h.count++
+	}
+}
+
+// ordered must be called with samplesLock held
+func (h *concreteHistory) ordered() []Sample {
+	ordered := make([]Sample, 0, h.count)
+	first := (h.next - h.count + len(h.samples)) % len(h.samples)
+
+	for i := 0; i < h.count; i++ {
+		ordered = append(ordered, h.samples[(first+i)%len(h.samples)])
+	}
+
+	return ordered
+}
+
+func (h *concreteHistory) load() error {
+	if h.options.Path == "" || !h.fs.FileExists(h.options.Path) {
+		return nil
+	}
+
+	bytes, err := h.fs.ReadFile(h.options.Path)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading history file")
+	}
+
+	var samples []Sample
+
+	err = json.Unmarshal(bytes, &samples)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling history file")
+	}
+
+	h.samplesLock.Lock()
+	defer h.samplesLock.Unlock()
+
+	for _, sample := range samples {
+		h.add(sample)
+	}
+
+	return nil
+}
+
+// save writes a snapshot of samples without holding samplesLock
+// so that Samples is not blocked by slow disks
+func (h *concreteHistory) save() error {
+	h.saveLock.Lock()
+	defer h.saveLock.Unlock()
+
+	h.samplesLock.Lock()
+	samples := h.ordered()
+	h.samplesLock.Unlock()
+
+	bytes, err := json.Marshal(samples)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling samples")
+	}
+
+	return h.fs.WriteFile(h.options.Path, bytes)
+}
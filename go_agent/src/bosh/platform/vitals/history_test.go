@@ -0,0 +1,158 @@
+package vitals_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "bosh/logger"
+	. "bosh/platform/vitals"
+	fakevitals "bosh/platform/vitals/fakes"
+	fakesys "bosh/system/fakes"
+	faketime "bosh/time/fakes"
+)
+
+var _ = Describe("concreteHistory", func() {
+	var (
+		vitalsService *fakevitals.FakeService
+		fs            *fakesys.FakeFileSystem
+		timeService   *faketime.FakeService
+		options       HistoryOptions
+		history       History
+	)
+
+	BeforeEach(func() {
+		vitalsService = fakevitals.NewFakeService()
+		fs = fakesys.NewFakeFileSystem()
+		timeService = &faketime.FakeService{}
+		options = HistoryOptions{IntervalSeconds: 10, Capacity: 3}
+	})
+
+	JustBeforeEach(func() {
+		history = NewHistory(vitalsService, fs, timeService, options, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	recordAt := func(secs int64, load string) {
+		timeService.NowTime = time.Unix(secs, 0)
+		vitalsService.GetExtendedVitals = Vitals{Load: []string{load, load, load}}
+		err := history.Record()
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	loads := func(samples []Sample) []string {
+		loads := []string{}
+		for _, sample := range samples {
+			loads = append(loads, sample.Vitals.Load[0])
+		}
+		return loads
+	}
+
+	Describe("Record", func() {
+		It("returns error when vitals cannot be retrieved", func() {
+			vitalsService.GetExtendedErr = errors.New("fake-get-extended-err")
+
+			err := history.Record()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-extended-err"))
+		})
+
+		It("keeps its own rate baseline", func() {
+			recordAt(10, "1")
+			Expect(vitalsService.GetExtendedConsumer).To(Equal("history"))
+		})
+
+		It("drops oldest samples when capacity is reached", func() {
+			recordAt(10, "1")
+			recordAt(20, "2")
+			recordAt(30, "3")
+			recordAt(40, "4")
+
+			samples := history.Samples(time.Unix(0, 0), time.Unix(100, 0), 0)
+			Expect(loads(samples)).To(Equal([]string{"2", "3", "4"}))
+			Expect(samples[0].Time).To(Equal(time.Unix(20, 0)))
+		})
+
+		Context("when path is set", func() {
+			BeforeEach(func() {
+				options.Path = "/fake-history.json"
+			})
+
+			It("saves samples to file", func() {
+				recordAt(10, "1")
+
+				content, err := fs.ReadFileString("/fake-history.json")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(content).To(ContainSubstring(`"load":["1","1","1"]`))
+			})
+
+			It("returns error when file cannot be written", func() {
+				fs.WriteToFileError = errors.New("fake-write-err")
+
+				timeService.NowTime = time.Unix(10, 0)
+				err := history.Record()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+			})
+		})
+	})
+
+	Describe("Samples", func() {
+		BeforeEach(func() {
+			options.Capacity = 10
+		})
+
+		JustBeforeEach(func() {
+			recordAt(10, "1")
+			recordAt(20, "2")
+			recordAt(30, "3")
+			recordAt(40, "4")
+			recordAt(50, "5")
+		})
+
+		It("returns samples within time range inclusively", func() {
+			samples := history.Samples(time.Unix(20, 0), time.Unix(40, 0), 0)
+			Expect(loads(samples)).To(Equal([]string{"2", "3", "4"}))
+		})
+
+		It("returns empty list when no samples are within time range", func() {
+			samples := history.Samples(time.Unix(100, 0), time.Unix(200, 0), 0)
+			Expect(samples).To(Equal([]Sample{}))
+		})
+
+		It("returns first sample of each window when resolution is set", func() {
+			samples := history.Samples(time.Unix(10, 0), time.Unix(50, 0), 25*time.Second)
+			Expect(loads(samples)).To(Equal([]string{"1", "4"}))
+		})
+	})
+
+	Describe("Start", func() {
+		Context("when path is set and file exists", func() {
+			BeforeEach(func() {
+				options.IntervalSeconds = 3600
+				options.Path = "/fake-history.json"
+				fs.WriteFileString("/fake-history.json", `[
+					{"time": "1970-01-01T00:00:10Z", "vitals": {"load": ["1", "1", "1"]}},
+					{"time": "1970-01-01T00:00:20Z", "vitals": {"load": ["2", "2", "2"]}}
+				]`)
+			})
+
+			It("restores samples from file", func() {
+				go history.Start()
+				defer history.Stop()
+
+				Eventually(func() []Sample {
+					return history.Samples(time.Unix(0, 0), time.Unix(100, 0), 0)
+				}).Should(HaveLen(2))
+			})
+		})
+
+		It("can be stopped more than once", func() {
+			go history.Start()
+
+			history.Stop()
+			history.Stop()
+		})
+	})
+})
@@ -1,29 +1,57 @@
 package vitals
 
 import (
+	"fmt"
+	"sync"
+	"time"
+
 	bosherr "bosh/errors"
 	boshstats "bosh/platform/stats"
 	boshdirs "bosh/settings/directories"
-	"fmt"
+	boshtime "bosh/time"
 )
 
 type Service interface {
 	Get() (vitals Vitals, err error)
+
+	// GetExtended additionally includes network, disk I/O, process,
+	// file descriptor and uptime vitals. Network and disk I/O rates
+	// are calculated since the previous call by the same consumer
+	// (e.g. "heartbeat") so that consumers polling at different
+	// intervals do not affect each other's rates.
+	GetExtended(consumer string) (vitals Vitals, err error)
 }
 
 type concreteService struct {
 	statsCollector boshstats.StatsCollector
 	dirProvider    boshdirs.DirectoriesProvider
+	timeService    boshtime.Service
+
+	// Previous counters used to calculate rates by consumer
+	lastSamples     map[string]*countersSample
+	lastSamplesLock sync.Mutex
 }
 
-func NewService(statsCollector boshstats.StatsCollector, dirProvider boshdirs.DirectoriesProvider) Service {
-	return concreteService{
+type countersSample struct {
+	takenAt time.Time
+	network map[string]boshstats.NetworkStats
+	diskIO  map[string]boshstats.DiskIOStats
+}
+
+func NewService(
+	statsCollector boshstats.StatsCollector,
+	dirProvider boshdirs.DirectoriesProvider,
+	timeService boshtime.Service,
+) Service {
+	return &concreteService{
 		statsCollector: statsCollector,
 		dirProvider:    dirProvider,
+		timeService:    timeService,
+		lastSamples:    map[string]*countersSample{},
 	}
 }
 
-func (s concreteService) Get() (vitals Vitals, err error) {
+func (s *concreteService) Get() (vitals Vitals, err error) {
 	var (
 		loadStats boshstats.CPULoad
 		cpuStats  boshstats.CPUStats
@@ -80,7 +108,7 @@ func (s concreteService) Get() (vitals Vitals, err error) {
 	return
 }
 
-func (s concreteService) getDiskStats() (diskStats DiskVitals, err error) {
+func (s *concreteService) getDiskStats() (diskStats DiskVitals, err error) {
 	disks := map[string]string{
 		"/": "system",
 		s.dirProvider.DataDir():  "ephemeral",
@@ -98,7 +126,7 @@ func (s concreteService) getDiskStats() (diskStats DiskVitals, err error) {
 	return
 }
 
-func (s concreteService) addDiskStats(diskStats DiskVitals, path, name string) (updated DiskVitals, err error) {
+func (s *concreteService) addDiskStats(diskStats DiskVitals, path, name string) (updated DiskVitals, err error) {
 	updated = diskStats
 
 	stat, diskErr := s.statsCollector.GetDiskStats(path)
@@ -116,6 +144,141 @@ func (s concreteService) addDiskStats(diskStats DiskVitals, path, name string) (
 	return
 }
 
+func (s *concreteService) GetExtended(consumer string) (vitals Vitals, err error) {
+	vitals, err = s.Get()
+	if err != nil {
+		return
+	}
+
+	uptimeStats, err := s.statsCollector.GetUptimeStats()
+	if err != nil {
+		err = bosherr.WrapError(err, "Getting Uptime Stats")
+		return
+	}
+
+	processStats, err := s.statsCollector.GetProcessStats()
+	if err != nil {
+		err = bosherr.WrapError(err, "Getting Process Stats")
+		return
+	}
+
+	fdStats, err := s.statsCollector.GetFileDescriptorStats()
+	if err != nil {
+		err = bosherr.WrapError(err, "Getting File Descriptor Stats")
+		return
+	}
+
+	vitals.Network, vitals.DiskIO, err = s.getRateVitals(consumer, uptimeStats)
+	if err != nil {
+		return
+	}
+
+	vitals.Processes = &ProcessVitals{
+		Total:   fmt.Sprintf("%d", processStats.Total),
+		Running: fmt.Sprintf("%d", processStats.Running),
+		Zombies: fmt.Sprintf("%d", processStats.Zombies),
+	}
+
+	vitals.FileDescriptors = &FileDescriptorVitals{
+		Open:    fmt.Sprintf("%d", fdStats.Used),
+		Max:     fmt.Sprintf("%d", fdStats.Total),
+		Percent: fdStats.Percent().FormatFractionOf100(0),
+	}
+
+	vitals.Uptime = &UptimeVitals{
+		Secs: fmt.Sprintf("%d", uptimeStats.Secs),
+	}
+
+	return
+}
+
+func (s *concreteService) getRateVitals(consumer string, uptimeStats boshstats.UptimeStats) (NetworkVitals, DiskIOVitals, error) {
+	networkStats, err := s.statsCollector.GetNetworkStats()
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Getting Network Stats")
+	}
+
+	diskIOStats, err := s.statsCollector.GetDiskIOStats()
+	if err != nil {
+		return nil, nil, bosherr.WrapError(err, "Getting Disk IO Stats")
+	}
+
+	sample := &countersSample{
+		takenAt: s.timeService.Now(),
+		network: networkStats,
+		diskIO:  diskIOStats,
+	}
+
+	s.lastSamplesLock.Lock()
+	previous := s.lastSamples[consumer]
+	s.lastSamples[consumer] = sample
+	s.lastSamplesLock.Unlock()
+
+	// Without a previous sample counters are averaged since boot
+	if previous == nil {
+		previous = &countersSample{
+			takenAt: sample.takenAt.Add(-time.Duration(uptimeStats.Secs) * time.Second),
+		}
+	}
+
+	elapsedSecs := sample.takenAt.Sub(previous.takenAt).Seconds()
+
+	networkVitals := make(NetworkVitals, len(networkStats))
+
+	for name, current := range networkStats {
+		prev := previous.network[name]
+
+		networkVitals[name] = SpecificNetworkVitals{
+			RxBytes:   formatRate(current.RxBytes, prev.RxBytes, elapsedSecs),
+			RxPackets: formatRate(current.RxPackets, prev.RxPackets, elapsedSecs),
+			RxErrors:  formatRate(current.RxErrors, prev.RxErrors, elapsedSecs),
+			RxDropped: formatRate(current.RxDropped, prev.RxDropped, elapsedSecs),
+			TxBytes:   formatRate(current.TxBytes, prev.TxBytes, elapsedSecs),
+			TxPackets: formatRate(current.TxPackets, prev.TxPackets, elapsedSecs),
+			TxErrors:  formatRate(current.TxErrors, prev.TxErrors, elapsedSecs),
+			TxDropped: formatRate(current.TxDropped, prev.TxDropped, elapsedSecs),
+		}
+	}
+
+	diskIOVitals := make(DiskIOVitals, len(diskIOStats))
+
+	for name, current := range diskIOStats {
+		prev := previous.diskIO[name]
+
+		diskIOVitals[name] = SpecificDiskIOVitals{
+			ReadIOPS:       formatRate(current.ReadsCompleted, prev.ReadsCompleted, elapsedSecs),
+			WriteIOPS:      formatRate(current.WritesCompleted, prev.WritesCompleted, elapsedSecs),
+			ReadLatencyMs:  formatLatency(current.ReadTimeMs, prev.ReadTimeMs, current.ReadsCompleted, prev.ReadsCompleted),
+			WriteLatencyMs: formatLatency(current.WriteTimeMs, prev.WriteTimeMs, current.WritesCompleted, prev.WritesCompleted),
+		}
+	}
+
+	return networkVitals, diskIOVitals, nil
+}
+
+// counterDelta treats a decreasing counter as reset (e.g. interface re-created)
+func counterDelta(current, previous uint64) uint64 {
+	if current < previous {
+		return current
+	}
+	return current - previous
+}
+
+func formatRate(current, previous uint64, elapsedSecs float64) string {
+	if elapsedSecs <= 0 {
+		return "0.00"
+	}
+	return fmt.Sprintf("%.2f", float64(counterDelta(current, previous))/elapsedSecs)
+}
+
+func formatLatency(currentMs, previousMs, currentOps, previousOps uint64) string {
+	ops := counterDelta(currentOps, previousOps)
+	if ops == 0 {
+		return "0.00"
+	}
+	return fmt.Sprintf("%.2f", float64(counterDelta(currentMs, previousMs))/float64(ops))
+}
+
 func createMemVitals(memUsage boshstats.Usage) MemoryVitals {
 	return MemoryVitals{
 		Percent: memUsage.Percent().FormatFractionOf100(0),
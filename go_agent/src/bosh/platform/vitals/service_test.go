@@ -1,6 +1,9 @@
 package vitals_test
 
 import (
+	"errors"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -9,9 +12,19 @@ import (
 	fakestats "bosh/platform/stats/fakes"
 	. "bosh/platform/vitals"
 	boshdirs "bosh/settings/directories"
+	faketime "bosh/time/fakes"
 )
 
 func buildVitalsService() (statsCollector *fakestats.FakeStatsCollector, service Service) {
+	statsCollector, _, service = buildVitalsServiceWithTime()
+	return
+}
+
+func buildVitalsServiceWithTime() (
+	statsCollector *fakestats.FakeStatsCollector,
+	timeService *faketime.FakeService,
+	service Service,
+) {
 	dirProvider := boshdirs.NewDirectoriesProvider("/fake/base/dir")
 	statsCollector = &fakestats.FakeStatsCollector{
 		CPULoad: boshstats.CPULoad{
@@ -49,9 +62,34 @@ func buildVitalsService() (statsCollector *fakestats.FakeStatsCollector, service
 		},
 	}
 
-	service = NewService(statsCollector, dirProvider)
+	statsCollector.NetworkStats = map[string]boshstats.NetworkStats{
+		"eth0": boshstats.NetworkStats{
+			RxBytes:   1000,
+			RxPackets: 100,
+			RxErrors:  10,
+			TxBytes:   2000,
+			TxPackets: 200,
+			TxDropped: 20,
+		},
+	}
+	statsCollector.DiskIOStats = map[string]boshstats.DiskIOStats{
+		"sda": boshstats.DiskIOStats{
+			ReadsCompleted:  50,
+			ReadTimeMs:      100,
+			WritesCompleted: 20,
+			WriteTimeMs:     80,
+		},
+	}
+	statsCollector.ProcessStats = boshstats.ProcessStats{Total: 120, Running: 3, Zombies: 2}
+	statsCollector.FileDescriptorStats = boshstats.Usage{Used: 512, Total: 2048}
+	statsCollector.UptimeStats = boshstats.UptimeStats{Secs: 10}
+
+	timeService = &faketime.FakeService{NowTime: time.Now()}
+
+	service = NewService(statsCollector, dirProvider, timeService)
 	return
 }
+
 func init() {
 	Describe("Testing with Ginkgo", func() {
 		It("vitals construction", func() {
@@ -118,4 +156,158 @@ func init() {
 			Expect(err).To(HaveOccurred())
 		})
 	})
+
+	Describe("GetExtended", func() {
+		var (
+			statsCollector *fakestats.FakeStatsCollector
+			timeService    *faketime.FakeService
+			service        Service
+		)
+
+		BeforeEach(func() {
+			statsCollector, timeService, service = buildVitalsServiceWithTime()
+		})
+
+		It("includes basic vitals", func() {
+			vitals, err := service.GetExtended("fake-consumer")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vitals.Load).To(Equal([]string{"0.20", "4.55", "1.12"}))
+			Expect(vitals.Mem).To(Equal(MemoryVitals{Kb: "700", Percent: "70"}))
+		})
+
+		It("includes processes, file descriptors and uptime", func() {
+			vitals, err := service.GetExtended("fake-consumer")
+			Expect(err).ToNot(HaveOccurred())
+
+			boshassert.MatchesJSONMap(GinkgoT(), vitals.Processes, map[string]interface{}{
+				"total":   "120",
+				"running": "3",
+				"zombies": "2",
+			})
+			boshassert.MatchesJSONMap(GinkgoT(), vitals.FileDescriptors, map[string]interface{}{
+				"open":    "512",
+				"max":     "2048",
+				"percent": "25",
+			})
+			boshassert.MatchesJSONMap(GinkgoT(), vitals.Uptime, map[string]interface{}{
+				"secs": "10",
+			})
+		})
+
+		It("calculates network and disk I/O rates since boot on first call", func() {
+			vitals, err := service.GetExtended("fake-consumer")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vitals.Network).To(Equal(NetworkVitals{
+				"eth0": SpecificNetworkVitals{
+					RxBytes:   "100.00",
+					RxPackets: "10.00",
+					RxErrors:  "1.00",
+					RxDropped: "0.00",
+					TxBytes:   "200.00",
+					TxPackets: "20.00",
+					TxErrors:  "0.00",
+					TxDropped: "2.00",
+				},
+			}))
+
+			Expect(vitals.DiskIO).To(Equal(DiskIOVitals{
+				"sda": SpecificDiskIOVitals{
+					ReadIOPS:       "5.00",
+					WriteIOPS:      "2.00",
+					ReadLatencyMs:  "2.00",
+					WriteLatencyMs: "4.00",
+				},
+			}))
+		})
+
+		It("calculates network and disk I/O rates since previous call", func() {
+			_, err := service.GetExtended("fake-consumer")
+			Expect(err).ToNot(HaveOccurred())
+
+			timeService.NowTime = timeService.NowTime.Add(5 * time.Second)
+			statsCollector.NetworkStats = map[string]boshstats.NetworkStats{
+				"eth0": boshstats.NetworkStats{RxBytes: 1500, TxBytes: 2000},
+			}
+			statsCollector.DiskIOStats = map[string]boshstats.DiskIOStats{
+				"sda": boshstats.DiskIOStats{
+					ReadsCompleted:  60,
+					ReadTimeMs:      150,
+					WritesCompleted: 20,
+					WriteTimeMs:     80,
+				},
+			}
+
+			vitals, err := service.GetExtended("fake-consumer")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vitals.Network["eth0"].RxBytes).To(Equal("100.00"))
+			Expect(vitals.Network["eth0"].TxBytes).To(Equal("0.00"))
+
+			Expect(vitals.DiskIO["sda"]).To(Equal(SpecificDiskIOVitals{
+				ReadIOPS:       "2.00",
+				WriteIOPS:      "0.00",
+				ReadLatencyMs:  "5.00",
+				WriteLatencyMs: "0.00",
+			}))
+		})
+
+		It("calculates rates since previous call by the same consumer", func() {
+			_, err := service.GetExtended("fake-consumer")
+			Expect(err).ToNot(HaveOccurred())
+
+			timeService.NowTime = timeService.NowTime.Add(5 * time.Second)
+			statsCollector.NetworkStats = map[string]boshstats.NetworkStats{
+				"eth0": boshstats.NetworkStats{RxBytes: 1500, TxBytes: 2000},
+			}
+
+			// Rates of other consumer's first call are averaged since boot
+			vitals, err := service.GetExtended("fake-other-consumer")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vitals.Network["eth0"].RxBytes).To(Equal("150.00"))
+
+			timeService.NowTime = timeService.NowTime.Add(5 * time.Second)
+			statsCollector.NetworkStats = map[string]boshstats.NetworkStats{
+				"eth0": boshstats.NetworkStats{RxBytes: 3000, TxBytes: 2000},
+			}
+
+			vitals, err = service.GetExtended("fake-consumer")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vitals.Network["eth0"].RxBytes).To(Equal("200.00"))
+
+			vitals, err = service.GetExtended("fake-other-consumer")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vitals.Network["eth0"].RxBytes).To(Equal("300.00"))
+		})
+
+		It("treats decreasing counters as reset", func() {
+			_, err := service.GetExtended("fake-consumer")
+			Expect(err).ToNot(HaveOccurred())
+
+			timeService.NowTime = timeService.NowTime.Add(2 * time.Second)
+			statsCollector.NetworkStats = map[string]boshstats.NetworkStats{
+				"eth0": boshstats.NetworkStats{RxBytes: 10},
+			}
+
+			vitals, err := service.GetExtended("fake-consumer")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vitals.Network["eth0"].RxBytes).To(Equal("5.00"))
+		})
+
+		It("returns error when network stats cannot be retrieved", func() {
+			statsCollector.NetworkStatsErr = errors.New("fake-network-stats-err")
+
+			_, err := service.GetExtended("fake-consumer")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-network-stats-err"))
+		})
+
+		It("returns error when disk I/O stats cannot be retrieved", func() {
+			statsCollector.DiskIOStatsErr = errors.New("fake-disk-io-stats-err")
+
+			_, err := service.GetExtended("fake-consumer")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-disk-io-stats-err"))
+		})
+	})
 }
@@ -6,6 +6,13 @@ type Vitals struct {
 	Load []string     `json:"load,omitempty"`
 	Mem  MemoryVitals `json:"mem"`
 	Swap MemoryVitals `json:"swap"`
+
+	// Extended vitals are only included when explicitly requested
+	Network         NetworkVitals         `json:"network,omitempty"`
+	DiskIO          DiskIOVitals          `json:"disk_io,omitempty"`
+	Processes       *ProcessVitals        `json:"processes,omitempty"`
+	FileDescriptors *FileDescriptorVitals `json:"file_descriptors,omitempty"`
+	Uptime          *UptimeVitals         `json:"uptime,omitempty"`
 }
 
 type CPUVitals struct {
@@ -25,3 +32,43 @@ type MemoryVitals struct {
 	Kb      string `json:"kb,omitempty"`
 	Percent string `json:"percent,omitempty"`
 }
+
+// NetworkVitals are keyed by interface name; values are per second rates
+type NetworkVitals map[string]SpecificNetworkVitals
+
+type SpecificNetworkVitals struct {
+	RxBytes   string `json:"rx_bytes_per_sec"`
+	RxPackets string `json:"rx_packets_per_sec"`
+	RxErrors  string `json:"rx_errors_per_sec"`
+	RxDropped string `json:"rx_dropped_per_sec"`
+	TxBytes   string `json:"tx_bytes_per_sec"`
+	TxPackets string `json:"tx_packets_per_sec"`
+	TxErrors  string `json:"tx_errors_per_sec"`
+	TxDropped string `json:"tx_dropped_per_sec"`
+}
+
+// DiskIOVitals are keyed by device name (e.g. sda, xvda1)
+type DiskIOVitals map[string]SpecificDiskIOVitals
+
+type SpecificDiskIOVitals struct {
+	ReadIOPS       string `json:"read_iops"`
+	WriteIOPS      string `json:"write_iops"`
+	ReadLatencyMs  string `json:"read_latency_ms"`
+	WriteLatencyMs string `json:"write_latency_ms"`
+}
+
+type ProcessVitals struct {
+	Total   string `json:"total"`
+	Running string `json:"running"`
+	Zombies string `json:"zombies"`
+}
+
+type FileDescriptorVitals struct {
+	Open    string `json:"open"`
+	Max     string `json:"max"`
+	Percent string `json:"percent"`
+}
+
+type UptimeVitals struct {
+	Secs string `json:"secs"`
+}
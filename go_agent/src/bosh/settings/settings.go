@@ -1,5 +1,9 @@
 package settings
 
+import (
+	"strings"
+)
+
 const (
 	RootUsername        = "root"
 	VCAPUsername        = "vcap"
@@ -15,17 +19,97 @@ type Settings struct {
 	Networks  Networks  `json:"networks"`
 	Ntp       []string  `json:"ntp"`
 	Mbus      string    `json:"mbus"`
+	MbusTLS   MbusTLS   `json:"mbus_tls"`
 	VM        VM        `json:"vm"`
+
+	RequestSigning RequestSigning `json:"request_signing"`
+	BlobSigning    BlobSigning    `json:"blob_signing"`
+}
+
+// MbusTLS holds PEM encoded certificates used by TLS enabled message bus.
+// With https message bus the agent is the server: Certificate and PrivateKey
+// are served to clients and ClientCA is used to verify client certificates.
+type MbusTLS struct {
+	// CA certificates used to verify server; system CAs are used when empty
+	CA string `json:"ca"`
+
+	// Optional client certificate and private key
+	Certificate string `json:"certificate"`
+	PrivateKey  string `json:"private_key"`
+
+	// When set https message bus requires client certificates signed by it
+	ClientCA string `json:"client_ca"`
+}
+
+// RequestSigning holds keys used to verify signed agent requests.
+// When no keys are given requests are not required to be signed.
+type RequestSigning struct {
+	// Base64 encoded HMAC-SHA256 keys by key id
+	Keys map[string]string `json:"keys"`
+
+	// Maximum allowed difference between request timestamp and agent clock;
+	// defaults to 5 minutes
+	MaxClockSkewSeconds int `json:"max_clock_skew_seconds"`
+}
+
+// BlobSigning holds public keys used to verify detached signatures
+// of job and package blobs listed in the apply spec.
+type BlobSigning struct {
+	// PEM encoded RSA or ECDSA public keys by key id
+	TrustedKeys map[string]string `json:"trusted_keys"`
+
+	// Rejects unsigned blobs; otherwise only signed blobs are verified
+	Strict bool `json:"strict"`
 }
 
 const (
+	BlobstoreTypeDav   = "dav"
 	BlobstoreTypeDummy = "dummy"
 	BlobstoreTypeLocal = "local"
+	BlobstoreTypeS3    = "s3"
 )
 
+// GetMbusURLs returns all message bus URLs;
+// multiple URLs can be specified separated by commas
+func (s Settings) GetMbusURLs() []string {
+	urls := []string{}
+
+	for _, mbusURL := range strings.Split(s.Mbus, ",") {
+		mbusURL = strings.TrimSpace(mbusURL)
+		if mbusURL != "" {
+			urls = append(urls, mbusURL)
+		}
+	}
+
+	return urls
+}
+
 type Blobstore struct {
 	Type    string                 `json:"provider"`
 	Options map[string]interface{} `json:"options"`
+	Retry   BlobstoreRetry         `json:"retry"`
+
+	Encryption BlobstoreEncryption `json:"encryption"`
+}
+
+// BlobstoreRetry limits retries of failed blobstore operations
+type BlobstoreRetry struct {
+	// Number of attempts including the first one; defaults to 3
+	MaxAttempts int `json:"max_attempts"`
+
+	// No attempt is started after deadline; not limited by default
+	DeadlineSeconds int `json:"deadline_seconds"`
+}
+
+// BlobstoreEncryption enables client side encryption of uploaded blobs.
+// Blobs are not encrypted when no keys are given.
+type BlobstoreEncryption struct {
+	// Base64 encoded 128, 192 or 256 bit AES keys by key id
+	Keys map[string]string `json:"keys"`
+
+	// Key used to encrypt uploaded blobs; other keys are kept
+	// to decrypt blobs uploaded before key rotation
+	KeyID string `json:"key_id"`
 }
 
 type Disks struct {
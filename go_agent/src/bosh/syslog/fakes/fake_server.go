@@ -8,6 +8,7 @@ type FakeServer struct {
 	StartFirstSyslogMsg *boshsyslog.Msg
 	StartErr            error
 
+	Stopped bool
 	StopErr error
 }
 
@@ -20,5 +21,6 @@ func (s *FakeServer) Start(callback boshsyslog.CallbackFunc) error {
 }
 
 func (s *FakeServer) Stop() error {
+	s.Stopped = true
 	return s.StopErr
 }
@@ -4,6 +4,9 @@ import (
 	"time"
 )
 
+// Environment variable that relates child processes to the request that started them
+const CorrelationIDEnvVar = "BOSH_CORRELATION_ID"
+
 type Command struct {
 	Name       string
 	Args       []string
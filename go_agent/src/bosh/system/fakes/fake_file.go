@@ -0,0 +1,95 @@
+package fakes
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// FakeFile reads and writes contents of a file in FakeFileSystem
+type FakeFile struct {
+	path   string
+	fs     *FakeFileSystem
+	flag   int
+	offset int64
+	closed bool
+}
+
+func (f *FakeFile) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, errors.New("File is closed")
+	}
+
+	f.fs.filesLock.Lock()
+	defer f.fs.filesLock.Unlock()
+
+	content := f.fs.getOrCreateFile(f.path).Content
+
+	if f.offset >= int64(len(content)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, content[f.offset:])
+	f.offset += int64(n)
+
+	return n, nil
+}
+
+func (f *FakeFile) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, errors.New("File is closed")
+	}
+
+	f.fs.filesLock.Lock()
+	defer f.fs.filesLock.Unlock()
+
+	if f.fs.WriteToFileError != nil {
+		return 0, f.fs.WriteToFileError
+	}
+
+	stats := f.fs.getOrCreateFile(f.path)
+
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(stats.Content))
+	}
+
+	end := f.offset + int64(len(p))
+	if end > int64(len(stats.Content)) {
+		content := make([]byte, end)
+		copy(content, stats.Content)
+		stats.Content = content
+	}
+
+	copy(stats.Content[f.offset:], p)
+	f.offset = end
+
+	return len(p), nil
+}
+
+func (f *FakeFile) Seek(offset int64, whence int) (int64, error) {
+	f.fs.filesLock.Lock()
+	defer f.fs.filesLock.Unlock()
+
+	switch whence {
+	case 0:
+	case 1:
+		offset += f.offset
+	case 2:
+		offset += int64(len(f.fs.getOrCreateFile(f.path).Content))
+	default:
+		return 0, errors.New("Invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("Negative position")
+	}
+
+	f.offset = offset
+
+	return offset, nil
+}
+
+func (f *FakeFile) Close() error {
+	f.closed = true
+	return nil
+}
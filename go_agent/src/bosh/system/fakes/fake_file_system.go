@@ -12,6 +12,7 @@ import (
 	gouuid "github.com/nu7hatch/gouuid"
 
 	bosherr "bosh/errors"
+	boshsys "bosh/system"
 )
 
 type FakeFileType string
@@ -56,6 +57,8 @@ type FakeFileSystem struct {
 	TempDirDir   string
 	TempDirError error
 
+	OpenFileErr error
+
 	GlobErr  error
 	globsMap map[string][][]string
 }
@@ -211,6 +214,32 @@ func (fs *FakeFileSystem) ReadFile(path string) ([]byte, error) {
 	return nil, errors.New("File not found")
 }
 
+func (fs *FakeFileSystem) OpenFile(path string, flag int, perm os.FileMode) (boshsys.File, error) {
+	fs.filesLock.Lock()
+	defer fs.filesLock.Unlock()
+
+	if fs.OpenFileErr != nil {
+		return nil, fs.OpenFileErr
+	}
+
+	stats := fs.files[path]
+	if stats == nil {
+		if flag&os.O_CREATE == 0 {
+			return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+		}
+
+		stats = fs.getOrCreateFile(path)
+		stats.FileType = FakeFileTypeFile
+		stats.FileMode = perm
+	}
+
+	if flag&os.O_TRUNC != 0 {
+		stats.Content = nil
+	}
+
+	return &FakeFile{path: path, fs: fs, flag: flag}, nil
+}
+
 func (fs *FakeFileSystem) FileExists(path string) bool {
 	return fs.GetFileTestStat(path) != nil
 }
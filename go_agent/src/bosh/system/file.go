@@ -0,0 +1,13 @@
+package system
+
+import (
+	"io"
+)
+
+// File allows streaming contents instead of loading them into memory
+type File interface {
+	io.Reader
+	io.Writer
+	io.Seeker
+	io.Closer
+}
@@ -22,6 +22,9 @@ type FileSystem interface {
 	ReadFileString(path string) (content string, err error)
 	ReadFile(path string) (content []byte, err error)
 
+	// OpenFile takes the same flags as os.OpenFile
+	OpenFile(path string, flag int, perm os.FileMode) (file File, err error)
+
 	FileExists(path string) bool
 
 	Rename(oldPath, newPath string) (err error)
@@ -236,6 +236,17 @@ func (fs osFileSystem) CopyFile(srcPath, dstPath string) (err error) {
 	return
 }
 
+func (fs osFileSystem) OpenFile(path string, flag int, perm os.FileMode) (File, error) {
+	fs.logger.Debug(fs.logTag, "Opening file %s", path)
+
+	file, err := os.OpenFile(path, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
 func (fs osFileSystem) TempFile(prefix string) (file *os.File, err error) {
 	fs.logger.Debug(fs.logTag, "Creating temp file with prefix %s", prefix)
 	return ioutil.TempFile("", prefix)
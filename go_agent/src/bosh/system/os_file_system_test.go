@@ -182,6 +182,39 @@ func init() {
 			Expect("some contents").To(Equal(string(content)))
 		})
 
+		It("open file", func() {
+			osFs, _ := createOsFs()
+			testPath := filepath.Join(os.TempDir(), "OpenFileTestFile")
+			defer os.Remove(testPath)
+
+			file, err := osFs.OpenFile(testPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0600))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = io.WriteString(file, "some contents")
+			Expect(err).ToNot(HaveOccurred())
+			file.Close()
+
+			file, err = osFs.OpenFile(testPath, os.O_RDONLY, 0)
+			Expect(err).ToNot(HaveOccurred())
+			defer file.Close()
+
+			_, err = file.Seek(5, 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			buf := &bytes.Buffer{}
+			_, err = io.Copy(buf, file)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.String()).To(Equal("contents"))
+		})
+
+		It("open file returns error when file does not exist", func() {
+			osFs, _ := createOsFs()
+
+			_, err := osFs.OpenFile(filepath.Join(os.TempDir(), "OpenFileMissingTestFile"), os.O_RDONLY, 0)
+			Expect(err).To(HaveOccurred())
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
 		It("file exists", func() {
 			osFs, _ := createOsFs()
 			testPath := filepath.Join(os.TempDir(), "FileExistsTestFile")
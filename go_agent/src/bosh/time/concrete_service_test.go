@@ -1,6 +1,8 @@
 package time_test
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -16,4 +18,13 @@ var _ = Describe("concreteService", func() {
 			Expect(float64(t2.Sub(t1))).To(BeNumerically(">", 0))
 		})
 	})
+
+	Describe("Sleep", func() {
+		It("blocks for given duration", func() {
+			service := NewConcreteService()
+			t1 := service.Now()
+			service.Sleep(10 * time.Millisecond)
+			Expect(service.Now().Sub(t1)).To(BeNumerically(">=", 10*time.Millisecond))
+		})
+	})
 })
@@ -1,13 +1,31 @@
 package fakes
 
 import (
+	"sync"
 	"time"
 )
 
 type FakeService struct {
 	NowTime time.Time
+
+	// Sleep moves NowTime forward instead of blocking
+	SleepDurations []time.Duration
+
+	// Sleep may be called from multiple goroutines
+	lock sync.Mutex
 }
 
 func (f *FakeService) Now() time.Time {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
 	return f.NowTime
 }
+
+func (f *FakeService) Sleep(duration time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.SleepDurations = append(f.SleepDurations, duration)
+	f.NowTime = f.NowTime.Add(duration)
+}